@@ -12,13 +12,16 @@ import (
 	"github.com/cosmos/cosmos-sdk/x/auth"
 	"github.com/cosmos/cosmos-sdk/x/bank"
 	"github.com/cosmos/cosmos-sdk/x/genutil"
+	"github.com/cosmos/cosmos-sdk/x/gov"
 	"github.com/cosmos/cosmos-sdk/x/mint"
 
 	"github.com/cosmos/cosmos-sdk/x/params"
+	paramsclient "github.com/cosmos/cosmos-sdk/x/params/client"
 	"github.com/cosmos/cosmos-sdk/x/staking"
 
 	"github.com/ovrclk/akash/x/deployment"
 	"github.com/ovrclk/akash/x/market"
+	marketclient "github.com/ovrclk/akash/x/market/client"
 	"github.com/ovrclk/akash/x/provider"
 
 	"github.com/tendermint/tendermint/libs/log"
@@ -32,10 +35,15 @@ import (
 	distr "github.com/cosmos/cosmos-sdk/x/distribution"
 	"github.com/cosmos/cosmos-sdk/x/slashing"
 	"github.com/cosmos/cosmos-sdk/x/supply"
+	"github.com/cosmos/cosmos-sdk/x/upgrade"
+	upgradeclient "github.com/cosmos/cosmos-sdk/x/upgrade/client"
 )
 
 const (
 	appName = "akash"
+
+	// software upgrade plan that migrates the market store to version 2.
+	upgradeMarketStoreV2 = "market-store-v2"
 )
 
 var (
@@ -62,6 +70,17 @@ var (
 
 		params.AppModuleBasic{},
 
+		// governance proposals
+		gov.NewAppModuleBasic(
+			paramsclient.ProposalHandler,
+			distr.ProposalHandler,
+			upgradeclient.ProposalHandler,
+			marketclient.ProposalHandler,
+		),
+
+		// software upgrades
+		upgrade.AppModuleBasic{},
+
 		// akash
 		deployment.AppModuleBasic{},
 		market.AppModuleBasic{},
@@ -85,6 +104,8 @@ type AkashApp struct {
 		distr      distr.Keeper
 		slashing   slashing.Keeper
 		mint       mint.Keeper
+		gov        gov.Keeper
+		upgrade    upgrade.Keeper
 		deployment deployment.Keeper
 		market     market.Keeper
 		provider   provider.Keeper
@@ -127,6 +148,8 @@ func NewApp(
 		supply.StoreKey,
 		staking.StoreKey,
 		mint.StoreKey,
+		gov.StoreKey,
+		upgrade.StoreKey,
 		deployment.StoreKey,
 		market.StoreKey,
 		provider.StoreKey,
@@ -212,6 +235,12 @@ func NewApp(
 		auth.FeeCollectorName,
 	)
 
+	app.keeper.upgrade = upgrade.NewKeeper(
+		map[int64]bool{},
+		keys[upgrade.StoreKey],
+		cdc,
+	)
+
 	app.keeper.deployment = deployment.NewKeeper(
 		cdc,
 		keys[deployment.StoreKey],
@@ -220,13 +249,35 @@ func NewApp(
 	app.keeper.market = market.NewKeeper(
 		cdc,
 		keys[market.StoreKey],
+		app.keeper.params.Subspace(market.DefaultParamspace),
+		app.keeper.bank,
+		app.keeper.supply,
 	)
 
+	app.keeper.upgrade.SetUpgradeHandler(upgradeMarketStoreV2, app.keeper.market.UpgradeHandler())
+
 	app.keeper.provider = provider.NewKeeper(
 		cdc,
 		keys[provider.StoreKey],
 	)
 
+	govRouter := gov.NewRouter()
+	govRouter.
+		AddRoute(gov.RouterKey, gov.ProposalHandler).
+		AddRoute(params.RouterKey, params.NewParamChangeProposalHandler(app.keeper.params)).
+		AddRoute(distr.RouterKey, distr.NewCommunityPoolSpendProposalHandler(app.keeper.distr)).
+		AddRoute(upgrade.RouterKey, upgrade.NewSoftwareUpgradeProposalHandler(app.keeper.upgrade)).
+		AddRoute(market.RouterKey, market.NewProposalHandler(app.keeper.market))
+
+	app.keeper.gov = gov.NewKeeper(
+		cdc,
+		keys[gov.StoreKey],
+		app.keeper.params.Subspace(gov.DefaultParamspace).WithKeyTable(gov.ParamKeyTable()),
+		app.keeper.supply,
+		&app.keeper.staking,
+		govRouter,
+	)
+
 	app.mm = module.NewManager(
 		genutil.NewAppModule(app.keeper.acct, app.keeper.staking, app.BaseApp.DeliverTx),
 		auth.NewAppModule(app.keeper.acct),
@@ -240,6 +291,10 @@ func NewApp(
 
 		staking.NewAppModule(app.keeper.staking, app.keeper.acct, app.keeper.supply),
 
+		gov.NewAppModule(app.keeper.gov, app.keeper.acct, app.keeper.supply),
+
+		upgrade.NewAppModule(app.keeper.upgrade),
+
 		// akash
 		deployment.NewAppModule(
 			app.keeper.deployment,
@@ -257,8 +312,8 @@ func NewApp(
 		provider.NewAppModule(app.keeper.provider, app.keeper.bank),
 	)
 
-	app.mm.SetOrderBeginBlockers(mint.ModuleName, distr.ModuleName, slashing.ModuleName)
-	app.mm.SetOrderEndBlockers(staking.ModuleName, deployment.ModuleName, market.ModuleName)
+	app.mm.SetOrderBeginBlockers(upgrade.ModuleName, mint.ModuleName, distr.ModuleName, slashing.ModuleName)
+	app.mm.SetOrderEndBlockers(gov.ModuleName, staking.ModuleName, deployment.ModuleName, market.ModuleName)
 
 	// NOTE: The genutils module must occur after staking so that pools are
 	//       properly initialized with tokens from genesis accounts.
@@ -268,6 +323,7 @@ func NewApp(
 		auth.ModuleName,
 		bank.ModuleName,
 		slashing.ModuleName,
+		gov.ModuleName,
 		mint.ModuleName,
 		supply.ModuleName,
 		genutil.ModuleName,
@@ -3,9 +3,11 @@ package app
 import (
 	"github.com/cosmos/cosmos-sdk/x/auth"
 	distr "github.com/cosmos/cosmos-sdk/x/distribution"
+	"github.com/cosmos/cosmos-sdk/x/gov"
 	"github.com/cosmos/cosmos-sdk/x/mint"
 	"github.com/cosmos/cosmos-sdk/x/staking"
 	"github.com/cosmos/cosmos-sdk/x/supply"
+	"github.com/ovrclk/akash/x/market"
 )
 
 func macPerms() map[string][]string {
@@ -15,7 +17,8 @@ func macPerms() map[string][]string {
 		mint.ModuleName:           {supply.Minter},
 		staking.BondedPoolName:    {supply.Burner, supply.Staking},
 		staking.NotBondedPoolName: {supply.Burner, supply.Staking},
-		// gov.ModuleName:            {supply.Burner},
+		market.ModuleName:         nil,
+		gov.ModuleName:            {supply.Burner},
 	}
 }
 
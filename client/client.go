@@ -122,6 +122,13 @@ func (c *qclient) Orders() (mquery.Orders, error) {
 	return c.mclient.Orders()
 }
 
+func (c *qclient) Order(id mtypes.OrderID) (mquery.Order, error) {
+	if c.mclient == nil {
+		return mquery.Order{}, ErrClientNotFound
+	}
+	return c.mclient.Order(id)
+}
+
 func (c *qclient) Bids() (mquery.Bids, error) {
 	if c.mclient == nil {
 		return mquery.Bids{}, ErrClientNotFound
@@ -143,6 +150,104 @@ func (c *qclient) Leases() (mquery.Leases, error) {
 	return c.mclient.Leases()
 }
 
+func (c *qclient) Lease(id mtypes.LeaseID) (mquery.Lease, error) {
+	if c.mclient == nil {
+		return mquery.Lease{}, ErrClientNotFound
+	}
+	return c.mclient.Lease(id)
+}
+
+func (c *qclient) ActiveProviders() (mquery.ActiveProviders, error) {
+	if c.mclient == nil {
+		return mquery.ActiveProviders{}, ErrClientNotFound
+	}
+	return c.mclient.ActiveProviders()
+}
+
+func (c *qclient) DeploymentLeases(id dtypes.DeploymentID) (mquery.Leases, error) {
+	if c.mclient == nil {
+		return mquery.Leases{}, ErrClientNotFound
+	}
+	return c.mclient.DeploymentLeases(id)
+}
+
+func (c *qclient) ResourceSupplyDemand() (mquery.ResourceSupplyDemand, error) {
+	if c.mclient == nil {
+		return mquery.ResourceSupplyDemand{}, ErrClientNotFound
+	}
+	return c.mclient.ResourceSupplyDemand()
+}
+
+func (c *qclient) ProviderBidStats(provider sdk.AccAddress, from, to int64) (mquery.ProviderBidStats, error) {
+	if c.mclient == nil {
+		return mquery.ProviderBidStats{}, ErrClientNotFound
+	}
+	return c.mclient.ProviderBidStats(provider, from, to)
+}
+
+func (c *qclient) OrderBids(id mtypes.OrderID, page, limit uint32) (mquery.OrderBids, error) {
+	if c.mclient == nil {
+		return mquery.OrderBids{}, ErrClientNotFound
+	}
+	return c.mclient.OrderBids(id, page, limit)
+}
+
+func (c *qclient) LeaseDurations() (mquery.LeaseDurationStats, error) {
+	if c.mclient == nil {
+		return mquery.LeaseDurationStats{}, ErrClientNotFound
+	}
+	return c.mclient.LeaseDurations()
+}
+
+func (c *qclient) MarketEvents(from, to int64) (mquery.MarketEvents, error) {
+	if c.mclient == nil {
+		return mquery.MarketEvents{}, ErrClientNotFound
+	}
+	return c.mclient.MarketEvents(from, to)
+}
+
+func (c *qclient) OrderWithBestBid(id mtypes.OrderID) (mquery.OrderWithBestBid, error) {
+	if c.mclient == nil {
+		return mquery.OrderWithBestBid{}, ErrClientNotFound
+	}
+	return c.mclient.OrderWithBestBid(id)
+}
+
+func (c *qclient) LeasesByTag(key, value string) (mquery.Leases, error) {
+	if c.mclient == nil {
+		return mquery.Leases{}, ErrClientNotFound
+	}
+	return c.mclient.LeasesByTag(key, value)
+}
+
+func (c *qclient) OrdersWithoutBids(minAge int64) (mquery.Orders, error) {
+	if c.mclient == nil {
+		return mquery.Orders{}, ErrClientNotFound
+	}
+	return c.mclient.OrdersWithoutBids(minAge)
+}
+
+func (c *qclient) PriceHistory(specHash []byte, window int64) (mquery.PriceHistory, error) {
+	if c.mclient == nil {
+		return mquery.PriceHistory{}, ErrClientNotFound
+	}
+	return c.mclient.PriceHistory(specHash, window)
+}
+
+func (c *qclient) OrdersByState(state mtypes.OrderState) (mquery.Orders, error) {
+	if c.mclient == nil {
+		return mquery.Orders{}, ErrClientNotFound
+	}
+	return c.mclient.OrdersByState(state)
+}
+
+func (c *qclient) TotalEscrowed() (sdk.Coins, error) {
+	if c.mclient == nil {
+		return sdk.Coins{}, ErrClientNotFound
+	}
+	return c.mclient.TotalEscrowed()
+}
+
 func (c *qclient) Providers() (pquery.Providers, error) {
 	if c.pclient == nil {
 		return pquery.Providers{}, ErrClientNotFound
@@ -36,6 +36,8 @@ func main() {
 		flags.LineBreak,
 		lcd.ServeCommand(cdc, lcdRoutes),
 		flags.LineBreak,
+		providerCmd(cdc),
+		flags.LineBreak,
 		keys.Commands(),
 		flags.LineBreak,
 		version.Cmd,
@@ -2,24 +2,61 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 
 	ccontext "github.com/cosmos/cosmos-sdk/client/context"
 	"github.com/cosmos/cosmos-sdk/client/keys"
 	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/auth"
 	"github.com/cosmos/cosmos-sdk/x/auth/client/utils"
 	"github.com/ovrclk/akash/client"
 	"github.com/ovrclk/akash/events"
 	"github.com/ovrclk/akash/provider"
 	"github.com/ovrclk/akash/provider/cluster"
+	"github.com/ovrclk/akash/provider/cluster/kube"
 	"github.com/ovrclk/akash/provider/session"
 	"github.com/ovrclk/akash/pubsub"
+	"github.com/ovrclk/akash/types"
+	"github.com/ovrclk/akash/util/uiutil"
 	dmodule "github.com/ovrclk/akash/x/deployment"
 	mmodule "github.com/ovrclk/akash/x/market"
+	mquery "github.com/ovrclk/akash/x/market/query"
+	mtypes "github.com/ovrclk/akash/x/market/types"
 	pmodule "github.com/ovrclk/akash/x/provider"
 	"github.com/spf13/cobra"
+	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/libs/log"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	flagJSON          = "json"
+	flagHealthAddr    = "health-addr"
+	defaultHealthAddr = "localhost:8444"
+	healthPath        = "/healthz"
+
+	flagGatewayAddr = "gateway-addr"
+	flagFollow      = "follow"
+	flagSince       = "since"
+	flagTail        = "tail"
+
+	flagDryRun  = "dry-run"
+	flagConfirm = "confirm"
+)
+
+var (
+	errProviderDegraded = errors.New("provider degraded")
+	errCannotFulfill    = errors.New("cluster cannot fulfill order")
 )
 
 func providerCmd(cdc *codec.Codec) *cobra.Command {
@@ -74,6 +111,34 @@ func providerCmd(cdc *codec.Codec) *cobra.Command {
 				return err
 			}
 
+			healthAddr, err := cmd.Flags().GetString(flagHealthAddr)
+			if err != nil {
+				return err
+			}
+			if healthAddr != "" {
+				mux := http.NewServeMux()
+				mux.Handle(healthPath, provider.HealthHandler(service))
+				go func() {
+					if err := http.ListenAndServe(healthAddr, mux); err != nil {
+						log.Error("serving health endpoint", "err", err)
+					}
+				}()
+			}
+
+			gatewayAddr, err := cmd.Flags().GetString(flagGatewayAddr)
+			if err != nil {
+				return err
+			}
+			if gatewayAddr != "" {
+				mux := http.NewServeMux()
+				mux.Handle(provider.LeasePrefix, provider.LeaseHandler(cctx.FromAddress, cclient))
+				go func() {
+					if err := http.ListenAndServe(gatewayAddr, mux); err != nil {
+						log.Error("serving gateway", "err", err)
+					}
+				}()
+			}
+
 			<-service.Done()
 
 			return nil
@@ -82,6 +147,396 @@ func providerCmd(cdc *codec.Codec) *cobra.Command {
 
 	cmd.Flags().Bool("cluster-k8s", false, "Use Kubernetes cluster")
 	cmd.Flags().String("manifest-ns", "lease", "Cluster manifest namespace")
+	cmd.Flags().String(flagHealthAddr, defaultHealthAddr, "Address to serve the health endpoint on (empty to disable)")
+	cmd.Flags().String(flagGatewayAddr, "", "Address to serve tenant requests such as lease logs and status on (empty to disable)")
+
+	cmd.AddCommand(drainNodeCmd())
+	cmd.AddCommand(healthzCmd())
+	cmd.AddCommand(inventoryCmd())
+	cmd.AddCommand(canFulfillCmd(cdc))
+	cmd.AddCommand(leaseLogsCmd(cdc))
+	cmd.AddCommand(gcCmd(cdc))
 
 	return cmd
 }
+
+func drainNodeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drain-node <node>",
+		Short: "cordon a cluster node and evict the lease pods running on it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ns, err := cmd.Flags().GetString("manifest-ns")
+			if err != nil {
+				return err
+			}
+
+			log := log.NewTMLogger(log.NewSyncWriter(os.Stdout))
+
+			kclient, err := kube.NewClient(log, "", ns)
+			if err != nil {
+				return err
+			}
+
+			leases, blocked, err := kclient.DrainNode(args[0])
+			for _, lid := range leases {
+				cmd.Println(mquery.LeasePath(lid))
+			}
+			for _, pod := range blocked {
+				cmd.Println("blocked:", pod)
+			}
+			if err == nil && len(blocked) > 0 {
+				err = fmt.Errorf("%v pods not evicted: blocked by disruption budgets", len(blocked))
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().String("manifest-ns", "lease", "Cluster manifest namespace")
+
+	return cmd
+}
+
+func healthzCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "healthz",
+		Short: "report the health of a running provider",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cmd.Flags().GetString(flagHealthAddr)
+			if err != nil {
+				return err
+			}
+
+			resp, err := http.Get("http://" + addr + healthPath)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			var health provider.Health
+			if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+				return err
+			}
+
+			buf, err := json.MarshalIndent(health, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(buf))
+
+			if !health.OK {
+				return errProviderDegraded
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagHealthAddr, defaultHealthAddr, "Address of the provider health endpoint")
+
+	return cmd
+}
+
+func inventoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "show the capacity of the cluster nodes and the resources committed to leases",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ns, err := cmd.Flags().GetString("manifest-ns")
+			if err != nil {
+				return err
+			}
+
+			asJSON, err := cmd.Flags().GetBool(flagJSON)
+			if err != nil {
+				return err
+			}
+
+			log := log.NewTMLogger(log.NewSyncWriter(os.Stderr))
+
+			kclient, err := kube.NewClient(log, "", ns)
+			if err != nil {
+				return err
+			}
+
+			nodes, err := kclient.NodeInventory()
+			if err != nil {
+				return err
+			}
+			total := kube.TotalInventory(nodes)
+
+			if asJSON {
+				buf, err := json.MarshalIndent(struct {
+					Nodes []kube.NodeInventory `json:"nodes"`
+					Total kube.NodeInventory   `json:"total"`
+				}{nodes, total}, "", "  ")
+				if err != nil {
+					return err
+				}
+				cmd.Println(string(buf))
+				return nil
+			}
+
+			table := uiutil.NewListTable().
+				AddHeader("NODE", "CAPACITY", "ALLOCATABLE", "COMMITTED", "AVAILABLE")
+			for _, node := range append(nodes, total) {
+				table.AddRow(node.Name,
+					formatUnit(node.Capacity),
+					formatUnit(node.Allocatable),
+					formatUnit(node.Committed),
+					formatUnit(node.Available()))
+			}
+
+			return uiutil.NewPrinter(cmd.OutOrStdout()).
+				AddTitle("Cluster Inventory").
+				Add(table.UITable()).
+				Flush()
+		},
+	}
+
+	cmd.Flags().String("manifest-ns", "lease", "Cluster manifest namespace")
+	cmd.Flags().Bool(flagJSON, false, "Output inventory as JSON")
+
+	return cmd
+}
+
+func canFulfillCmd(cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "can-fulfill <owner>/<dseq>/<gseq>/<oseq>",
+		Short: "check whether the cluster has the free capacity to run an order",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cctx := ccontext.NewCLIContext().WithCodec(cdc)
+
+			oid, err := mquery.ParseOrderPath(strings.Split(args[0], "/"))
+			if err != nil {
+				return err
+			}
+
+			ns, err := cmd.Flags().GetString("manifest-ns")
+			if err != nil {
+				return err
+			}
+
+			result, err := mmodule.AppModuleBasic{}.GetQueryClient(cctx).OrderWithBestBid(oid)
+			if err != nil {
+				return err
+			}
+
+			log := log.NewTMLogger(log.NewSyncWriter(os.Stderr))
+
+			kclient, err := kube.NewClient(log, "", ns)
+			if err != nil {
+				return err
+			}
+
+			nodes, err := kclient.NodeInventory()
+			if err != nil {
+				return err
+			}
+
+			if ok, limit := kube.FitResources(nodes, result.Order.Spec.GetResources()); !ok {
+				cmd.Printf("no: insufficient %v\n", limit)
+				return errCannotFulfill
+			}
+
+			cmd.Println("yes")
+			return nil
+		},
+	}
+
+	cmd.Flags().String("manifest-ns", "lease", "Cluster manifest namespace")
+
+	return cmd
+}
+
+func gcCmd(cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc <provider>",
+		Short: "list, and with --confirm delete, cluster lease resources without an active lease of the provider",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cctx := ccontext.NewCLIContext().WithCodec(cdc)
+
+			provider, err := sdk.AccAddressFromBech32(args[0])
+			if err != nil {
+				return err
+			}
+			if provider.Empty() {
+				return mtypes.ErrEmptyProvider
+			}
+
+			dryRun, err := cmd.Flags().GetBool(flagDryRun)
+			if err != nil {
+				return err
+			}
+			confirm, err := cmd.Flags().GetBool(flagConfirm)
+			if err != nil {
+				return err
+			}
+			if dryRun && confirm {
+				return fmt.Errorf("--%s and --%s are exclusive", flagDryRun, flagConfirm)
+			}
+
+			ns, err := cmd.Flags().GetString("manifest-ns")
+			if err != nil {
+				return err
+			}
+
+			leases, err := mmodule.AppModuleBasic{}.GetQueryClient(cctx).Leases()
+			if err != nil {
+				return err
+			}
+
+			var active []mtypes.LeaseID
+			for _, lease := range leases {
+				if lease.State == mtypes.LeaseActive {
+					active = append(active, lease.ID())
+				}
+			}
+
+			log := log.NewTMLogger(log.NewSyncWriter(os.Stderr))
+
+			kclient, err := kube.NewClient(log, "", ns)
+			if err != nil {
+				return err
+			}
+
+			orphans, err := kclient.OrphanedResources(provider, active)
+			if err != nil {
+				return err
+			}
+			for _, orphan := range orphans {
+				cmd.Println(orphan)
+			}
+
+			if !confirm {
+				return nil
+			}
+			return kclient.DeleteOrphanedResources(orphans)
+		},
+	}
+
+	cmd.Flags().String("manifest-ns", "lease", "Cluster manifest namespace")
+	cmd.Flags().Bool(flagDryRun, false, "Only list the orphaned resources (the default)")
+	cmd.Flags().Bool(flagConfirm, false, "Delete the orphaned resources")
+
+	return cmd
+}
+
+// formatUnit renders a unit as cpu/memory/storage.
+func formatUnit(u types.Unit) string {
+	return resource.NewMilliQuantity(int64(u.CPU), resource.DecimalSI).String() + "/" +
+		resource.NewQuantity(int64(u.Memory), resource.BinarySI).String() + "/" +
+		resource.NewQuantity(int64(u.Storage), resource.BinarySI).String()
+}
+
+func leaseLogsCmd(cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lease-logs <provider> <dseq> <gseq> <oseq>",
+		Short: "stream the logs of one of your leases from its provider",
+		Args:  cobra.ExactArgs(4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cctx := ccontext.NewCLIContext().WithCodec(cdc)
+
+			lid, err := leaseLogsLeaseID(cctx.GetFromAddress(), args)
+			if err != nil {
+				return err
+			}
+
+			follow, err := cmd.Flags().GetBool(flagFollow)
+			if err != nil {
+				return err
+			}
+			since, err := cmd.Flags().GetDuration(flagSince)
+			if err != nil {
+				return err
+			}
+			tail, err := cmd.Flags().GetInt64(flagTail)
+			if err != nil {
+				return err
+			}
+
+			puri, err := providerHostURI(cctx, lid.Provider)
+			if err != nil {
+				return err
+			}
+
+			kb := auth.NewTxBuilderFromCLI(os.Stdin).Keybase()
+			sign := func(msg []byte) ([]byte, crypto.PubKey, error) {
+				return kb.Sign(cctx.GetFromName(), keys.DefaultKeyPass, msg)
+			}
+
+			query := url.Values{}
+			query.Set(flagFollow, strconv.FormatBool(follow))
+			query.Set(flagSince, since.String())
+			query.Set(flagTail, strconv.FormatInt(tail, 10))
+
+			req, err := provider.NewLeaseRequest(context.Background(), puri, lid, provider.LeaseLogsPath(lid), query, sign)
+			if err != nil {
+				return err
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				body, _ := ioutil.ReadAll(resp.Body)
+				return fmt.Errorf("%v: %s", resp.Status, strings.TrimSpace(string(body)))
+			}
+
+			_, err = io.Copy(cmd.OutOrStdout(), resp.Body)
+			return err
+		},
+	}
+
+	cmd.Flags().Bool(flagFollow, false, "Keep streaming new log lines")
+	cmd.Flags().Duration(flagSince, 0, "Only show lines newer than this (e.g. 10m)")
+	cmd.Flags().Int64(flagTail, 0, "Only show this many of the most recent lines per pod (0 for all)")
+
+	return cmd
+}
+
+func leaseLogsLeaseID(owner sdk.AccAddress, args []string) (mtypes.LeaseID, error) {
+	prov, err := sdk.AccAddressFromBech32(args[0])
+	if err != nil {
+		return mtypes.LeaseID{}, err
+	}
+	dseq, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return mtypes.LeaseID{}, err
+	}
+	gseq, err := strconv.ParseUint(args[2], 10, 32)
+	if err != nil {
+		return mtypes.LeaseID{}, err
+	}
+	oseq, err := strconv.ParseUint(args[3], 10, 32)
+	if err != nil {
+		return mtypes.LeaseID{}, err
+	}
+	return mtypes.LeaseID{
+		Owner:    owner,
+		DSeq:     dseq,
+		GSeq:     uint32(gseq),
+		OSeq:     uint32(oseq),
+		Provider: prov,
+	}, nil
+}
+
+// providerHostURI looks up the gateway address the provider registered.
+func providerHostURI(cctx ccontext.CLIContext, addr sdk.AccAddress) (*url.URL, error) {
+	providers, err := pmodule.AppModuleBasic{}.GetQueryClient(cctx).Providers()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range providers {
+		if p.Owner.Equals(addr) {
+			return url.Parse(p.HostURI)
+		}
+	}
+	return nil, fmt.Errorf("provider %v not found", addr)
+}
@@ -31,13 +31,22 @@ func (g Group) GetResources() []types.Resource {
 }
 
 type Service struct {
-	Name   string
-	Image  string
-	Args   []string
-	Env    []string
-	Unit   types.Unit
-	Count  uint32
-	Expose []ServiceExpose
+	Name        string
+	Image       string
+	Command     []string
+	Args        []string
+	Env         []string
+	Annotations map[string]string
+	Unit        types.Unit
+	Count       uint32
+	Expose      []ServiceExpose
+	Probe       ServiceProbe
+	// Path the service's storage is mounted at.  If set, Unit.Storage is
+	// provisioned as a persistent volume rather than ephemeral storage.
+	StorageMount string
+	// Run as a StatefulSet: replicas get stable names, their own volume and
+	// are started and updated in order.
+	Stateful bool
 }
 
 func (s Service) GetUnit() types.Unit {
@@ -55,4 +64,21 @@ type ServiceExpose struct {
 	Service      string
 	Global       bool
 	Hosts        []string
+	// Kubernetes service type ("ClusterIP", "NodePort" or "LoadBalancer").
+	// Empty leaves the choice to the provider.
+	ServiceType string
+	// Serve the hosts over HTTPS
+	HTTPS bool
+}
+
+// ServiceProbe is the health check of a service.  The container is probed
+// over HTTP if Path is set and by opening a TCP connection otherwise.  Zero
+// values fall back to the first exposed port and the Kubernetes defaults.
+type ServiceProbe struct {
+	Path             string
+	Port             uint32
+	InitialDelay     uint32
+	Period           uint32
+	Timeout          uint32
+	FailureThreshold uint32
 }
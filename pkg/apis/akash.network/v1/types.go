@@ -32,16 +32,28 @@ func (m ManifestGroup) ToAkash() *manifest.Group {
 
 	for _, svc := range m.Services {
 		masvc := manifest.Service{
-			Name:  svc.Name,
-			Image: svc.Image,
-			Args:  svc.Args[:],
-			Env:   svc.Env[:],
+			Name:         svc.Name,
+			Image:        svc.Image,
+			Command:      svc.Command[:],
+			Args:         svc.Args[:],
+			Env:          svc.Env[:],
+			Annotations:  copyAnnotations(svc.Annotations),
+			StorageMount: svc.StorageMount,
+			Stateful:     svc.Stateful,
 			Unit: types.Unit{
 				CPU:     svc.Unit.CPU,
 				Memory:  svc.Unit.Memory,
 				Storage: svc.Unit.Storage,
 			},
 			Count: svc.Count,
+			Probe: manifest.ServiceProbe{
+				Path:             svc.Probe.Path,
+				Port:             svc.Probe.Port,
+				InitialDelay:     svc.Probe.InitialDelay,
+				Period:           svc.Probe.Period,
+				Timeout:          svc.Probe.Timeout,
+				FailureThreshold: svc.Probe.FailureThreshold,
+			},
 		}
 		for _, expose := range svc.Expose {
 			masvc.Expose = append(masvc.Expose, manifest.ServiceExpose{
@@ -51,6 +63,8 @@ func (m ManifestGroup) ToAkash() *manifest.Group {
 				Service:      expose.Service,
 				Global:       expose.Global,
 				Hosts:        expose.Hosts[:],
+				ServiceType:  expose.ServiceType,
+				HTTPS:        expose.HTTPS,
 			})
 		}
 
@@ -60,21 +74,44 @@ func (m ManifestGroup) ToAkash() *manifest.Group {
 	return ma
 }
 
+func copyAnnotations(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
 func ManifestGroupFromAkash(m *manifest.Group) ManifestGroup {
 	ma := ManifestGroup{Name: m.Name}
 
 	for _, svc := range m.Services {
 		masvc := &ManifestService{
-			Name:  svc.Name,
-			Image: svc.Image,
-			Args:  svc.Args[:],
-			Env:   svc.Env[:],
+			Name:         svc.Name,
+			Image:        svc.Image,
+			Command:      svc.Command[:],
+			Args:         svc.Args[:],
+			Env:          svc.Env[:],
+			Annotations:  copyAnnotations(svc.Annotations),
+			StorageMount: svc.StorageMount,
+			Stateful:     svc.Stateful,
 			Unit: ResourceUnit{
 				CPU:     svc.Unit.CPU,
 				Memory:  svc.Unit.Memory,
 				Storage: svc.Unit.Storage,
 			},
 			Count: svc.Count,
+			Probe: ManifestServiceProbe{
+				Path:             svc.Probe.Path,
+				Port:             svc.Probe.Port,
+				InitialDelay:     svc.Probe.InitialDelay,
+				Period:           svc.Probe.Period,
+				Timeout:          svc.Probe.Timeout,
+				FailureThreshold: svc.Probe.FailureThreshold,
+			},
 		}
 		for _, expose := range svc.Expose {
 			masvc.Expose = append(masvc.Expose, &ManifestServiceExpose{
@@ -84,6 +121,8 @@ func ManifestGroupFromAkash(m *manifest.Group) ManifestGroup {
 				Service:      expose.Service,
 				Global:       expose.Global,
 				Hosts:        expose.Hosts[:],
+				ServiceType:  expose.ServiceType,
+				HTTPS:        expose.HTTPS,
 			})
 		}
 
@@ -138,15 +177,25 @@ type ManifestService struct {
 	// Service name
 	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	// Docker image
-	Image string   `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
-	Args  []string `protobuf:"bytes,3,rep,name=args" json:"args,omitempty"`
-	Env   []string `protobuf:"bytes,4,rep,name=env" json:"env,omitempty"`
+	Image string `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	// Entrypoint and arguments overriding the image's
+	Command []string `protobuf:"bytes,9,rep,name=command" json:"command,omitempty"`
+	Args    []string `protobuf:"bytes,3,rep,name=args" json:"args,omitempty"`
+	Env     []string `protobuf:"bytes,4,rep,name=env" json:"env,omitempty"`
+	// Pod annotations requested by the tenant
+	Annotations map[string]string `protobuf:"bytes,8,rep,name=annotations" json:"annotations,omitempty"`
 	// Resource requirements
 	Unit ResourceUnit `protobuf:"bytes,5,opt,name=unit" json:"unit"`
 	// Number of instances
 	Count uint32 `protobuf:"varint,6,opt,name=count,proto3" json:"count,omitempty"`
 	// Overlay Network Links
 	Expose []*ManifestServiceExpose `protobuf:"bytes,7,rep,name=expose" json:"expose,omitempty"`
+	// Health check
+	Probe ManifestServiceProbe `protobuf:"bytes,10,opt,name=probe" json:"probe"`
+	// Persistent storage mount path
+	StorageMount string `protobuf:"bytes,11,opt,name=storageMount,proto3" json:"storageMount,omitempty"`
+	// Run as a StatefulSet
+	Stateful bool `protobuf:"varint,12,opt,name=stateful,proto3" json:"stateful,omitempty"`
 }
 
 type ManifestServiceExpose struct {
@@ -157,6 +206,20 @@ type ManifestServiceExpose struct {
 	Global       bool   `protobuf:"varint,5,opt,name=global,proto3" json:"global,omitempty"`
 	// accepted hostnames
 	Hosts []string `protobuf:"bytes,6,rep,name=hosts" json:"hosts,omitempty"`
+	// Kubernetes service type
+	ServiceType string `protobuf:"bytes,7,opt,name=serviceType,proto3" json:"serviceType,omitempty"`
+	// terminate TLS at the ingress
+	HTTPS bool `protobuf:"varint,8,opt,name=https,proto3" json:"https,omitempty"`
+}
+
+type ManifestServiceProbe struct {
+	// HTTP path; probed over TCP if empty
+	Path             string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Port             uint32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	InitialDelay     uint32 `protobuf:"varint,3,opt,name=initialDelay,proto3" json:"initialDelay,omitempty"`
+	Period           uint32 `protobuf:"varint,4,opt,name=period,proto3" json:"period,omitempty"`
+	Timeout          uint32 `protobuf:"varint,5,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	FailureThreshold uint32 `protobuf:"varint,6,opt,name=failureThreshold,proto3" json:"failureThreshold,omitempty"`
 }
 
 type ResourceUnit struct {
@@ -159,6 +159,11 @@ func (in *ManifestList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManifestService) DeepCopyInto(out *ManifestService) {
 	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Args != nil {
 		in, out := &in.Args, &out.Args
 		*out = make([]string, len(*in))
@@ -169,6 +174,13 @@ func (in *ManifestService) DeepCopyInto(out *ManifestService) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	out.Unit = in.Unit
 	if in.Expose != nil {
 		in, out := &in.Expose, &out.Expose
@@ -181,6 +193,7 @@ func (in *ManifestService) DeepCopyInto(out *ManifestService) {
 			}
 		}
 	}
+	out.Probe = in.Probe
 	return
 }
 
@@ -215,6 +228,22 @@ func (in *ManifestServiceExpose) DeepCopy() *ManifestServiceExpose {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManifestServiceProbe) DeepCopyInto(out *ManifestServiceProbe) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManifestServiceProbe.
+func (in *ManifestServiceProbe) DeepCopy() *ManifestServiceProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(ManifestServiceProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManifestSpec) DeepCopyInto(out *ManifestSpec) {
 	*out = *in
@@ -0,0 +1,13 @@
+package bidengine
+
+type config struct {
+	// Longest lease, in blocks, the provider will bid for.  0 disables the limit.
+	MaxLeaseDuration int64 `env:"AKASH_MAX_LEASE_DURATION" envDefault:"0"`
+
+	// Deposit placed in escrow with each bid.
+	BidDeposit string `env:"AKASH_BID_DEPOSIT" envDefault:"0akash"`
+
+	// Price bid for each unit of each resource an order requests, capped at
+	// the order's price limit.  Empty bids the price limit.
+	BidPricePerUnit string `env:"AKASH_BID_PRICE_PER_UNIT" envDefault:""`
+}
@@ -0,0 +1,41 @@
+package bidengine
+
+import (
+	"sync"
+
+	mquery "github.com/ovrclk/akash/x/market/query"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+)
+
+// inflightBids tracks orders with a bid submission in progress so that
+// overlapping attempts for the same order are not broadcast twice.
+type inflightBids struct {
+	orders map[string]struct{}
+	mtx    sync.Mutex
+}
+
+func newInflightBids() *inflightBids {
+	return &inflightBids{orders: make(map[string]struct{})}
+}
+
+// do runs fn unless a submission for the order is already in flight.  It
+// returns false if fn was skipped.
+func (b *inflightBids) do(oid mtypes.OrderID, fn func() error) (bool, error) {
+	key := mquery.OrderPath(oid)
+
+	b.mtx.Lock()
+	if _, ok := b.orders[key]; ok {
+		b.mtx.Unlock()
+		return false, nil
+	}
+	b.orders[key] = struct{}{}
+	b.mtx.Unlock()
+
+	defer func() {
+		b.mtx.Lock()
+		delete(b.orders, key)
+		b.mtx.Unlock()
+	}()
+
+	return true, fn()
+}
@@ -0,0 +1,78 @@
+package bidengine
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+)
+
+func TestInflightBids(t *testing.T) {
+	const attempts = 10
+
+	owner := ed25519.GenPrivKey().PubKey().Address()
+	oid := mtypes.MakeOrderID(dtypes.GroupID{Owner: owner.Bytes(), DSeq: 1, GSeq: 1}, 1)
+
+	bids := newInflightBids()
+
+	var (
+		submissions int32
+		skipped     int32
+		started     = make(chan struct{})
+		release     = make(chan struct{})
+		wg          sync.WaitGroup
+	)
+
+	// first attempt blocks mid-broadcast
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ok, err := bids.do(oid, func() error {
+			atomic.AddInt32(&submissions, 1)
+			close(started)
+			<-release
+			return nil
+		})
+		assert.True(t, ok)
+		assert.NoError(t, err)
+	}()
+	<-started
+
+	var attemptsWg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		attemptsWg.Add(1)
+		go func() {
+			defer attemptsWg.Done()
+			ok, err := bids.do(oid, func() error {
+				atomic.AddInt32(&submissions, 1)
+				return nil
+			})
+			assert.NoError(t, err)
+			if !ok {
+				atomic.AddInt32(&skipped, 1)
+			}
+		}()
+	}
+	attemptsWg.Wait()
+
+	// other orders are unaffected
+	other := mtypes.MakeOrderID(oid.GroupID(), 2)
+	ok, err := bids.do(other, func() error { return nil })
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), submissions)
+	assert.Equal(t, int32(attempts), skipped)
+
+	// resolved; the order may be bid again
+	ok, err = bids.do(oid, func() error { return nil })
+	assert.True(t, ok)
+	assert.NoError(t, err)
+}
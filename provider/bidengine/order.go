@@ -20,6 +20,9 @@ type order struct {
 	order mtypes.OrderID
 	bid   *mquery.Bid
 
+	config   config
+	inflight *inflightBids
+
 	session session.Session
 	cluster cluster.Cluster
 	bus     pubsub.Bus
@@ -42,14 +45,16 @@ func newOrder(e *service, oid mtypes.OrderID, bid *mquery.Bid) (*order, error) {
 	log := session.Log().With("order", oid)
 
 	order := &order{
-		order:   oid,
-		bid:     bid,
-		session: session,
-		cluster: e.cluster,
-		bus:     e.bus,
-		sub:     sub,
-		log:     log,
-		lc:      lifecycle.New(),
+		order:    oid,
+		bid:      bid,
+		config:   e.config,
+		inflight: e.inflight,
+		session:  session,
+		cluster:  e.cluster,
+		bus:      e.bus,
+		sub:      sub,
+		log:      log,
+		lc:       lifecycle.New(),
 	}
 
 	// Shut down when parent begins shutting down
@@ -175,20 +180,35 @@ loop:
 
 			reservation = result.Value().(cluster.Reservation)
 
-			// TODO: price
-			// price := calculatePrice(reservation.Resources())
-			price := sdk.NewCoin("akash", sdk.NewInt(0))
+			price, err := calculatePrice(group.GroupSpec, o.config.BidPricePerUnit)
+			if err != nil {
+				o.log.Error("calculating bid price", "err", err)
+				break loop
+			}
+
+			deposit, err := sdk.ParseCoin(o.config.BidDeposit)
+			if err != nil {
+				o.log.Error("parsing bid deposit", "err", err)
+				break loop
+			}
 
-			o.log.Debug("submitting fulfillment", "price", price)
+			o.log.Debug("submitting fulfillment", "price", price, "deposit", deposit)
 
 			// Begin submitting fulfillment
 			bidch = runner.Do(func() runner.Result {
-				return runner.NewResult(nil, o.session.Client().Tx().Broadcast(&mtypes.MsgCreateBid{
-					Order:    o.order,
-					Provider: o.session.Provider(),
-					// TODO: price
-					// Price:    price,
-				}))
+				submitted, err := o.inflight.do(o.order, func() error {
+					return o.session.Client().Tx().Broadcast(&mtypes.MsgCreateBid{
+						Order:       o.order,
+						Provider:    o.session.Provider(),
+						Price:       price,
+						MaxDuration: o.config.MaxLeaseDuration,
+						Deposit:     deposit,
+					})
+				})
+				if !submitted {
+					o.log.Info("bid already in flight; skipping")
+				}
+				return runner.NewResult(nil, err)
 			})
 
 		case result := <-bidch:
@@ -237,6 +257,12 @@ func (o *order) shouldBid(group *dquery.Group) bool {
 		return false
 	}
 
+	if !group.WithinDuration(o.config.MaxLeaseDuration) {
+		o.log.Debug("unable to fulfill: lease duration over limit",
+			"duration", group.Duration, "max", o.config.MaxLeaseDuration)
+		return false
+	}
+
 	// XXX
 	// if err := validation.ValidateDeploymentGroup(group); err != nil {
 	// 	o.log.Error("unable to fulfill: group validation error",
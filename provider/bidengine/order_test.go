@@ -0,0 +1,34 @@
+package bidengine
+
+import (
+	"testing"
+
+	dquery "github.com/ovrclk/akash/x/deployment/query"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestOrder_shouldBid_maxLeaseDuration(t *testing.T) {
+	tests := []struct {
+		max      int64
+		duration int64
+		bid      bool
+	}{
+		{0, 0, true},
+		{0, 1000, true},
+		{100, 0, false},
+		{100, 50, true},
+		{100, 100, true},
+		{100, 101, false},
+	}
+
+	for _, test := range tests {
+		o := &order{
+			config: config{MaxLeaseDuration: test.max},
+			log:    log.NewNopLogger(),
+		}
+		group := &dquery.Group{GroupSpec: dtypes.GroupSpec{Name: "test", Duration: test.duration}}
+		assert.Equal(t, test.bid, o.shouldBid(group), "max %v duration %v", test.max, test.duration)
+	}
+}
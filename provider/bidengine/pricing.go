@@ -1,40 +1,40 @@
 package bidengine
 
-// TODO: price
-
-// func calculatePrice(resources types.ResourceGroup) uint64 {
-// 	min, max := calculatePriceRange(resources)
-
-// 	if max == min {
-// 		return max
-// 	}
-
-// 	return uint64(rand.Int63n(int64(max-min)) + int64(min))
-// }
-
-// func calculatePriceRange(resources types.ResourceGroup) (uint64, uint64) {
-// 	// TODO: catch overflow
-// 	var (
-// 		mem  uint64
-// 		rmax uint64
-// 	)
-
-// 	cfg := validation.Config()
-
-// 	for _, group := range resources.GetResources() {
-// 		rmax += group.Price * uint64(group.Count)
-// 		mem += group.Unit.Memory * uint64(group.Count)
-// 	}
-
-// 	cmin := uint64(float64(mem) * float64(cfg.MinGroupMemPrice) / float64(unit.Gi))
-// 	cmax := uint64(float64(mem) * float64(cfg.MaxGroupMemPrice) / float64(unit.Gi))
-
-// 	if cmax > rmax {
-// 		cmax = rmax
-// 	}
-// 	if cmax == 0 {
-// 		cmax = 1
-// 	}
-
-// 	return cmin, cmax
-// }
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
+)
+
+// calculatePrice prices a bid on the group at perUnit for every unit of every
+// resource it requests, capped at the most the group accepts.  An empty
+// perUnit bids the group's price limit.
+func calculatePrice(group dtypes.GroupSpec, perUnit string) (sdk.Coin, error) {
+	limit := group.PriceLimit()
+	if perUnit == "" {
+		return limit, nil
+	}
+
+	unit, err := sdk.ParseCoin(perUnit)
+	if err != nil {
+		return sdk.Coin{}, err
+	}
+	if !unit.IsPositive() {
+		return sdk.Coin{}, fmt.Errorf("bid price per unit must be positive: %v", unit)
+	}
+	if unit.Denom != limit.Denom {
+		return sdk.Coin{}, fmt.Errorf("bid price denomination %v not accepted by order (%v)", unit.Denom, limit.Denom)
+	}
+
+	var count int64
+	for _, resource := range group.Resources {
+		count += int64(resource.Count)
+	}
+
+	price := sdk.NewCoin(unit.Denom, unit.Amount.MulRaw(count))
+	if limit.IsLT(price) {
+		return limit, nil
+	}
+	return price, nil
+}
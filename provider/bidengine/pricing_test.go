@@ -3,91 +3,55 @@ package bidengine
 import (
 	"testing"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ovrclk/akash/types"
-	"github.com/ovrclk/akash/types/unit"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestCalculatePriceRange(t *testing.T) {
-
-	tests := []struct {
-		name      string
-		resources []types.ResourceGroup
-		min       uint64
-		max       uint64
-	}{
-		{
-			name: "max-unbound",
-			min:  25600,
-			max:  76800,
-			resources: []types.ResourceGroup{
-				{
-					Unit: types.ResourceUnit{
-						CPU:    100,
-						Memory: 512 * unit.Gi,
-						Disk:   512 * unit.Mi,
-					},
-					Count: 1,
-					Price: 1000000,
-				},
-			},
-		},
-		{
-			name: "max-truncated",
-			min:  25600,
-			max:  25601,
-			resources: []types.ResourceGroup{
-				{
-					Unit: types.ResourceUnit{
-						CPU:    100,
-						Memory: 512 * unit.Gi,
-						Disk:   512 * unit.Mi,
-					},
-					Count: 1,
-					Price: 25601,
-				},
-			},
-		},
-		{
-			name: "min-max-same",
-			min:  25,
-			max:  25,
-			resources: []types.ResourceGroup{
-				{
-					Unit: types.ResourceUnit{
-						CPU:    100,
-						Memory: 512 * unit.Mi,
-						Disk:   512 * unit.Mi,
-					},
-					Count: 1,
-					Price: 25,
-				},
+func TestCalculatePrice(t *testing.T) {
+	group := dtypes.GroupSpec{
+		Name: "test",
+		Resources: []dtypes.Resource{
+			{
+				Unit:  types.Unit{CPU: 100, Memory: 512, Storage: 512},
+				Count: 2,
+				Price: sdk.NewInt64Coin("akash", 50),
 			},
-		},
-		{
-			name: "pass-by-one",
-			min:  25,
-			max:  26,
-			resources: []types.ResourceGroup{
-				{
-					Unit: types.ResourceUnit{
-						CPU:    100,
-						Memory: 512 * unit.Mi,
-						Disk:   512 * unit.Mi,
-					},
-					Count: 1,
-					Price: 26,
-				},
+			{
+				Unit:  types.Unit{CPU: 200, Memory: 1024, Storage: 1024},
+				Count: 1,
+				Price: sdk.NewInt64Coin("akash", 50),
 			},
 		},
 	}
 
-	for _, test := range tests {
-		rlist := &types.DeploymentGroup{Resources: test.resources}
+	tests := []struct {
+		name    string
+		perUnit string
+		price   sdk.Coin
+	}{
+		{"unset", "", sdk.NewInt64Coin("akash", 100)},
+		{"per-unit", "10akash", sdk.NewInt64Coin("akash", 30)},
+		{"at-limit", "33akash", sdk.NewInt64Coin("akash", 99)},
+		{"capped", "40akash", sdk.NewInt64Coin("akash", 100)},
+	}
 
-		min, max := calculatePriceRange(rlist)
-		assert.Equal(t, test.min, min, "%v:min=%v", test.name, min)
-		assert.Equal(t, test.max, max, "%v:max=%v", test.name, max)
+	for _, test := range tests {
+		price, err := calculatePrice(group, test.perUnit)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.price, price, test.name)
 	}
 
+	// a max price lowers the cap
+	group.MaxPrice = sdk.NewInt64Coin("akash", 60)
+	price, err := calculatePrice(group, "40akash")
+	require.NoError(t, err)
+	assert.Equal(t, group.MaxPrice, price)
+
+	for _, perUnit := range []string{"0akash", "10stake", "akash"} {
+		_, err := calculatePrice(group, perUnit)
+		assert.Error(t, err, perUnit)
+	}
 }
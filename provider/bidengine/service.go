@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	lifecycle "github.com/boz/go-lifecycle"
+	"github.com/caarlos0/env"
 	"github.com/ovrclk/akash/provider/cluster"
 	"github.com/ovrclk/akash/provider/session"
 	"github.com/ovrclk/akash/pubsub"
@@ -29,6 +30,12 @@ func NewService(ctx context.Context, session session.Session, cluster cluster.Cl
 
 	session = session.ForModule("bidengine-service")
 
+	config := config{}
+	if err := env.Parse(&config); err != nil {
+		session.Log().Error("parsing config", "err", err)
+		return nil, err
+	}
+
 	sub, err := bus.Subscribe()
 	if err != nil {
 		return nil, err
@@ -43,6 +50,8 @@ func NewService(ctx context.Context, session session.Session, cluster cluster.Cl
 	session.Log().Info("found orders", "count", len(existingOrders))
 
 	s := &service{
+		config:   config,
+		inflight: newInflightBids(),
 		session:  session,
 		cluster:  cluster,
 		bus:      bus,
@@ -60,6 +69,9 @@ func NewService(ctx context.Context, session session.Session, cluster cluster.Cl
 }
 
 type service struct {
+	config   config
+	inflight *inflightBids
+
 	session session.Session
 	cluster cluster.Cluster
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io"
 	"sync"
+	"time"
 
 	"github.com/ovrclk/akash/manifest"
 	atypes "github.com/ovrclk/akash/types"
@@ -17,12 +18,12 @@ import (
 var ErrNoDeployments = errors.New("no deployments")
 
 type Client interface {
-	Deploy(mtypes.LeaseID, *manifest.Group) error
-	TeardownLease(mtypes.LeaseID) error
+	Deploy(context.Context, mtypes.LeaseID, *manifest.Group) error
+	TeardownLease(context.Context, mtypes.LeaseID) error
 	Deployments() ([]Deployment, error)
 	LeaseStatus(mtypes.LeaseID) (*LeaseStatus, error)
 	ServiceStatus(mtypes.LeaseID, string) (*ServiceStatus, error)
-	ServiceLogs(context.Context, mtypes.LeaseID, int64, bool) ([]*ServiceLog, error)
+	ServiceLogs(ctx context.Context, lid mtypes.LeaseID, tailLines int64, follow bool, since time.Duration) ([]*ServiceLog, error)
 	Inventory() ([]Node, error)
 }
 
@@ -86,7 +87,7 @@ func NullClient() Client {
 	}
 }
 
-func (c *nullClient) Deploy(lid mtypes.LeaseID, mgroup *manifest.Group) error {
+func (c *nullClient) Deploy(_ context.Context, lid mtypes.LeaseID, mgroup *manifest.Group) error {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 	c.leases[mquery.LeasePath(lid)] = mgroup
@@ -118,11 +119,11 @@ func (c *nullClient) ServiceStatus(_ mtypes.LeaseID, _ string) (*ServiceStatus,
 	return nil, nil
 }
 
-func (c *nullClient) ServiceLogs(_ context.Context, _ mtypes.LeaseID, _ int64, _ bool) ([]*ServiceLog, error) {
+func (c *nullClient) ServiceLogs(_ context.Context, _ mtypes.LeaseID, _ int64, _ bool, _ time.Duration) ([]*ServiceLog, error) {
 	return nil, nil
 }
 
-func (c *nullClient) TeardownLease(lid mtypes.LeaseID) error {
+func (c *nullClient) TeardownLease(_ context.Context, lid mtypes.LeaseID) error {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
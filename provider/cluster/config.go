@@ -5,4 +5,11 @@ import "time"
 type config struct {
 	InventoryResourcePollPeriod     time.Duration `env:"AKASH_INVENTORY_RESOURCE_POLL_PERIOD" envDefault:"5s"`
 	InventoryResourceDebugFrequency uint          `env:"AKASH_INVENTORY_RESOURCE_DEBUG_FREQUENCY" envDefault:"10"`
+
+	// A lease is degraded once its containers restart MonitorRestartThreshold
+	// times within MonitorRestartWindow.  0 disables the check.
+	MonitorRestartThreshold uint32        `env:"AKASH_MONITOR_RESTART_THRESHOLD" envDefault:"0"`
+	MonitorRestartWindow    time.Duration `env:"AKASH_MONITOR_RESTART_WINDOW" envDefault:"10m"`
+	// Close degraded leases instead of only reporting them.
+	MonitorRestartCloseLease bool `env:"AKASH_MONITOR_RESTART_CLOSE_LEASE" envDefault:"false"`
 }
@@ -177,7 +177,10 @@ func (is *inventoryService) run(reservations []*reservation) {
 	ready := false
 	runch := is.runCheck()
 
-	var fetchCount uint
+	var (
+		fetchCount    uint
+		lastReconcile time.Time
+	)
 
 loop:
 	for {
@@ -200,7 +203,9 @@ loop:
 						continue
 					}
 
-					res.allocated = ev.Status == event.ClusterDeploymentDeployed
+					// degraded deployments still hold their resources
+					res.allocated = ev.Status == event.ClusterDeploymentDeployed ||
+						ev.Status == event.ClusterDeploymentDegraded
 
 					is.log.Debug("reservation status update",
 						"order", res.OrderID(),
@@ -265,7 +270,9 @@ loop:
 
 		case ch := <-is.statusch:
 
-			ch <- is.getStatus(inventory, reservations)
+			status := is.getStatus(inventory, reservations)
+			status.LastReconcile = lastReconcile
+			ch <- status
 
 		case <-t.C:
 			// run cluster inventory check
@@ -291,6 +298,7 @@ loop:
 			}
 
 			inventory = res.Value().([]Node)
+			lastReconcile = time.Now()
 			if fetchCount%is.config.InventoryResourceDebugFrequency == 0 {
 				is.log.Debug("inventory fetched", "nodes", len(inventory))
 				for _, node := range inventory {
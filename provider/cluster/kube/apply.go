@@ -1,84 +1,496 @@
 package kube
 
 import (
+	"context"
+
+	"github.com/ovrclk/akash/manifest"
+	akashv1types "github.com/ovrclk/akash/pkg/apis/akash.network/v1"
 	akashv1 "github.com/ovrclk/akash/pkg/client/clientset/versioned"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/tendermint/tendermint/libs/log"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/api/extensions/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 )
 
-func applyNS(kc kubernetes.Interface, b *nsBuilder) error {
-	obj, err := kc.CoreV1().Namespaces().Get(b.name(), metav1.GetOptions{})
-	switch {
-	case err == nil:
-		obj, err = b.update(obj)
-		if err == nil {
-			_, err = kc.CoreV1().Namespaces().Update(obj)
+// applyLease applies all resources for a lease group in dependency order:
+// namespace, then configuration (the image pull secret), then workloads (see
+// applyWorkload) with their disruption budgets, then services, then
+// ingresses.  Every resource of one kind is applied before any resource of
+// the next.
+//
+// client-go requests can't be cancelled, so ctx is checked before each
+// resource is applied: a cancelled apply stops at the next resource with
+// ctx.Err().
+func applyLease(ctx context.Context, kc kubernetes.Interface, mc akashv1.Interface, log log.Logger, host, mns string, lid mtypes.LeaseID, group *manifest.Group) error {
+	if err := applyNS(ctx, kc, newNSBuilder(lid, group)); err != nil {
+		log.Error("applying namespace", "err", err, "lease", lid)
+		return err
+	}
+
+	if err := applyManifest(ctx, mc, newManifestBuilder(log, mns, lid, group)); err != nil {
+		log.Error("applying manifest", "err", err, "lease", lid)
+		return err
+	}
+
+	if err := cleanupStaleResources(ctx, kc, lid, group); err != nil {
+		log.Error("cleaning stale resources", "err", err, "lease", lid)
+		return err
+	}
+
+	if err := applyPullSecret(ctx, kc, newPullSecretBuilder(log, lid, group)); err != nil {
+		log.Error("applying image pull secret", "err", err, "lease", lid)
+		return err
+	}
+
+	for idx := range group.Services {
+		service := &group.Services[idx]
+		if err := applyWorkload(ctx, kc, log, lid, group, service); err != nil {
+			return err
 		}
-	case errors.IsNotFound(err):
-		obj, err = b.create()
-		if err == nil {
-			_, err = kc.CoreV1().Namespaces().Create(obj)
+		if err := applyPDB(ctx, kc, newPDBBuilder(log, lid, group, service)); err != nil {
+			log.Error("applying pod disruption budget", "err", err, "lease", lid, "service", service.Name)
+			return err
 		}
 	}
-	return err
+
+	for idx := range group.Services {
+		service := &group.Services[idx]
+		if len(service.Expose) == 0 {
+			log.Debug("no services", "lease", lid, "service", service.Name)
+			continue
+		}
+		if err := applyService(ctx, kc, newServiceBuilder(log, lid, group, service)); err != nil {
+			log.Error("applying service", "err", err, "lease", lid, "service", service.Name)
+			return err
+		}
+	}
+
+	for idx := range group.Services {
+		service := &group.Services[idx]
+		for _, expose := range service.Expose {
+			if !shouldExpose(&expose) {
+				continue
+			}
+			if err := applyIngress(ctx, kc, newIngressBuilder(log, host, lid, group, service, &expose)); err != nil {
+				log.Error("applying ingress", "err", err, "lease", lid, "service", service.Name, "expose", expose)
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
-func applyDeployment(kc kubernetes.Interface, b *deploymentBuilder) error {
-	obj, err := kc.AppsV1().Deployments(b.ns()).Get(b.name(), metav1.GetOptions{})
-	switch {
-	case err == nil:
-		obj, err = b.update(obj)
-		if err == nil {
-			_, err = kc.AppsV1().Deployments(b.ns()).Update(obj)
+// applyWorkload applies the pods of a service: a stateful set and the
+// headless service naming its replicas for stateful services, or a
+// deployment and the claim of its storage otherwise.  The workload of the
+// other kind, left by an earlier manifest, is deleted.
+func applyWorkload(ctx context.Context, kc kubernetes.Interface, log log.Logger, lid mtypes.LeaseID, group *manifest.Group, service *manifest.Service) error {
+	ns := lidNS(lid)
+
+	if service.Stateful {
+		if err := applyHeadlessService(ctx, kc, newHeadlessServiceBuilder(log, lid, group, service)); err != nil {
+			log.Error("applying headless service", "err", err, "lease", lid, "service", service.Name)
+			return err
 		}
-	case errors.IsNotFound(err):
-		obj, err = b.create()
-		if err == nil {
-			_, err = kc.AppsV1().Deployments(b.ns()).Create(obj)
+		if err := applyStatefulSet(ctx, kc, newStatefulSetBuilder(log, lid, group, service)); err != nil {
+			log.Error("applying stateful set", "err", err, "lease", lid, "service", service.Name)
+			return err
 		}
+		return deleteDeployment(ctx, kc, ns, service.Name)
 	}
-	return err
+
+	if service.StorageMount != "" {
+		if err := applyPVC(ctx, kc, newPVCBuilder(log, lid, group, service)); err != nil {
+			log.Error("applying persistent volume claim", "err", err, "lease", lid, "service", service.Name)
+			return err
+		}
+	}
+	if err := applyDeployment(ctx, kc, newDeploymentBuilder(log, lid, group, service)); err != nil {
+		log.Error("applying deployment", "err", err, "lease", lid, "service", service.Name)
+		return err
+	}
+	if err := deleteStatefulSet(ctx, kc, ns, service.Name); err != nil {
+		return err
+	}
+	return deleteService(ctx, kc, ns, headlessServiceName(service))
 }
 
-func applyService(kc kubernetes.Interface, b *serviceBuilder) error {
-	obj, err := kc.CoreV1().Services(b.ns()).Get(b.name(), metav1.GetOptions{})
-	switch {
-	case err == nil:
-		obj, err = b.update(obj)
-		if err == nil {
-			_, err = kc.CoreV1().Services(b.ns()).Update(obj)
+func applyNS(ctx context.Context, kc kubernetes.Interface, b *nsBuilder) error {
+	return retryApply(ctx, func() error {
+		obj, err := getNamespace(ctx, kc, b.name())
+		switch {
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = kc.CoreV1().Namespaces().Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = kc.CoreV1().Namespaces().Create(obj)
+			}
 		}
-	case errors.IsNotFound(err):
-		obj, err = b.create()
-		if err == nil {
-			_, err = kc.CoreV1().Services(b.ns()).Create(obj)
+		return err
+	})
+}
+
+func applyDeployment(ctx context.Context, kc kubernetes.Interface, b *deploymentBuilder) error {
+	return retryApply(ctx, func() error {
+		if config.ServerSideApply {
+			obj, err := b.create()
+			if err != nil {
+				return err
+			}
+			gvk := appsv1.SchemeGroupVersion.WithKind("Deployment")
+			if ok, err := serverSideApply(ctx, kc.AppsV1().RESTClient(), "deployments", b.ns(), gvk, obj); ok {
+				return err
+			}
+		}
+
+		obj, err := kc.AppsV1().Deployments(b.ns()).Get(b.name(), metav1.GetOptions{})
+		switch {
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = kc.AppsV1().Deployments(b.ns()).Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = kc.AppsV1().Deployments(b.ns()).Create(obj)
+			}
+		}
+		return err
+	})
+}
+
+func applyStatefulSet(ctx context.Context, kc kubernetes.Interface, b *statefulSetBuilder) error {
+	return retryApply(ctx, func() error {
+		if config.ServerSideApply {
+			obj, err := b.create()
+			if err != nil {
+				return err
+			}
+			gvk := appsv1.SchemeGroupVersion.WithKind("StatefulSet")
+			if ok, err := serverSideApply(ctx, kc.AppsV1().RESTClient(), "statefulsets", b.ns(), gvk, obj); ok {
+				return err
+			}
+		}
+
+		obj, err := kc.AppsV1().StatefulSets(b.ns()).Get(b.name(), metav1.GetOptions{})
+		switch {
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = kc.AppsV1().StatefulSets(b.ns()).Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = kc.AppsV1().StatefulSets(b.ns()).Create(obj)
+			}
+		}
+		return err
+	})
+}
+
+func applyHeadlessService(ctx context.Context, kc kubernetes.Interface, b *headlessServiceBuilder) error {
+	return retryApply(ctx, func() error {
+		if config.ServerSideApply {
+			obj, err := b.create()
+			if err != nil {
+				return err
+			}
+			gvk := corev1.SchemeGroupVersion.WithKind("Service")
+			if ok, err := serverSideApply(ctx, kc.CoreV1().RESTClient(), "services", b.ns(), gvk, obj); ok {
+				return err
+			}
+		}
+
+		obj, err := kc.CoreV1().Services(b.ns()).Get(b.name(), metav1.GetOptions{})
+		switch {
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = kc.CoreV1().Services(b.ns()).Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = kc.CoreV1().Services(b.ns()).Create(obj)
+			}
+		}
+		return err
+	})
+}
+
+// applyPullSecret stores the provider's registry credentials in the lease
+// namespace, or removes them once the provider has none.  The secret goes
+// with the namespace on teardown.
+func applyPullSecret(ctx context.Context, kc kubernetes.Interface, b *pullSecretBuilder) error {
+	return retryApply(ctx, func() error {
+		if !registryAuthConfigured() {
+			err := kc.CoreV1().Secrets(b.ns()).Delete(b.name(), &metav1.DeleteOptions{})
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
 		}
+
+		if config.ServerSideApply {
+			obj, err := b.create()
+			if err != nil {
+				return err
+			}
+			gvk := corev1.SchemeGroupVersion.WithKind("Secret")
+			if ok, err := serverSideApply(ctx, kc.CoreV1().RESTClient(), "secrets", b.ns(), gvk, obj); ok {
+				return err
+			}
+		}
+
+		obj, err := kc.CoreV1().Secrets(b.ns()).Get(b.name(), metav1.GetOptions{})
+		switch {
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = kc.CoreV1().Secrets(b.ns()).Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = kc.CoreV1().Secrets(b.ns()).Create(obj)
+			}
+		}
+		return err
+	})
+}
+
+// applyPVC creates the claim of a service's persistent storage or resizes
+// it.  Claims are never deleted here: they hold tenant data and go with the
+// lease namespace.
+func applyPVC(ctx context.Context, kc kubernetes.Interface, b *pvcBuilder) error {
+	return retryApply(ctx, func() error {
+		if config.ServerSideApply {
+			obj, err := b.create()
+			if err != nil {
+				return err
+			}
+			gvk := corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim")
+			if ok, err := serverSideApply(ctx, kc.CoreV1().RESTClient(), "persistentvolumeclaims", b.ns(), gvk, obj); ok {
+				return err
+			}
+		}
+
+		obj, err := kc.CoreV1().PersistentVolumeClaims(b.ns()).Get(b.name(), metav1.GetOptions{})
+		switch {
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = kc.CoreV1().PersistentVolumeClaims(b.ns()).Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = kc.CoreV1().PersistentVolumeClaims(b.ns()).Create(obj)
+			}
+		}
+		return err
+	})
+}
+
+// applyPDB maintains a disruption budget for multi-replica services and
+// removes any existing budget once a service drops to a single replica.
+// Budgets are written as policy/v1 when the cluster serves it; see pdb.go.
+func applyPDB(ctx context.Context, kc kubernetes.Interface, b *pdbBuilder) error {
+	if pdbAPIV1() {
+		return applyPDBV1(ctx, kc, b)
 	}
-	return err
+	return retryApply(ctx, func() error {
+		if _, ok := b.minAvailable(); !ok {
+			err := deletePDB(kc, b.ns(), b.name())
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		if config.ServerSideApply {
+			obj, err := b.create()
+			if err != nil {
+				return err
+			}
+			gvk := policyv1beta1.SchemeGroupVersion.WithKind("PodDisruptionBudget")
+			if ok, err := serverSideApply(ctx, kc.PolicyV1beta1().RESTClient(), "poddisruptionbudgets", b.ns(), gvk, obj); ok {
+				return err
+			}
+		}
+
+		obj, err := kc.PolicyV1beta1().PodDisruptionBudgets(b.ns()).Get(b.name(), metav1.GetOptions{})
+		switch {
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = kc.PolicyV1beta1().PodDisruptionBudgets(b.ns()).Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = kc.PolicyV1beta1().PodDisruptionBudgets(b.ns()).Create(obj)
+			}
+		}
+		return err
+	})
 }
 
-func applyIngress(kc kubernetes.Interface, b *ingressBuilder) error {
-	obj, err := kc.ExtensionsV1beta1().Ingresses(b.ns()).Get(b.name(), metav1.GetOptions{})
-	switch {
-	case err == nil:
-		obj, err = b.update(obj)
-		if err == nil {
-			_, err = kc.ExtensionsV1beta1().Ingresses(b.ns()).Update(obj)
+func applyPDBV1(ctx context.Context, kc kubernetes.Interface, b *pdbBuilder) error {
+	return retryApply(ctx, func() error {
+		if _, ok := b.minAvailable(); !ok {
+			err := deletePDB(kc, b.ns(), b.name())
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
 		}
-	case errors.IsNotFound(err):
-		obj, err = b.create()
-		if err == nil {
-			_, err = kc.ExtensionsV1beta1().Ingresses(b.ns()).Create(obj)
+
+		if config.ServerSideApply {
+			obj, err := b.create()
+			if err != nil {
+				return err
+			}
+			rc := policyV1REST{kc.PolicyV1beta1().RESTClient()}
+			if ok, err := serverSideApply(ctx, rc, "poddisruptionbudgets", b.ns(), policyV1PDBKind, obj); ok {
+				return err
+			}
 		}
+
+		client := policyV1PDBs(kc, b.ns())
+		obj, err := client.Get(b.name())
+		switch {
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = client.Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = client.Create(obj)
+			}
+		}
+		return err
+	})
+}
+
+func applyService(ctx context.Context, kc kubernetes.Interface, b *serviceBuilder) error {
+	return retryApply(ctx, func() error {
+		if config.ServerSideApply {
+			obj, err := b.create()
+			if err != nil {
+				return err
+			}
+			gvk := corev1.SchemeGroupVersion.WithKind("Service")
+			if ok, err := serverSideApply(ctx, kc.CoreV1().RESTClient(), "services", b.ns(), gvk, obj); ok {
+				return err
+			}
+		}
+
+		obj, err := kc.CoreV1().Services(b.ns()).Get(b.name(), metav1.GetOptions{})
+		switch {
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = kc.CoreV1().Services(b.ns()).Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = kc.CoreV1().Services(b.ns()).Create(obj)
+			}
+		}
+		return err
+	})
+}
+
+func applyIngress(ctx context.Context, kc kubernetes.Interface, b *ingressBuilder) error {
+	if ingressAPIV1() {
+		return applyIngressV1(ctx, kc, b)
 	}
-	return err
+	return retryApply(ctx, func() error {
+		if config.ServerSideApply {
+			obj, err := b.create()
+			if err != nil {
+				return err
+			}
+			gvk := extv1.SchemeGroupVersion.WithKind("Ingress")
+			if ok, err := serverSideApply(ctx, kc.ExtensionsV1beta1().RESTClient(), "ingresses", b.ns(), gvk, obj); ok {
+				return err
+			}
+		}
+
+		obj, err := kc.ExtensionsV1beta1().Ingresses(b.ns()).Get(b.name(), metav1.GetOptions{})
+		switch {
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = kc.ExtensionsV1beta1().Ingresses(b.ns()).Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = kc.ExtensionsV1beta1().Ingresses(b.ns()).Create(obj)
+			}
+		}
+		return err
+	})
 }
 
-func prepareEnvironment(kc kubernetes.Interface, ns string) error {
-	_, err := kc.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
+func applyIngressV1(ctx context.Context, kc kubernetes.Interface, b *ingressBuilder) error {
+	return retryApply(ctx, func() error {
+		if config.ServerSideApply {
+			obj, err := b.createV1()
+			if err != nil {
+				return err
+			}
+			if ok, err := serverSideApply(ctx, kc.NetworkingV1().RESTClient(), "ingresses", b.ns(), netv1IngressKind, obj); ok {
+				return err
+			}
+		}
+
+		obj, err := netv1Ingresses(kc, b.ns()).Get(b.name())
+		switch {
+		case err == nil:
+			obj, err = b.updateV1(obj)
+			if err == nil {
+				_, err = netv1Ingresses(kc, b.ns()).Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.createV1()
+			if err == nil {
+				_, err = netv1Ingresses(kc, b.ns()).Create(obj)
+			}
+		}
+		return err
+	})
+}
+
+// prepareEnvironment creates the provider's namespace, ns, if it doesn't
+// exist.  Nothing is created once ctx is done; its error is returned instead.
+func prepareEnvironment(ctx context.Context, kc kubernetes.Interface, ns string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := getNamespace(ctx, kc, ns)
 	if errors.IsNotFound(err) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		obj := &corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: ns,
@@ -89,19 +501,38 @@ func prepareEnvironment(kc kubernetes.Interface, ns string) error {
 	return err
 }
 
-func applyManifest(kc akashv1.Interface, b *manifestBuilder) error {
-	obj, err := kc.AkashV1().Manifests(b.ns()).Get(b.name(), metav1.GetOptions{})
-	switch {
-	case err == nil:
-		obj, err = b.update(obj)
-		if err == nil {
-			_, err = kc.AkashV1().Manifests(b.ns()).Update(obj)
+func applyManifest(ctx context.Context, kc akashv1.Interface, b *manifestBuilder) error {
+	return retryApply(ctx, func() error {
+		if config.ServerSideApply {
+			obj, err := b.create()
+			if err != nil {
+				return err
+			}
+			gvk := akashv1types.SchemeGroupVersion.WithKind("Manifest")
+			if ok, err := serverSideApply(ctx, kc.AkashV1().RESTClient(), "manifests", b.ns(), gvk, obj); ok {
+				return err
+			}
 		}
-	case errors.IsNotFound(err):
-		obj, err = b.create()
-		if err == nil {
-			_, err = kc.AkashV1().Manifests(b.ns()).Create(obj)
+
+		obj, err := kc.AkashV1().Manifests(b.ns()).Get(b.name(), metav1.GetOptions{})
+		switch {
+		case err == nil && config.ManifestPatchUpdates:
+			var data []byte
+			data, err = b.patch()
+			if err == nil {
+				_, err = kc.AkashV1().Manifests(b.ns()).Patch(b.name(), types.MergePatchType, data)
+			}
+		case err == nil:
+			obj, err = b.update(obj)
+			if err == nil {
+				_, err = kc.AkashV1().Manifests(b.ns()).Update(obj)
+			}
+		case errors.IsNotFound(err):
+			obj, err = b.create()
+			if err == nil {
+				_, err = kc.AkashV1().Manifests(b.ns()).Create(obj)
+			}
 		}
-	}
-	return err
+		return err
+	})
 }
@@ -0,0 +1,241 @@
+package kube
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ovrclk/akash/manifest"
+	akashv1 "github.com/ovrclk/akash/pkg/apis/akash.network/v1"
+	afake "github.com/ovrclk/akash/pkg/client/clientset/versioned/fake"
+	"github.com/ovrclk/akash/types"
+	"github.com/ovrclk/akash/types/unit"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestApplyManifest_patch(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.ManifestPatchUpdates = true
+
+	const ns = "lease"
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+
+	b := newManifestBuilder(log.NewNopLogger(), ns, mtypes.LeaseID{}, group)
+
+	existing, err := b.create()
+	require.NoError(t, err)
+	existing.Namespace = ns
+	existing.Annotations = map[string]string{"example.com/unmanaged": "keep"}
+
+	kc := afake.NewSimpleClientset(existing)
+
+	group.Services[0].Count = 3
+	require.NoError(t, applyManifest(context.Background(), kc, b))
+
+	obj, err := kc.AkashV1().Manifests(ns).Get(b.name(), metav1.GetOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "keep", obj.Annotations["example.com/unmanaged"])
+	assert.Equal(t, b.labels(), obj.Labels)
+	require.Len(t, obj.Spec.ManifestGroup.Services, 1)
+	assert.Equal(t, uint32(3), obj.Spec.ManifestGroup.Services[0].Count)
+}
+
+func TestApplyLease_order(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentIngressDomain = "provider.test"
+
+	group := &manifest.Group{
+		Name: "test",
+		Services: []manifest.Service{
+			*testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi}),
+			*testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi}),
+			{Name: "worker", Image: "busybox", Unit: types.Unit{CPU: 100, Memory: 128 * unit.Mi}, Count: 1},
+		},
+	}
+	group.Services[1].Name = "api"
+
+	for i := 0; i < 3; i++ {
+		kc := kfake.NewSimpleClientset()
+		mc := afake.NewSimpleClientset()
+
+		require.NoError(t, applyLease(context.Background(), kc, mc, log.NewNopLogger(), "host", "lease", mtypes.LeaseID{}, group))
+
+		var created []string
+		for _, action := range kc.Actions() {
+			if action.GetVerb() == "create" {
+				created = append(created, action.GetResource().Resource)
+			}
+		}
+
+		assert.Equal(t, []string{
+			"namespaces",
+			"deployments", "deployments", "deployments",
+			"services", "services",
+			"ingresses", "ingresses",
+		}, created)
+	}
+}
+
+func TestApplyLease_canceled(t *testing.T) {
+	group := &manifest.Group{
+		Name:     "test",
+		Services: []manifest.Service{*testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})},
+	}
+
+	kc := kfake.NewSimpleClientset()
+	mc := afake.NewSimpleClientset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := applyLease(ctx, kc, mc, log.NewNopLogger(), "host", "lease", mtypes.LeaseID{}, group)
+	assert.Equal(t, context.Canceled, err)
+	assert.Empty(t, kc.Actions())
+	assert.Empty(t, mc.Actions())
+}
+
+func TestPrepareEnvironment_canceled(t *testing.T) {
+	kc := kfake.NewSimpleClientset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Equal(t, context.Canceled, prepareEnvironment(ctx, kc, "lease"))
+	assert.Empty(t, kc.Actions())
+
+	// canceled while looking the namespace up
+	ctx, cancel = context.WithCancel(context.Background())
+	kc.PrependReactor("get", "namespaces", func(ktesting.Action) (bool, runtime.Object, error) {
+		cancel()
+		return false, nil, nil
+	})
+
+	assert.Equal(t, context.Canceled, prepareEnvironment(ctx, kc, "lease"))
+	for _, action := range kc.Actions() {
+		assert.NotEqual(t, "create", action.GetVerb())
+	}
+
+	_, err := kc.CoreV1().Namespaces().Get("lease", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestApplyPVC(t *testing.T) {
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi, Storage: 10 * unit.Gi})
+	service.StorageMount = "/data"
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+	b := newPVCBuilder(log.NewNopLogger(), mtypes.LeaseID{}, group, service)
+
+	kc := kfake.NewSimpleClientset()
+	require.NoError(t, applyPVC(context.Background(), kc, b))
+
+	service.Unit.Storage = 20 * unit.Gi
+	require.NoError(t, applyPVC(context.Background(), kc, b))
+
+	obj, err := kc.CoreV1().PersistentVolumeClaims(b.ns()).Get(b.name(), metav1.GetOptions{})
+	require.NoError(t, err)
+	size := obj.Spec.Resources.Requests["storage"]
+	assert.Equal(t, "20Gi", size.String())
+}
+
+func TestApplyLease_stateful(t *testing.T) {
+	lid := mtypes.LeaseID{DSeq: 1, GSeq: 1, OSeq: 1}
+	ns := lidNS(lid)
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi, Storage: 10 * unit.Gi})
+	service.Count = 3
+	service.Stateful = true
+	service.StorageMount = "/data"
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+
+	kc := kfake.NewSimpleClientset()
+	mc := afake.NewSimpleClientset()
+	require.NoError(t, applyLease(context.Background(), kc, mc, log.NewNopLogger(), "host", "lease", lid, group))
+
+	set, err := kc.AppsV1().StatefulSets(ns).Get("web", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), *set.Spec.Replicas)
+	assert.Len(t, set.Spec.VolumeClaimTemplates, 1)
+
+	headless, err := kc.CoreV1().Services(ns).Get("web-headless", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "None", headless.Spec.ClusterIP)
+
+	_, err = kc.AppsV1().Deployments(ns).Get("web", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// no longer stateful: replaced by a deployment
+	group.Services[0].Stateful = false
+	group.Services[0].Count = 1
+	require.NoError(t, applyLease(context.Background(), kc, mc, log.NewNopLogger(), "host", "lease", lid, group))
+
+	_, err = kc.AppsV1().Deployments(ns).Get("web", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = kc.AppsV1().StatefulSets(ns).Get("web", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	_, err = kc.CoreV1().Services(ns).Get("web-headless", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestApplyLease_redeployLegacy(t *testing.T) {
+	const mns = "lease"
+
+	lid := mtypes.LeaseID{DSeq: 1, GSeq: 1, OSeq: 1}
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+
+	// deployed before lease namespaces were named after the full lease ID
+	sha := sha1.Sum(nil)
+	legacy := hex.EncodeToString(sha[:])
+
+	legacyNS := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   legacy,
+		Labels: map[string]string{akashManagedLabelName: "true"},
+	}}
+	legacyDeployment, err := testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	legacyDeployment.Namespace = legacy
+
+	legacyManifest, err := akashv1.NewManifest(legacy, lid, group)
+	require.NoError(t, err)
+	legacyManifest.Namespace = mns
+	legacyManifest.Labels = map[string]string{akashManagedLabelName: "true"}
+
+	kc := kfake.NewSimpleClientset(legacyNS, legacyDeployment)
+	mc := afake.NewSimpleClientset(legacyManifest)
+
+	// the provider's deployments no longer include the legacy manifest
+	deployments, err := leaseDeployments(mc, mns)
+	require.NoError(t, err)
+	assert.Empty(t, deployments)
+
+	require.NoError(t, applyLease(context.Background(), kc, mc, log.NewNopLogger(), "host", mns, lid, group))
+
+	_, err = kc.AppsV1().Deployments(lidNS(lid)).Get(service.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	deployments, err = leaseDeployments(mc, mns)
+	require.NoError(t, err)
+	require.Len(t, deployments, 1)
+	assert.True(t, lid.Equals(deployments[0].LeaseID()))
+
+	// the legacy namespace and manifest are left for gc
+	orphans, err := orphanedResources(kc, mc, mns, lid.Provider, []mtypes.LeaseID{lid})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []OrphanedResource{
+		{Kind: orphanKindNamespace, Name: legacy},
+		{Kind: orphanKindManifest, Namespace: mns, Name: legacy},
+	}, orphans)
+}
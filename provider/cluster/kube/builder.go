@@ -2,8 +2,12 @@ package kube
 
 import (
 	"crypto/sha1"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 
@@ -15,15 +19,44 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	extv1 "k8s.io/api/extensions/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
 	akashManagedLabelName         = "akash.network"
 	akashManifestServiceLabelName = "akash.network/manifest-service"
 	akashDefaultIngressBackend    = "http"
+
+	// lease namespace labels; the lease ID label is dseq.gseq.oseq, unique
+	// together with the owner and provider labels.
+	akashLeaseIDLabelName       = "akash.network/lease-id"
+	akashLeaseOwnerLabelName    = "akash.network/lease-owner"
+	akashLeaseProviderLabelName = "akash.network/lease-provider"
+
+	// lease namespace annotations
+	akashLeaseAnnotationName         = "akash.network/lease"
+	akashCreatedHeightAnnotationName = "akash.network/created-height"
+
+	certManagerClusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+	egressBandwidthAnnotation          = "kubernetes.io/egress-bandwidth"
+)
+
+var (
+	errEphemeralStorageExceeded = errors.New("ephemeral storage exceeds provider limit")
+	errInvalidIngressHost       = errors.New("invalid ingress host")
+	errIngressTLSUnavailable    = errors.New("ingress TLS unavailable")
+	errAnnotationNotAllowed     = errors.New("pod annotation not allowed")
+
+	errInvalidDedicatedNodeLabel = errors.New("invalid dedicated node label")
+	errInvalidEgressBandwidth    = errors.New("invalid egress bandwidth")
+	errInvalidProbe              = errors.New("invalid probe")
+	errInvalidServiceType        = errors.New("invalid service type")
+	errInvalidStorageMount       = errors.New("invalid storage mount")
+	errInvalidRegistryAuth       = errors.New("invalid registry credentials")
 )
 
 type builder struct {
@@ -54,21 +87,53 @@ func (b *nsBuilder) name() string {
 	return b.ns()
 }
 
+// labels identify the lease of the namespace, so that operators can select
+// namespaces by lease, owner or provider.
+func (b *nsBuilder) labels() map[string]string {
+	obj := b.builder.labels()
+	obj[akashLeaseIDLabelName] = fmt.Sprintf("%v.%v.%v", b.lid.DSeq, b.lid.GSeq, b.lid.OSeq)
+	obj[akashLeaseOwnerLabelName] = b.lid.Owner.String()
+	obj[akashLeaseProviderLabelName] = b.lid.Provider.String()
+	return obj
+}
+
+// annotations record the full lease ID, which is too long for a label value,
+// and the height the deployment was created at, which is its dseq.
+func (b *nsBuilder) annotations() map[string]string {
+	return map[string]string{
+		akashLeaseAnnotationName:         lidPath(b.lid),
+		akashCreatedHeightAnnotationName: strconv.FormatUint(b.lid.DSeq, 10),
+	}
+}
+
 func (b *nsBuilder) create() (*corev1.Namespace, error) {
 	return &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   b.ns(),
-			Labels: b.labels(),
+			Name:        b.ns(),
+			Labels:      b.labels(),
+			Annotations: b.annotations(),
 		},
 	}, nil
 }
 
+// update sets the lease labels and annotations, keeping any others.
 func (b *nsBuilder) update(obj *corev1.Namespace) (*corev1.Namespace, error) {
 	obj.Name = b.ns()
-	obj.Labels = b.labels()
+	obj.Labels = mergeStrings(obj.Labels, b.labels())
+	obj.Annotations = mergeStrings(obj.Annotations, b.annotations())
 	return obj, nil
 }
 
+func mergeStrings(dst, src map[string]string) map[string]string {
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
 // deployment
 type deploymentBuilder struct {
 	builder
@@ -93,6 +158,10 @@ func (b *deploymentBuilder) labels() map[string]string {
 }
 
 func (b *deploymentBuilder) create() (*appsv1.Deployment, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
 	replicas := int32(b.service.Count)
 	kdeployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -106,47 +175,275 @@ func (b *deploymentBuilder) create() (*appsv1.Deployment, error) {
 			Replicas: &replicas,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: b.labels(),
+					Labels:      b.labels(),
+					Annotations: b.annotations(),
 				},
 				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{b.container()},
+					Containers:       []corev1.Container{b.container()},
+					Volumes:          b.volumes(),
+					ImagePullSecrets: b.imagePullSecrets(),
 				},
 			},
 		},
 	}
+	b.schedule(&kdeployment.Spec.Template.Spec)
 
 	return kdeployment, nil
 }
 
 func (b *deploymentBuilder) update(obj *appsv1.Deployment) (*appsv1.Deployment, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
 	replicas := int32(b.service.Count)
 	obj.Labels = b.labels()
 	obj.Spec.Selector.MatchLabels = b.labels()
 	obj.Spec.Replicas = &replicas
 	obj.Spec.Template.Labels = b.labels()
+	obj.Spec.Template.Annotations = b.annotations()
 	obj.Spec.Template.Spec.Containers = []corev1.Container{b.container()}
+	obj.Spec.Template.Spec.Volumes = b.volumes()
+	obj.Spec.Template.Spec.ImagePullSecrets = b.imagePullSecrets()
+	b.schedule(&obj.Spec.Template.Spec)
 	return obj, nil
 }
 
-func (b *deploymentBuilder) container() corev1.Container {
-	qcpu := resource.NewScaledQuantity(int64(b.service.Unit.CPU), resource.Milli)
-	qmem := resource.NewQuantity(int64(b.service.Unit.Memory), resource.DecimalSI)
+func (b *deploymentBuilder) validate() error {
+	max := config.DeploymentEphemeralStorageMax
+	if storage := b.ephemeralStorage(); max > 0 && storage > max {
+		return fmt.Errorf("%w: service %v (%v > %v)", errEphemeralStorageExceeded, b.service.Name, storage, max)
+	}
+	for key := range b.service.Annotations {
+		if !annotationAllowed(key) {
+			return fmt.Errorf("%w: service %v: %q", errAnnotationNotAllowed, b.service.Name, key)
+		}
+	}
+	if _, _, err := dedicatedNodeLabel(); err != nil {
+		return err
+	}
+	if limit := config.DeploymentEgressBandwidth; limit != "" {
+		if _, err := resource.ParseQuantity(limit); err != nil {
+			return fmt.Errorf("%w: %q", errInvalidEgressBandwidth, limit)
+		}
+	}
+	if err := b.validateProbe(); err != nil {
+		return err
+	}
+	if err := b.validateStorageMount(); err != nil {
+		return err
+	}
+	return nil
+}
 
-	kcontainer := corev1.Container{
-		Name:  b.service.Name,
-		Image: b.service.Image,
-		Args:  b.service.Args,
-		Resources: corev1.ResourceRequirements{
-			Limits: corev1.ResourceList{
-				corev1.ResourceCPU:    qcpu.DeepCopy(),
-				corev1.ResourceMemory: qmem.DeepCopy(),
+// validateStorageMount checks that a persistent volume is mounted at an
+// absolute path and sized.  The claim of a deployment can be mounted by a
+// single node, so the service must run a single replica unless it is
+// stateful, with a claim per replica.
+func (b *deploymentBuilder) validateStorageMount() error {
+	if !b.persistent() {
+		return nil
+	}
+	switch {
+	case !path.IsAbs(b.service.StorageMount):
+		return fmt.Errorf("%w: service %v: path %q", errInvalidStorageMount, b.service.Name, b.service.StorageMount)
+	case b.service.Unit.Storage == 0:
+		return fmt.Errorf("%w: service %v: no storage", errInvalidStorageMount, b.service.Name)
+	case b.service.Count > 1 && !b.service.Stateful:
+		return fmt.Errorf("%w: service %v: %v replicas", errInvalidStorageMount, b.service.Name, b.service.Count)
+	}
+	return nil
+}
+
+func (b *deploymentBuilder) validateProbe() error {
+	probe := b.service.Probe
+	if probe.Path != "" && !strings.HasPrefix(probe.Path, "/") {
+		return fmt.Errorf("%w: service %v: path %q", errInvalidProbe, b.service.Name, probe.Path)
+	}
+	if probe.Port == 0 {
+		return nil
+	}
+	for _, expose := range b.service.Expose {
+		if expose.Port == probe.Port {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: service %v: port %v not exposed", errInvalidProbe, b.service.Name, probe.Port)
+}
+
+// schedule restricts the pod to dedicated nodes, if configured.
+func (b *deploymentBuilder) schedule(spec *corev1.PodSpec) {
+	key, value, err := dedicatedNodeLabel()
+	if err != nil || key == "" {
+		spec.NodeSelector = nil
+		spec.Tolerations = nil
+		return
+	}
+	spec.NodeSelector = map[string]string{key: value}
+	spec.Tolerations = []corev1.Toleration{{
+		Key:      key,
+		Operator: corev1.TolerationOpEqual,
+		Value:    value,
+		Effect:   corev1.TaintEffectNoSchedule,
+	}}
+}
+
+func dedicatedNodeLabel() (string, string, error) {
+	if config.DeploymentDedicatedNodeLabel == "" {
+		return "", "", nil
+	}
+	parts := strings.SplitN(config.DeploymentDedicatedNodeLabel, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("%w: %q", errInvalidDedicatedNodeLabel, config.DeploymentDedicatedNodeLabel)
+	}
+	return parts[0], parts[1], nil
+}
+
+func (b *deploymentBuilder) annotations() map[string]string {
+	limit := b.egressBandwidth()
+	if len(b.service.Annotations) == 0 && limit == "" {
+		return nil
+	}
+	obj := make(map[string]string, len(b.service.Annotations)+1)
+	for k, v := range b.service.Annotations {
+		obj[k] = v
+	}
+	if limit != "" {
+		obj[egressBandwidthAnnotation] = limit
+	}
+	return obj
+}
+
+// egressBandwidth returns the egress limit to annotate pods with, if the
+// cluster network can enforce it.
+func (b *deploymentBuilder) egressBandwidth() string {
+	limit := config.DeploymentEgressBandwidth
+	if limit == "" {
+		return ""
+	}
+	if !config.DeploymentCNIBandwidth {
+		b.log.Info("egress bandwidth limit configured but CNI bandwidth support not enabled; not applied",
+			"service", b.service.Name, "limit", limit)
+		return ""
+	}
+	return limit
+}
+
+func annotationAllowed(key string) bool {
+	for _, allowed := range config.DeploymentPodAnnotationsAllowed {
+		if key == allowed {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/") && strings.HasPrefix(key, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *deploymentBuilder) ephemeralStorage() int64 {
+	if b.service.Unit.Storage == 0 || b.persistent() {
+		return config.DeploymentEphemeralStorageDefault
+	}
+	return int64(b.service.Unit.Storage)
+}
+
+// imagePullSecrets references the lease's registry credentials, if the
+// provider has any.
+func (b *deploymentBuilder) imagePullSecrets() []corev1.LocalObjectReference {
+	if !registryAuthConfigured() {
+		return nil
+	}
+	return []corev1.LocalObjectReference{{Name: pullSecretName}}
+}
+
+// persistent returns whether the service's storage is a persistent volume.
+func (b *deploymentBuilder) persistent() bool {
+	return b.service.StorageMount != ""
+}
+
+// volumes returns the pod volume backed by the service's persistent volume
+// claim, if any.
+func (b *deploymentBuilder) volumes() []corev1.Volume {
+	if !b.persistent() {
+		return nil
+	}
+	return []corev1.Volume{{
+		Name: pvcVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: pvcName(b.service),
 			},
-			// TODO: this prevents over-subscription.  skip for now.
-			// Requests: corev1.ResourceList{
-			// 	corev1.ResourceCPU:    qcpu.DeepCopy(),
-			// 	corev1.ResourceMemory: qmem.DeepCopy(),
-			// },
 		},
+	}}
+}
+
+// resources returns the compute sold with the lease as both the requests and
+// the limits of the container, so the scheduler reserves what the tenant
+// pays for and the node doesn't let it use more.  CPU units are millicores;
+// memory and storage are bytes.
+func (b *deploymentBuilder) resources() corev1.ResourceRequirements {
+	qcpu := resource.NewMilliQuantity(int64(b.service.Unit.CPU), resource.DecimalSI)
+	qmem := resource.NewQuantity(int64(b.service.Unit.Memory), resource.BinarySI)
+	qstorage := resource.NewQuantity(b.ephemeralStorage(), resource.DecimalSI)
+
+	return corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:              qcpu.DeepCopy(),
+			corev1.ResourceMemory:           qmem.DeepCopy(),
+			corev1.ResourceEphemeralStorage: qstorage.DeepCopy(),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:              qcpu.DeepCopy(),
+			corev1.ResourceMemory:           qmem.DeepCopy(),
+			corev1.ResourceEphemeralStorage: qstorage.DeepCopy(),
+		},
+	}
+}
+
+// probe returns the health check of the container: an HTTP GET if the
+// service declares a probe path and a TCP connect otherwise, against the
+// probe port or the first exposed port.  Services exposing no ports are not
+// probed.
+func (b *deploymentBuilder) probe() *corev1.Probe {
+	mprobe := b.service.Probe
+
+	port := mprobe.Port
+	if port == 0 && len(b.service.Expose) > 0 {
+		port = b.service.Expose[0].Port
+	}
+	if port == 0 {
+		return nil
+	}
+
+	probe := &corev1.Probe{
+		InitialDelaySeconds: int32(mprobe.InitialDelay),
+		PeriodSeconds:       int32(mprobe.Period),
+		TimeoutSeconds:      int32(mprobe.Timeout),
+		FailureThreshold:    int32(mprobe.FailureThreshold),
+	}
+	if mprobe.Path != "" {
+		probe.HTTPGet = &corev1.HTTPGetAction{
+			Path: mprobe.Path,
+			Port: intstr.FromInt(int(port)),
+		}
+	} else {
+		probe.TCPSocket = &corev1.TCPSocketAction{
+			Port: intstr.FromInt(int(port)),
+		}
+	}
+	return probe
+}
+
+func (b *deploymentBuilder) container() corev1.Container {
+	kcontainer := corev1.Container{
+		Name:           b.service.Name,
+		Image:          b.service.Image,
+		Command:        b.service.Command,
+		Args:           b.service.Args,
+		Resources:      b.resources(),
+		ReadinessProbe: b.probe(),
+		LivenessProbe:  b.probe(),
 	}
 
 	for _, env := range b.service.Env {
@@ -165,6 +462,13 @@ func (b *deploymentBuilder) container() corev1.Container {
 		})
 	}
 
+	if b.persistent() {
+		kcontainer.VolumeMounts = []corev1.VolumeMount{{
+			Name:      pvcVolumeName,
+			MountPath: b.service.StorageMount,
+		}}
+	}
+
 	return kcontainer
 }
 
@@ -183,15 +487,16 @@ func newServiceBuilder(log log.Logger, lid mtypes.LeaseID, group *manifest.Group
 }
 
 func (b *serviceBuilder) create() (*corev1.Service, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   b.name(),
 			Labels: b.labels(),
 		},
 		Spec: corev1.ServiceSpec{
-			// use NodePort to support GCP. GCP provides a new IP address for every ingress
-			// and requires the service type to be either NodePort or LoadBalancer
-			Type:     config.DeploymentServiceType,
+			Type:     b.serviceType(),
 			Selector: b.labels(),
 			Ports:    b.ports(),
 		},
@@ -199,12 +504,76 @@ func (b *serviceBuilder) create() (*corev1.Service, error) {
 }
 
 func (b *serviceBuilder) update(obj *corev1.Service) (*corev1.Service, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	// keep the node ports already assigned to the service
+	nodePorts := make(map[string]int32, len(obj.Spec.Ports))
+	for _, port := range obj.Spec.Ports {
+		nodePorts[port.Name] = port.NodePort
+	}
+
 	obj.Labels = b.labels()
+	obj.Spec.Type = b.serviceType()
 	obj.Spec.Selector = b.labels()
 	obj.Spec.Ports = b.ports()
+	if obj.Spec.Type != corev1.ServiceTypeClusterIP {
+		for idx := range obj.Spec.Ports {
+			obj.Spec.Ports[idx].NodePort = nodePorts[obj.Spec.Ports[idx].Name]
+		}
+	}
 	return obj, nil
 }
 
+// validate checks the service types selected by the exposes: global ports
+// are reached through a NodePort or LoadBalancer service, or through an
+// ingress if no type is selected, and internal ports only through a
+// ClusterIP service.
+func (b *serviceBuilder) validate() error {
+	for _, expose := range b.service.Expose {
+		switch stype := corev1.ServiceType(expose.ServiceType); stype {
+		case "":
+		case corev1.ServiceTypeClusterIP:
+			if expose.Global {
+				return fmt.Errorf("%w: service %v: global port %v can't be %v",
+					errInvalidServiceType, b.service.Name, expose.Port, stype)
+			}
+		case corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+			if !expose.Global {
+				return fmt.Errorf("%w: service %v: internal port %v can't be %v",
+					errInvalidServiceType, b.service.Name, expose.Port, stype)
+			}
+		default:
+			return fmt.Errorf("%w: service %v: %q", errInvalidServiceType, b.service.Name, stype)
+		}
+	}
+	return nil
+}
+
+// serviceType returns the widest type selected by the exposes of the
+// service, or the provider's default if none selects one.
+func (b *serviceBuilder) serviceType() corev1.ServiceType {
+	rank := map[corev1.ServiceType]int{
+		corev1.ServiceTypeClusterIP:    1,
+		corev1.ServiceTypeNodePort:     2,
+		corev1.ServiceTypeLoadBalancer: 3,
+	}
+
+	var stype corev1.ServiceType
+	for _, expose := range b.service.Expose {
+		if t := corev1.ServiceType(expose.ServiceType); rank[t] > rank[stype] {
+			stype = t
+		}
+	}
+	if stype == "" {
+		// use NodePort to support GCP. GCP provides a new IP address for every ingress
+		// and requires the service type to be either NodePort or LoadBalancer
+		return config.DeploymentServiceType
+	}
+	return stype
+}
+
 func (b *serviceBuilder) ports() []corev1.ServicePort {
 	ports := make([]corev1.ServicePort, 0, len(b.service.Expose))
 	for _, expose := range b.service.Expose {
@@ -217,18 +586,339 @@ func (b *serviceBuilder) ports() []corev1.ServicePort {
 	return ports
 }
 
+// pod disruption budget.  Budgets are built as policy/v1beta1 objects, the
+// only version the vendored k8s.io/api has, and sent as policy/v1 where the
+// cluster serves it (see pdb.go).
+type pdbBuilder struct {
+	deploymentBuilder
+}
+
+func newPDBBuilder(log log.Logger, lid mtypes.LeaseID, group *manifest.Group, service *manifest.Service) *pdbBuilder {
+	return &pdbBuilder{
+		deploymentBuilder: deploymentBuilder{
+			builder: builder{log: log.With("module", "kube-builder"), lid: lid, group: group},
+			service: service,
+		},
+	}
+}
+
+// minAvailable keeps all but one replica up during voluntary disruptions.
+// Single-replica services get no budget.
+func (b *pdbBuilder) minAvailable() (int32, bool) {
+	if b.service.Count <= 1 {
+		return 0, false
+	}
+	return int32(b.service.Count - 1), true
+}
+
+func (b *pdbBuilder) create() (*policyv1beta1.PodDisruptionBudget, error) {
+	obj := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   b.name(),
+			Labels: b.labels(),
+		},
+	}
+	return b.update(obj)
+}
+
+func (b *pdbBuilder) update(obj *policyv1beta1.PodDisruptionBudget) (*policyv1beta1.PodDisruptionBudget, error) {
+	min, _ := b.minAvailable()
+	minAvailable := intstr.FromInt(int(min))
+	obj.Labels = b.labels()
+	obj.Spec.MinAvailable = &minAvailable
+	obj.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: b.labels(),
+	}
+	return obj, nil
+}
+
+// stateful set
+type statefulSetBuilder struct {
+	deploymentBuilder
+}
+
+func newStatefulSetBuilder(log log.Logger, lid mtypes.LeaseID, group *manifest.Group, service *manifest.Service) *statefulSetBuilder {
+	return &statefulSetBuilder{
+		deploymentBuilder: deploymentBuilder{
+			builder: builder{log: log.With("module", "kube-builder"), lid: lid, group: group},
+			service: service,
+		},
+	}
+}
+
+func (b *statefulSetBuilder) create() (*appsv1.StatefulSet, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	replicas := int32(b.service.Count)
+	obj := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   b.name(),
+			Labels: b.labels(),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: headlessServiceName(b.service),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: b.labels(),
+			},
+			Replicas:            &replicas,
+			PodManagementPolicy: appsv1.OrderedReadyPodManagement,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      b.labels(),
+					Annotations: b.annotations(),
+				},
+				Spec: corev1.PodSpec{
+					Containers:       []corev1.Container{b.container()},
+					ImagePullSecrets: b.imagePullSecrets(),
+				},
+			},
+			VolumeClaimTemplates: b.volumeClaimTemplates(),
+		},
+	}
+	b.schedule(&obj.Spec.Template.Spec)
+
+	return obj, nil
+}
+
+// update leaves the volume claim templates, which can't be changed once the
+// set exists.
+func (b *statefulSetBuilder) update(obj *appsv1.StatefulSet) (*appsv1.StatefulSet, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	replicas := int32(b.service.Count)
+	obj.Labels = b.labels()
+	obj.Spec.Replicas = &replicas
+	obj.Spec.Template.Labels = b.labels()
+	obj.Spec.Template.Annotations = b.annotations()
+	obj.Spec.Template.Spec.Containers = []corev1.Container{b.container()}
+	obj.Spec.Template.Spec.ImagePullSecrets = b.imagePullSecrets()
+	b.schedule(&obj.Spec.Template.Spec)
+	return obj, nil
+}
+
+// volumeClaimTemplates gives each replica of a service with persistent
+// storage a claim of its own, mounted by the container as the pod volume
+// of a deployment would be.
+func (b *statefulSetBuilder) volumeClaimTemplates() []corev1.PersistentVolumeClaim {
+	if !b.persistent() {
+		return nil
+	}
+	pvc := newPVCBuilder(b.log, b.lid, b.group, b.service)
+	return []corev1.PersistentVolumeClaim{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   pvcVolumeName,
+			Labels: b.labels(),
+		},
+		Spec: pvc.spec(),
+	}}
+}
+
+// headless service
+type headlessServiceBuilder struct {
+	deploymentBuilder
+}
+
+func newHeadlessServiceBuilder(log log.Logger, lid mtypes.LeaseID, group *manifest.Group, service *manifest.Service) *headlessServiceBuilder {
+	return &headlessServiceBuilder{
+		deploymentBuilder: deploymentBuilder{
+			builder: builder{log: log.With("module", "kube-builder"), lid: lid, group: group},
+			service: service,
+		},
+	}
+}
+
+// headlessServiceName names the service giving each replica of a stateful
+// set a DNS name (<service>-<ordinal>.<service>-headless).
+func headlessServiceName(service *manifest.Service) string {
+	return service.Name + "-headless"
+}
+
+func (b *headlessServiceBuilder) name() string {
+	return headlessServiceName(b.service)
+}
+
+func (b *headlessServiceBuilder) create() (*corev1.Service, error) {
+	return b.update(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: b.name(),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: corev1.ClusterIPNone,
+		},
+	})
+}
+
+func (b *headlessServiceBuilder) update(obj *corev1.Service) (*corev1.Service, error) {
+	ports := make([]corev1.ServicePort, 0, len(b.service.Expose))
+	for _, expose := range b.service.Expose {
+		ports = append(ports, corev1.ServicePort{
+			Name:       strconv.Itoa(int(expose.Port)),
+			Port:       int32(expose.Port),
+			TargetPort: intstr.FromInt(int(expose.Port)),
+		})
+	}
+
+	obj.Labels = b.labels()
+	obj.Spec.Selector = b.labels()
+	obj.Spec.Ports = ports
+	// replicas are addressable before they are ready, so peers can find
+	// each other while starting in order
+	obj.Spec.PublishNotReadyAddresses = true
+	return obj, nil
+}
+
+// image pull secret
+type pullSecretBuilder struct {
+	builder
+}
+
+const pullSecretName = "akash-registry"
+
+func newPullSecretBuilder(log log.Logger, lid mtypes.LeaseID, group *manifest.Group) *pullSecretBuilder {
+	return &pullSecretBuilder{
+		builder: builder{log: log.With("module", "kube-builder"), lid: lid, group: group},
+	}
+}
+
+func registryAuthConfigured() bool {
+	return config.DeploymentRegistryServer != ""
+}
+
+func (b *pullSecretBuilder) name() string {
+	return pullSecretName
+}
+
+func (b *pullSecretBuilder) create() (*corev1.Secret, error) {
+	return b.update(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: b.name(),
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+	})
+}
+
+func (b *pullSecretBuilder) update(obj *corev1.Secret) (*corev1.Secret, error) {
+	data, err := b.dockerConfig()
+	if err != nil {
+		return nil, err
+	}
+	obj.Labels = b.labels()
+	obj.Data = map[string][]byte{corev1.DockerConfigJsonKey: data}
+	return obj, nil
+}
+
+// dockerConfig returns the configured registry credentials in the format of
+// ~/.docker/config.json, as kubelet reads them from the secret.
+func (b *pullSecretBuilder) dockerConfig() ([]byte, error) {
+	server := config.DeploymentRegistryServer
+	username := config.DeploymentRegistryUsername
+	password := config.DeploymentRegistryPassword
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("%w: %v: username and password required", errInvalidRegistryAuth, server)
+	}
+
+	type authConfig struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	}
+	return json.Marshal(map[string]map[string]authConfig{
+		"auths": {
+			server: {
+				Username: username,
+				Password: password,
+				Auth:     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	})
+}
+
+// persistent volume claim
+type pvcBuilder struct {
+	deploymentBuilder
+}
+
+const pvcVolumeName = "storage"
+
+func newPVCBuilder(log log.Logger, lid mtypes.LeaseID, group *manifest.Group, service *manifest.Service) *pvcBuilder {
+	return &pvcBuilder{
+		deploymentBuilder: deploymentBuilder{
+			builder: builder{log: log.With("module", "kube-builder"), lid: lid, group: group},
+			service: service,
+		},
+	}
+}
+
+func pvcName(service *manifest.Service) string {
+	return service.Name + "-storage"
+}
+
+func (b *pvcBuilder) name() string {
+	return pvcName(b.service)
+}
+
+func (b *pvcBuilder) create() (*corev1.PersistentVolumeClaim, error) {
+	if err := b.validateStorageMount(); err != nil {
+		return nil, err
+	}
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   b.name(),
+			Labels: b.labels(),
+		},
+		Spec: b.spec(),
+	}, nil
+}
+
+// update resizes the claim.  The storage class can't be changed once the
+// claim exists, and a claim can only grow if its class allows expansion.
+func (b *pvcBuilder) update(obj *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	if err := b.validateStorageMount(); err != nil {
+		return nil, err
+	}
+	obj.Labels = b.labels()
+	obj.Spec.Resources.Requests = b.requests()
+	return obj, nil
+}
+
+func (b *pvcBuilder) spec() corev1.PersistentVolumeClaimSpec {
+	spec := corev1.PersistentVolumeClaimSpec{
+		AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		Resources: corev1.ResourceRequirements{
+			Requests: b.requests(),
+		},
+	}
+	if class := config.DeploymentStorageClass; class != "" {
+		spec.StorageClassName = &class
+	}
+	return spec
+}
+
+func (b *pvcBuilder) requests() corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceStorage: *resource.NewQuantity(int64(b.service.Unit.Storage), resource.BinarySI),
+	}
+}
+
 // ingress
 type ingressBuilder struct {
 	deploymentBuilder
 	expose *manifest.ServiceExpose
+	hosts  []string
 }
 
 func newIngressBuilder(log log.Logger, host string, lid mtypes.LeaseID, group *manifest.Group, service *manifest.Service, expose *manifest.ServiceExpose) *ingressBuilder {
-	if config.DeploymentIngressStaticHosts {
+	hosts := uniqueHosts(expose.Hosts)
+	if len(hosts) == 0 && config.DeploymentIngressStaticHosts {
 		uid := strings.ToLower(shortuuid.New())
 		h := fmt.Sprintf("%s.%s", uid, config.DeploymentIngressDomain)
 		log.Debug("IngressBuilder: map", "host", h)
-		expose.Hosts = append(expose.Hosts, h)
+		hosts = append(hosts, h)
 	}
 	return &ingressBuilder{
 		deploymentBuilder: deploymentBuilder{
@@ -240,11 +930,42 @@ func newIngressBuilder(log log.Logger, host string, lid mtypes.LeaseID, group *m
 			service: service,
 		},
 		expose: expose,
+		hosts:  hosts,
+	}
+}
+
+// uniqueHosts lower-cases hosts and drops duplicates, preserving order.
+func uniqueHosts(hosts []string) []string {
+	seen := make(map[string]bool, len(hosts))
+	result := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		host = strings.ToLower(host)
+		if seen[host] {
+			continue
+		}
+		seen[host] = true
+		result = append(result, host)
 	}
+	return result
+}
+
+func (b *ingressBuilder) validate() error {
+	for _, host := range b.hosts {
+		if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+			return fmt.Errorf("%w: %q: %v", errInvalidIngressHost, host, strings.Join(errs, ", "))
+		}
+	}
+	if b.expose.HTTPS && config.DeploymentIngressClusterIssuer == "" {
+		return fmt.Errorf("%w: service %v: no cluster issuer configured", errIngressTLSUnavailable, b.service.Name)
+	}
+	return nil
 }
 
 func (b *ingressBuilder) create() (*extv1.Ingress, error) {
-	return &extv1.Ingress{
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+	obj := &extv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   b.name(),
 			Labels: b.labels(),
@@ -252,17 +973,73 @@ func (b *ingressBuilder) create() (*extv1.Ingress, error) {
 		Spec: extv1.IngressSpec{
 			Rules: b.rules(),
 		},
-	}, nil
+	}
+	b.applyTLS(&obj.ObjectMeta, &obj.Spec.TLS)
+	return obj, nil
 }
 
 func (b *ingressBuilder) update(obj *extv1.Ingress) (*extv1.Ingress, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
 	obj.Labels = b.labels()
 	obj.Spec.Rules = b.rules()
+	b.applyTLS(&obj.ObjectMeta, &obj.Spec.TLS)
+	return obj, nil
+}
+
+// createV1 and updateV1 build the networking.k8s.io/v1 ingress, whose rules
+// need a path type and nest the backend service.
+func (b *ingressBuilder) createV1() (*netv1Ingress, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+	obj := &netv1Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   b.name(),
+			Labels: b.labels(),
+		},
+		Spec: netv1IngressSpec{
+			Rules: b.rulesV1(),
+		},
+	}
+	b.applyTLS(&obj.ObjectMeta, &obj.Spec.TLS)
 	return obj, nil
 }
 
+func (b *ingressBuilder) updateV1(obj *netv1Ingress) (*netv1Ingress, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+	obj.Labels = b.labels()
+	obj.Spec.Rules = b.rulesV1()
+	b.applyTLS(&obj.ObjectMeta, &obj.Spec.TLS)
+	return obj, nil
+}
+
+// applyTLS requests a cert-manager certificate for the ingress hosts, stored
+// in the secret named by the TLS block, when the expose asks for HTTPS.
+// Otherwise it removes both.  Other annotations are left alone.
+func (b *ingressBuilder) applyTLS(meta *metav1.ObjectMeta, tls *[]extv1.IngressTLS) {
+	if !b.expose.HTTPS {
+		delete(meta.Annotations, certManagerClusterIssuerAnnotation)
+		*tls = nil
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[certManagerClusterIssuerAnnotation] = config.DeploymentIngressClusterIssuer
+	*tls = []extv1.IngressTLS{
+		{
+			Hosts:      b.hosts,
+			SecretName: b.name() + "-tls",
+		},
+	}
+}
+
 func (b *ingressBuilder) rules() []extv1.IngressRule {
-	rules := make([]extv1.IngressRule, 0, len(b.expose.Hosts))
+	rules := make([]extv1.IngressRule, 0, len(b.hosts))
 	httpRule := &extv1.HTTPIngressRuleValue{
 		Paths: []extv1.HTTPIngressPath{extv1.HTTPIngressPath{
 			Backend: extv1.IngressBackend{
@@ -272,7 +1049,7 @@ func (b *ingressBuilder) rules() []extv1.IngressRule {
 		},
 	}
 
-	for _, host := range b.expose.Hosts {
+	for _, host := range b.hosts {
 		rules = append(rules, extv1.IngressRule{
 			Host:             host,
 			IngressRuleValue: extv1.IngressRuleValue{HTTP: httpRule},
@@ -282,6 +1059,30 @@ func (b *ingressBuilder) rules() []extv1.IngressRule {
 	return rules
 }
 
+func (b *ingressBuilder) rulesV1() []netv1IngressRule {
+	rules := make([]netv1IngressRule, 0, len(b.hosts))
+	httpRule := &netv1HTTPIngressRuleValue{
+		Paths: []netv1HTTPIngressPath{{
+			Path:     "/",
+			PathType: "Prefix",
+			Backend: netv1IngressBackend{
+				Service: &netv1IngressServiceBackend{
+					Name: b.name(),
+					Port: netv1ServiceBackendPort{Number: exposeExternalPort(b.expose)},
+				},
+			},
+		}},
+	}
+
+	for _, host := range b.hosts {
+		rules = append(rules, netv1IngressRule{
+			Host: host,
+			HTTP: httpRule,
+		})
+	}
+	return rules
+}
+
 func exposeExternalPort(expose *manifest.ServiceExpose) int32 {
 	if expose.ExternalPort == 0 {
 		return int32(expose.Port)
@@ -289,11 +1090,15 @@ func exposeExternalPort(expose *manifest.ServiceExpose) int32 {
 	return int32(expose.ExternalPort)
 }
 
+// lidPath returns the full lease ID as owner/dseq/gseq/oseq/provider.
+func lidPath(lid mtypes.LeaseID) string {
+	return fmt.Sprintf("%v/%v/%v/%v/%v", lid.Owner, lid.DSeq, lid.GSeq, lid.OSeq, lid.Provider)
+}
+
+// lidNS returns the namespace of the lease: a hash of the lease ID, so that
+// every lease is deployed to a namespace of its own.
 func lidNS(lid mtypes.LeaseID) string {
-	// TODO
-	var path string
-	// path := lid.String()
-	sha := sha1.Sum([]byte(path))
+	sha := sha1.Sum([]byte(lidPath(lid)))
 	return hex.EncodeToString(sha[:])
 }
 
@@ -333,6 +1138,20 @@ func (b *manifestBuilder) update(obj *akashv1.Manifest) (*akashv1.Manifest, erro
 	return obj, nil
 }
 
+// patch returns a JSON merge patch covering only the fields managed by the provider.
+func (b *manifestBuilder) patch() ([]byte, error) {
+	m, err := akashv1.NewManifest(b.name(), b.lid, b.group)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": b.labels(),
+		},
+		"spec": m.Spec,
+	})
+}
+
 func (b *manifestBuilder) name() string {
 	return lidNS(b.lid)
 }
@@ -0,0 +1,670 @@
+package kube
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ovrclk/akash/manifest"
+	"github.com/ovrclk/akash/types"
+	"github.com/ovrclk/akash/types/unit"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestDeploymentBuilder_ephemeralStorage(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentEphemeralStorageDefault = 256 * unit.Mi
+	config.DeploymentEphemeralStorageMax = 2 * unit.Gi
+
+	tests := []struct {
+		storage  uint64
+		expected int64
+		ok       bool
+	}{
+		{0, 256 * unit.Mi, true},
+		{1 * unit.Gi, 1 * unit.Gi, true},
+		{2 * unit.Gi, 2 * unit.Gi, true},
+		{3 * unit.Gi, 0, false},
+	}
+
+	for _, test := range tests {
+		b := testDeploymentBuilder(testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi, Storage: test.storage}))
+
+		obj, err := b.create()
+		if !test.ok {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+
+		container := obj.Spec.Template.Spec.Containers[0]
+
+		limit := container.Resources.Limits[corev1.ResourceEphemeralStorage]
+		assert.Equal(t, test.expected, limit.Value())
+
+		request := container.Resources.Requests[corev1.ResourceEphemeralStorage]
+		assert.Equal(t, test.expected, request.Value())
+	}
+}
+
+func TestDeploymentBuilder_resources(t *testing.T) {
+	// 0.5 CPU, 512Mi memory
+	b := testDeploymentBuilder(testService(types.Unit{CPU: 500, Memory: 512 * unit.Mi, Storage: 1 * unit.Gi}))
+
+	obj, err := b.create()
+	require.NoError(t, err)
+
+	expected := corev1.ResourceList{
+		corev1.ResourceCPU:              resource.MustParse("500m"),
+		corev1.ResourceMemory:           resource.MustParse("512Mi"),
+		corev1.ResourceEphemeralStorage: resource.MustParse("1073741824"),
+	}
+
+	resources := obj.Spec.Template.Spec.Containers[0].Resources
+	for _, list := range []corev1.ResourceList{resources.Requests, resources.Limits} {
+		require.Len(t, list, len(expected))
+		for name, quantity := range expected {
+			actual := list[name]
+			assert.Zero(t, quantity.Cmp(actual), "%v: %v != %v", name, quantity.String(), actual.String())
+		}
+		cpu, mem := list[corev1.ResourceCPU], list[corev1.ResourceMemory]
+		assert.Equal(t, "500m", cpu.String())
+		assert.Equal(t, "512Mi", mem.String())
+	}
+}
+
+func TestDeploymentBuilder_annotations(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentPodAnnotationsAllowed = []string{"prometheus.io/", "sidecar.example.com/inject"}
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+	service.Annotations = map[string]string{
+		"prometheus.io/scrape":       "true",
+		"prometheus.io/port":         "9090",
+		"sidecar.example.com/inject": "false",
+	}
+
+	obj, err := testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	assert.Equal(t, service.Annotations, obj.Spec.Template.Annotations)
+	assert.Empty(t, obj.Annotations)
+
+	service.Annotations["sidecar.example.com/inject-all"] = "true"
+	_, err = testDeploymentBuilder(service).create()
+	assert.True(t, errors.Is(err, errAnnotationNotAllowed))
+
+	config.DeploymentPodAnnotationsAllowed = nil
+	service.Annotations = map[string]string{"prometheus.io/scrape": "true"}
+	_, err = testDeploymentBuilder(service).update(obj)
+	assert.True(t, errors.Is(err, errAnnotationNotAllowed))
+}
+
+func TestDeploymentBuilder_command(t *testing.T) {
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+
+	obj, err := testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	require.Len(t, obj.Spec.Template.Spec.Containers, 1)
+	assert.Nil(t, obj.Spec.Template.Spec.Containers[0].Command)
+	assert.Nil(t, obj.Spec.Template.Spec.Containers[0].Args)
+
+	service.Command = []string{"/bin/sh", "-c"}
+	service.Args = []string{"exec nginx -g 'daemon off;'"}
+
+	obj, err = testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	assert.Equal(t, service.Command, obj.Spec.Template.Spec.Containers[0].Command)
+	assert.Equal(t, service.Args, obj.Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestDeploymentBuilder_probeTCP(t *testing.T) {
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+
+	obj, err := testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+
+	container := obj.Spec.Template.Spec.Containers[0]
+	expected := &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(80)},
+		},
+	}
+	assert.Equal(t, expected, container.ReadinessProbe)
+	assert.Equal(t, expected, container.LivenessProbe)
+
+	// nothing to probe
+	service.Expose = nil
+	obj, err = testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	assert.Nil(t, obj.Spec.Template.Spec.Containers[0].ReadinessProbe)
+	assert.Nil(t, obj.Spec.Template.Spec.Containers[0].LivenessProbe)
+}
+
+func TestDeploymentBuilder_probeHTTP(t *testing.T) {
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+	service.Expose = append(service.Expose, manifest.ServiceExpose{Port: 8080})
+	service.Probe = manifest.ServiceProbe{
+		Path:             "/healthz",
+		Port:             8080,
+		InitialDelay:     5,
+		Period:           10,
+		Timeout:          2,
+		FailureThreshold: 3,
+	}
+
+	obj, err := testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+
+	container := obj.Spec.Template.Spec.Containers[0]
+	expected := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8080)},
+		},
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      2,
+		FailureThreshold:    3,
+	}
+	assert.Equal(t, expected, container.ReadinessProbe)
+	assert.Equal(t, expected, container.LivenessProbe)
+
+	service.Probe.Port = 9090
+	_, err = testDeploymentBuilder(service).create()
+	assert.True(t, errors.Is(err, errInvalidProbe))
+
+	service.Probe.Port = 0
+	service.Probe.Path = "healthz"
+	_, err = testDeploymentBuilder(service).create()
+	assert.True(t, errors.Is(err, errInvalidProbe))
+}
+
+func TestDeploymentBuilder_dedicatedNodes(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+
+	config.DeploymentDedicatedNodeLabel = ""
+	obj, err := testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	assert.Empty(t, obj.Spec.Template.Spec.NodeSelector)
+	assert.Empty(t, obj.Spec.Template.Spec.Tolerations)
+
+	config.DeploymentDedicatedNodeLabel = "akash.network/role=provider"
+	expected := corev1.Toleration{
+		Key:      "akash.network/role",
+		Operator: corev1.TolerationOpEqual,
+		Value:    "provider",
+		Effect:   corev1.TaintEffectNoSchedule,
+	}
+
+	created, err := testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	updated, err := testDeploymentBuilder(service).update(obj)
+	require.NoError(t, err)
+
+	for _, obj := range []*appsv1.Deployment{created, updated} {
+		spec := obj.Spec.Template.Spec
+		assert.Equal(t, map[string]string{"akash.network/role": "provider"}, spec.NodeSelector)
+		assert.Equal(t, []corev1.Toleration{expected}, spec.Tolerations)
+	}
+
+	config.DeploymentDedicatedNodeLabel = "akash.network/role"
+	_, err = testDeploymentBuilder(service).create()
+	assert.True(t, errors.Is(err, errInvalidDedicatedNodeLabel))
+}
+
+func TestDeploymentBuilder_egressBandwidth(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+
+	// unlimited by default
+	obj, err := testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	assert.NotContains(t, obj.Spec.Template.Annotations, egressBandwidthAnnotation)
+
+	// configured without CNI support: not applied
+	config.DeploymentEgressBandwidth = "10M"
+	obj, err = testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	assert.NotContains(t, obj.Spec.Template.Annotations, egressBandwidthAnnotation)
+
+	config.DeploymentCNIBandwidth = true
+	obj, err = testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	assert.Equal(t, "10M", obj.Spec.Template.Annotations[egressBandwidthAnnotation])
+
+	config.DeploymentEgressBandwidth = "fast"
+	_, err = testDeploymentBuilder(service).create()
+	assert.True(t, errors.Is(err, errInvalidEgressBandwidth))
+}
+
+func TestIngressBuilder_clusterIssuer(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentIngressStaticHosts = false
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+	service.Expose[0].Hosts = []string{"example.com"}
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+
+	config.DeploymentIngressClusterIssuer = "letsencrypt"
+	b := newIngressBuilder(log.NewNopLogger(), "host", mtypes.LeaseID{}, group, service, &service.Expose[0])
+
+	// plain HTTP
+	obj, err := b.create()
+	require.NoError(t, err)
+	assert.NotContains(t, obj.Annotations, certManagerClusterIssuerAnnotation)
+	assert.Empty(t, obj.Spec.TLS)
+
+	service.Expose[0].HTTPS = true
+
+	obj, err = b.create()
+	require.NoError(t, err)
+	assert.Equal(t, "letsencrypt", obj.Annotations[certManagerClusterIssuerAnnotation])
+	require.Len(t, obj.Spec.TLS, 1)
+	assert.Equal(t, []string{"example.com"}, obj.Spec.TLS[0].Hosts)
+	assert.Equal(t, "web-tls", obj.Spec.TLS[0].SecretName)
+
+	config.DeploymentIngressClusterIssuer = ""
+	_, err = b.create()
+	assert.True(t, errors.Is(err, errIngressTLSUnavailable))
+}
+
+func TestIngressBuilder_versions(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentIngressStaticHosts = false
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+	service.Expose[0].Hosts = []string{"example.com"}
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+	b := newIngressBuilder(log.NewNopLogger(), "host", mtypes.LeaseID{}, group, service, &service.Expose[0])
+
+	v1beta1, err := b.create()
+	require.NoError(t, err)
+	assert.Equal(t, b.labels(), v1beta1.Labels)
+	assert.Equal(t, []extv1.IngressRule{{
+		Host: "example.com",
+		IngressRuleValue: extv1.IngressRuleValue{HTTP: &extv1.HTTPIngressRuleValue{
+			Paths: []extv1.HTTPIngressPath{{
+				Backend: extv1.IngressBackend{ServiceName: "web", ServicePort: intstr.FromInt(80)},
+			}},
+		}},
+	}}, v1beta1.Spec.Rules)
+
+	v1, err := b.createV1()
+	require.NoError(t, err)
+	assert.Equal(t, b.labels(), v1.Labels)
+	assert.Equal(t, []netv1IngressRule{{
+		Host: "example.com",
+		HTTP: &netv1HTTPIngressRuleValue{
+			Paths: []netv1HTTPIngressPath{{
+				Path:     "/",
+				PathType: "Prefix",
+				Backend: netv1IngressBackend{
+					Service: &netv1IngressServiceBackend{Name: "web", Port: netv1ServiceBackendPort{Number: 80}},
+				},
+			}},
+		},
+	}}, v1.Spec.Rules)
+
+	// the same TLS handling for both
+	config.DeploymentIngressClusterIssuer = "letsencrypt"
+	service.Expose[0].HTTPS = true
+
+	v1, err = b.updateV1(v1)
+	require.NoError(t, err)
+	assert.Equal(t, "letsencrypt", v1.Annotations[certManagerClusterIssuerAnnotation])
+	assert.Equal(t, []extv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "web-tls"}}, v1.Spec.TLS)
+}
+
+func TestIngressBuilder_annotations(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentIngressStaticHosts = false
+	config.DeploymentIngressClusterIssuer = "letsencrypt"
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+	service.Expose[0].Hosts = []string{"example.com"}
+	service.Expose[0].HTTPS = true
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+	b := newIngressBuilder(log.NewNopLogger(), "host", mtypes.LeaseID{}, group, service, &service.Expose[0])
+
+	obj, err := b.create()
+	require.NoError(t, err)
+	obj.Annotations["nginx.ingress.kubernetes.io/proxy-body-size"] = "8m"
+
+	obj, err = b.update(obj)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		certManagerClusterIssuerAnnotation:            "letsencrypt",
+		"nginx.ingress.kubernetes.io/proxy-body-size": "8m",
+	}, obj.Annotations)
+
+	// back to plain HTTP
+	service.Expose[0].HTTPS = false
+	obj, err = b.update(obj)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"nginx.ingress.kubernetes.io/proxy-body-size": "8m",
+	}, obj.Annotations)
+	assert.Empty(t, obj.Spec.TLS)
+}
+
+func TestServiceBuilder_type(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentServiceType = corev1.ServiceTypeNodePort
+
+	tests := []struct {
+		global   bool
+		stype    string
+		expected corev1.ServiceType
+		ok       bool
+	}{
+		{true, "", corev1.ServiceTypeNodePort, true},
+		{false, "", corev1.ServiceTypeNodePort, true},
+		{false, "ClusterIP", corev1.ServiceTypeClusterIP, true},
+		{true, "NodePort", corev1.ServiceTypeNodePort, true},
+		{true, "LoadBalancer", corev1.ServiceTypeLoadBalancer, true},
+		{true, "ClusterIP", "", false},
+		{false, "NodePort", "", false},
+		{false, "LoadBalancer", "", false},
+		{true, "ExternalName", "", false},
+	}
+
+	for _, test := range tests {
+		service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+		service.Expose[0].Global = test.global
+		service.Expose[0].ServiceType = test.stype
+		group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+		b := newServiceBuilder(log.NewNopLogger(), mtypes.LeaseID{}, group, service)
+
+		obj, err := b.create()
+		if !test.ok {
+			assert.True(t, errors.Is(err, errInvalidServiceType), "%v %v", test.global, test.stype)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, obj.Spec.Type, "%v %v", test.global, test.stype)
+	}
+}
+
+func TestServiceBuilder_nodePorts(t *testing.T) {
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+	service.Expose[0].ServiceType = "NodePort"
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+	b := newServiceBuilder(log.NewNopLogger(), mtypes.LeaseID{}, group, service)
+
+	obj, err := b.create()
+	require.NoError(t, err)
+	require.Len(t, obj.Spec.Ports, 1)
+
+	// assigned by the API server; kept across updates
+	obj.Spec.Ports[0].NodePort = 31080
+	obj, err = b.update(obj)
+	require.NoError(t, err)
+	assert.Equal(t, int32(31080), obj.Spec.Ports[0].NodePort)
+
+	// no node ports on a ClusterIP service
+	service.Expose[0].Global = false
+	service.Expose[0].ServiceType = "ClusterIP"
+	obj, err = b.update(obj)
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ServiceTypeClusterIP, obj.Spec.Type)
+	assert.Zero(t, obj.Spec.Ports[0].NodePort)
+}
+
+func TestPDBBuilder(t *testing.T) {
+	tests := []struct {
+		count        uint32
+		minAvailable int32
+		ok           bool
+	}{
+		{0, 0, false},
+		{1, 0, false},
+		{2, 1, true},
+		{5, 4, true},
+	}
+
+	for _, test := range tests {
+		service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+		service.Count = test.count
+		group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+		b := newPDBBuilder(log.NewNopLogger(), mtypes.LeaseID{}, group, service)
+
+		min, ok := b.minAvailable()
+		assert.Equal(t, test.ok, ok, "count %v", test.count)
+		if !ok {
+			continue
+		}
+		assert.Equal(t, test.minAvailable, min, "count %v", test.count)
+
+		obj, err := b.create()
+		require.NoError(t, err)
+		assert.Equal(t, int(test.minAvailable), obj.Spec.MinAvailable.IntValue())
+		assert.Equal(t, b.labels(), obj.Spec.Selector.MatchLabels)
+	}
+}
+
+func TestPVCBuilder(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentStorageClass = "ssd"
+	config.DeploymentEphemeralStorageDefault = 256 * unit.Mi
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi, Storage: 10 * unit.Gi})
+	service.StorageMount = "/var/lib/data"
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+
+	pvc, err := newPVCBuilder(log.NewNopLogger(), mtypes.LeaseID{}, group, service).create()
+	require.NoError(t, err)
+	assert.Equal(t, "web-storage", pvc.Name)
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, pvc.Spec.AccessModes)
+	require.NotNil(t, pvc.Spec.StorageClassName)
+	assert.Equal(t, "ssd", *pvc.Spec.StorageClassName)
+	size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, "10Gi", size.String())
+
+	obj, err := testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+
+	spec := obj.Spec.Template.Spec
+	assert.Equal(t, []corev1.Volume{{
+		Name: "storage",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "web-storage"},
+		},
+	}}, spec.Volumes)
+	assert.Equal(t, []corev1.VolumeMount{{Name: "storage", MountPath: "/var/lib/data"}}, spec.Containers[0].VolumeMounts)
+
+	// the persisted storage isn't also given as ephemeral storage
+	ephemeral := spec.Containers[0].Resources.Limits[corev1.ResourceEphemeralStorage]
+	assert.Equal(t, int64(256*unit.Mi), ephemeral.Value())
+
+	service.StorageMount = "data"
+	_, err = testDeploymentBuilder(service).create()
+	assert.True(t, errors.Is(err, errInvalidStorageMount))
+
+	service.StorageMount = "/var/lib/data"
+	service.Count = 2
+	_, err = testDeploymentBuilder(service).create()
+	assert.True(t, errors.Is(err, errInvalidStorageMount))
+}
+
+func TestPullSecretBuilder(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentRegistryServer = "registry.example.com"
+	config.DeploymentRegistryUsername = "akash"
+	config.DeploymentRegistryPassword = "secret"
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+	b := newPullSecretBuilder(log.NewNopLogger(), mtypes.LeaseID{}, group)
+
+	obj, err := b.create()
+	require.NoError(t, err)
+	assert.Equal(t, "akash-registry", obj.Name)
+	assert.Equal(t, corev1.SecretTypeDockerConfigJson, obj.Type)
+	assert.Equal(t, "true", obj.Labels[akashManagedLabelName])
+
+	var cfg struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	require.NoError(t, json.Unmarshal(obj.Data[corev1.DockerConfigJsonKey], &cfg))
+	require.Contains(t, cfg.Auths, "registry.example.com")
+	auth := cfg.Auths["registry.example.com"]
+	assert.Equal(t, "akash", auth.Username)
+	assert.Equal(t, "secret", auth.Password)
+	decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+	require.NoError(t, err)
+	assert.Equal(t, "akash:secret", string(decoded))
+
+	deployment, err := testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "akash-registry"}},
+		deployment.Spec.Template.Spec.ImagePullSecrets)
+
+	config.DeploymentRegistryPassword = ""
+	_, err = b.create()
+	assert.True(t, errors.Is(err, errInvalidRegistryAuth))
+
+	// no credentials, no reference
+	config.DeploymentRegistryServer = ""
+	deployment, err = testDeploymentBuilder(service).create()
+	require.NoError(t, err)
+	assert.Empty(t, deployment.Spec.Template.Spec.ImagePullSecrets)
+}
+
+func TestStatefulSetBuilder(t *testing.T) {
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi, Storage: 10 * unit.Gi})
+	service.Count = 3
+	service.Stateful = true
+	service.StorageMount = "/var/lib/postgresql"
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+	b := newStatefulSetBuilder(log.NewNopLogger(), mtypes.LeaseID{}, group, service)
+
+	obj, err := b.create()
+	require.NoError(t, err)
+	assert.Equal(t, "web", obj.Name)
+	assert.Equal(t, int32(3), *obj.Spec.Replicas)
+	assert.Equal(t, "web-headless", obj.Spec.ServiceName)
+	assert.Equal(t, b.labels(), obj.Spec.Selector.MatchLabels)
+	assert.Equal(t, b.labels(), obj.Spec.Template.Labels)
+
+	// each replica mounts its own claim
+	spec := obj.Spec.Template.Spec
+	assert.Empty(t, spec.Volumes)
+	require.Len(t, spec.Containers, 1)
+	assert.Equal(t, []corev1.VolumeMount{{Name: "storage", MountPath: "/var/lib/postgresql"}}, spec.Containers[0].VolumeMounts)
+
+	require.Len(t, obj.Spec.VolumeClaimTemplates, 1)
+	claim := obj.Spec.VolumeClaimTemplates[0]
+	assert.Equal(t, "storage", claim.Name)
+	size := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, "10Gi", size.String())
+
+	service.Count = 5
+	obj, err = b.update(obj)
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), *obj.Spec.Replicas)
+
+	// no storage, no claims
+	service.StorageMount = ""
+	obj, err = b.create()
+	require.NoError(t, err)
+	assert.Empty(t, obj.Spec.VolumeClaimTemplates)
+	assert.Empty(t, obj.Spec.Template.Spec.Containers[0].VolumeMounts)
+
+	svc, err := newHeadlessServiceBuilder(log.NewNopLogger(), mtypes.LeaseID{}, group, service).create()
+	require.NoError(t, err)
+	assert.Equal(t, "web-headless", svc.Name)
+	assert.Equal(t, corev1.ClusterIPNone, svc.Spec.ClusterIP)
+	assert.Equal(t, b.labels(), svc.Spec.Selector)
+	require.Len(t, svc.Spec.Ports, 1)
+	assert.Equal(t, int32(80), svc.Spec.Ports[0].Port)
+}
+
+func TestIngressBuilder_hosts(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentIngressStaticHosts = true
+	config.DeploymentIngressDomain = "provider.test"
+
+	tests := []struct {
+		hosts    []string
+		expected []string
+		ok       bool
+	}{
+		{nil, nil, true},
+		{[]string{"example.com", "www.example.com"}, []string{"example.com", "www.example.com"}, true},
+		{[]string{"example.com", "EXAMPLE.com", "www.example.com"}, []string{"example.com", "www.example.com"}, true},
+		{[]string{"example.com", "not_valid.example.com"}, nil, false},
+	}
+
+	for _, test := range tests {
+		service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+		service.Expose[0].Hosts = test.hosts
+		group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+
+		b := newIngressBuilder(log.NewNopLogger(), "host", mtypes.LeaseID{}, group, service, &service.Expose[0])
+
+		obj, err := b.create()
+		if !test.ok {
+			assert.Error(t, err, "hosts %v", test.hosts)
+			continue
+		}
+		require.NoError(t, err)
+
+		var hosts []string
+		for _, rule := range obj.Spec.Rules {
+			hosts = append(hosts, rule.Host)
+		}
+
+		if test.expected == nil {
+			// generated host
+			require.Len(t, hosts, 1)
+			assert.True(t, strings.HasSuffix(hosts[0], ".provider.test"))
+			continue
+		}
+		assert.Equal(t, test.expected, hosts)
+	}
+}
+
+func testService(unit types.Unit) *manifest.Service {
+	return &manifest.Service{
+		Name:  "web",
+		Image: "nginx",
+		Unit:  unit,
+		Count: 1,
+		Expose: []manifest.ServiceExpose{
+			{Port: 80, Global: true},
+		},
+	}
+}
+
+func testDeploymentBuilder(service *manifest.Service) *deploymentBuilder {
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+	return newDeploymentBuilder(log.NewNopLogger(), mtypes.LeaseID{}, group, service)
+}
+
+func TestLidNS(t *testing.T) {
+	lid := mtypes.LeaseID{DSeq: 1, GSeq: 2, OSeq: 3}
+
+	ns := lidNS(lid)
+	assert.Len(t, ns, 40)
+	assert.Equal(t, ns, lidNS(lid))
+
+	other := lid
+	other.OSeq++
+	assert.NotEqual(t, ns, lidNS(other))
+}
@@ -1,6 +1,8 @@
 package kube
 
 import (
+	"context"
+
 	"github.com/ovrclk/akash/manifest"
 	mtypes "github.com/ovrclk/akash/x/market/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -9,7 +11,11 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func cleanupStaleResources(kc kubernetes.Interface, lid mtypes.LeaseID, group *manifest.Group) error {
+func cleanupStaleResources(ctx context.Context, kc kubernetes.Interface, lid mtypes.LeaseID, group *manifest.Group) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	ns := lidNS(lid)
 
 	// build label selector for objects not in current manifest group
@@ -35,8 +41,22 @@ func cleanupStaleResources(kc kubernetes.Interface, lid mtypes.LeaseID, group *m
 		return err
 	}
 
+	// delete stale stateful sets
+	if err := kc.AppsV1().StatefulSets(ns).DeleteCollection(&metav1.DeleteOptions{}, metav1.ListOptions{
+		LabelSelector: selector,
+	}); err != nil {
+		return err
+	}
+
 	// delete stale ingresses
-	if err := kc.ExtensionsV1beta1().Ingresses(ns).DeleteCollection(&metav1.DeleteOptions{}, metav1.ListOptions{
+	if err := deleteIngressCollection(kc, ns, metav1.ListOptions{
+		LabelSelector: selector,
+	}); err != nil {
+		return err
+	}
+
+	// delete stale disruption budgets
+	if err := deletePDBCollection(kc, ns, metav1.ListOptions{
 		LabelSelector: selector,
 	}); err != nil {
 		return err
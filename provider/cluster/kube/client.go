@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ovrclk/akash/manifest"
 	akashv1 "github.com/ovrclk/akash/pkg/apis/akash.network/v1"
 	manifestclient "github.com/ovrclk/akash/pkg/client/clientset/versioned"
@@ -18,6 +20,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiextcs "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -28,6 +31,11 @@ import (
 
 type Client interface {
 	cluster.Client
+	DrainNode(name string) ([]mtypes.LeaseID, []string, error)
+	CheckManifestCRD() error
+	NodeInventory() ([]NodeInventory, error)
+	OrphanedResources(provider sdk.AccAddress, active []mtypes.LeaseID) ([]OrphanedResource, error)
+	DeleteOrphanedResources(orphans []OrphanedResource) error
 }
 
 type client struct {
@@ -65,12 +73,25 @@ func NewClient(log log.Logger, host, ns string) (Client, error) {
 		return nil, fmt.Errorf("error creating metrics client: %v", err)
 	}
 
-	err = akashv1.CreateCRD(mcr)
-	if err != nil {
-		return nil, fmt.Errorf("error creating akashv1 CRD: %v", err)
+	// the CRD may have been installed by an administrator when the provider
+	// cannot create it itself.
+	if err := akashv1.CreateCRD(mcr); err != nil {
+		log.Error("creating akashv1 CRD", "err", err)
+	}
+
+	if err := checkManifestCRD(kc.Discovery()); err != nil {
+		return nil, err
+	}
+
+	if err := configureIngressAPI(log, kc.Discovery()); err != nil {
+		return nil, fmt.Errorf("error detecting ingress API: %v", err)
+	}
+
+	if err := configurePDBAPI(log, kc.Discovery()); err != nil {
+		return nil, fmt.Errorf("error detecting disruption budget API: %v", err)
 	}
 
-	err = prepareEnvironment(kc, ns)
+	err = prepareEnvironment(context.Background(), kc, ns)
 	if err != nil {
 		return nil, fmt.Errorf("error preparing environment %v", err)
 	}
@@ -103,15 +124,27 @@ func openKubeConfig(log log.Logger) (*rest.Config, error) {
 	return rest.InClusterConfig()
 }
 
-func (c *client) shouldExpose(expose *manifest.ServiceExpose) bool {
+// shouldExpose returns whether expose is served through an ingress.  Exposes
+// selecting a NodePort or LoadBalancer service are reachable without one.
+func shouldExpose(expose *manifest.ServiceExpose) bool {
+	switch corev1.ServiceType(expose.ServiceType) {
+	case corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+		return false
+	}
 	return expose.Global &&
 		(expose.ExternalPort == 80 ||
 			(expose.ExternalPort == 0 && expose.Port == 80))
 }
 
 func (c *client) Deployments() ([]cluster.Deployment, error) {
+	return leaseDeployments(c.mc, c.ns)
+}
 
-	manifests, err := c.mc.AkashV1().Manifests(c.ns).List(metav1.ListOptions{})
+// leaseDeployments returns the lease manifests in mns.  Manifests not named
+// after their lease's namespace were written to the legacy shared namespace
+// and are left for gc; the lease has since been redeployed under its own.
+func leaseDeployments(mc manifestclient.Interface, mns string) ([]cluster.Deployment, error) {
+	manifests, err := mc.AkashV1().Manifests(mns).List(metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -119,85 +152,41 @@ func (c *client) Deployments() ([]cluster.Deployment, error) {
 	var deployments []cluster.Deployment
 
 	for _, manifest := range manifests.Items {
+		if manifest.Name != lidNS(manifest.Spec.LeaseID.ToAkash()) {
+			continue
+		}
 		deployments = append(deployments, manifest)
 	}
 
 	return deployments, nil
 }
 
-func (c *client) Deploy(lid mtypes.LeaseID, group *manifest.Group) error {
-	if err := applyNS(c.kc, newNSBuilder(lid, group)); err != nil {
-		c.log.Error("applying namespace", "err", err, "lease", lid)
-		return err
-	}
-
-	if err := applyManifest(c.mc, newManifestBuilder(c.log, c.ns, lid, group)); err != nil {
-		c.log.Error("applying manifest", "err", err, "lease", lid)
-		return err
-	}
-
-	if err := cleanupStaleResources(c.kc, lid, group); err != nil {
-		c.log.Error("cleaning stale resources", "err", err, "lease", lid)
-		return err
-	}
-
-	for _, service := range group.Services {
-		if err := applyDeployment(c.kc, newDeploymentBuilder(c.log, lid, group, &service)); err != nil {
-			c.log.Error("applying deployment", "err", err, "lease", lid, "service", service.Name)
-			return err
-		}
-
-		if len(service.Expose) == 0 {
-			c.log.Debug("no services", "lease", lid, "service", service.Name)
-			continue
-		}
-
-		if err := applyService(c.kc, newServiceBuilder(c.log, lid, group, &service)); err != nil {
-			c.log.Error("applying service", "err", err, "lease", lid, "service", service.Name)
-			return err
-		}
-
-		for _, expose := range service.Expose {
-			if !c.shouldExpose(&expose) {
-				continue
-			}
-			if err := applyIngress(c.kc, newIngressBuilder(c.log, c.host, lid, group, &service, &expose)); err != nil {
-				c.log.Error("applying ingress", "err", err, "lease", lid, "service", service.Name, "expose", expose)
-				return err
-			}
-		}
-	}
+func (c *client) Deploy(ctx context.Context, lid mtypes.LeaseID, group *manifest.Group) error {
+	return applyLease(ctx, c.kc, c.mc, c.log, c.host, c.ns, lid, group)
+}
 
-	return nil
+func (c *client) DrainNode(name string) ([]mtypes.LeaseID, []string, error) {
+	return drainNode(c.kc, c.mc, c.ns, name)
 }
 
-func (c *client) TeardownLease(lid mtypes.LeaseID) error {
-	return c.kc.CoreV1().Namespaces().Delete(lidNS(lid), &metav1.DeleteOptions{})
+func (c *client) TeardownLease(ctx context.Context, lid mtypes.LeaseID) error {
+	return teardownLease(ctx, c.kc, c.mc, c.ns, lid)
 }
 
 func (c *client) ServiceLogs(ctx context.Context, lid mtypes.LeaseID,
-	tailLines int64, follow bool) ([]*cluster.ServiceLog, error) {
-	pods, err := c.kc.CoreV1().Pods(lidNS(lid)).List(metav1.ListOptions{})
+	tailLines int64, follow bool, since time.Duration) ([]*cluster.ServiceLog, error) {
+	streams, err := leaseLogs(ctx, c.kc, lid, tailLines, follow, since, openPodLogs)
 	if err != nil {
 		c.log.Error(err.Error())
 		return nil, errors.New("internal error")
 	}
-	streams := make([]*cluster.ServiceLog, len(pods.Items))
-	for i, pod := range pods.Items {
-		stream, err := c.kc.CoreV1().Pods(lidNS(lid)).GetLogs(pod.Name, &corev1.PodLogOptions{
-			Follow:     follow,
-			TailLines:  &tailLines,
-			Timestamps: true,
-		}).Context(ctx).Stream()
-		if err != nil {
-			c.log.Error(err.Error())
-			return nil, errors.New("internal error")
-		}
-		streams[i] = cluster.NewServiceLog(pod.Name, stream)
-	}
 	return streams, nil
 }
 
+func (c *client) CheckManifestCRD() error {
+	return checkManifestCRD(c.kc.Discovery())
+}
+
 // todo: limit number of results and do pagination / streaming
 func (c *client) LeaseStatus(lid mtypes.LeaseID) (*cluster.LeaseStatus, error) {
 	deployments, err := c.deploymentsForLease(lid)
@@ -205,10 +194,15 @@ func (c *client) LeaseStatus(lid mtypes.LeaseID) (*cluster.LeaseStatus, error) {
 		c.log.Error(err.Error())
 		return nil, err
 	}
-	if len(deployments) == 0 {
+	statefulSets, err := c.kc.AppsV1().StatefulSets(lidNS(lid)).List(metav1.ListOptions{})
+	if err != nil {
+		c.log.Error(err.Error())
+		return nil, errors.New("internal error")
+	}
+	if len(deployments) == 0 && len(statefulSets.Items) == 0 {
 		return nil, cluster.ErrNoDeployments
 	}
-	serviceStatus := make(map[string]*cluster.ServiceStatus, len(deployments))
+	serviceStatus := make(map[string]*cluster.ServiceStatus, len(deployments)+len(statefulSets.Items))
 	for _, deployment := range deployments {
 		status := &cluster.ServiceStatus{
 			Name:      deployment.Name,
@@ -217,15 +211,62 @@ func (c *client) LeaseStatus(lid mtypes.LeaseID) (*cluster.LeaseStatus, error) {
 		}
 		serviceStatus[deployment.Name] = status
 	}
-	ingress, err := c.kc.ExtensionsV1beta1().Ingresses(lidNS(lid)).List(metav1.ListOptions{})
+	for _, set := range statefulSets.Items {
+		serviceStatus[set.Name] = &cluster.ServiceStatus{
+			Name:      set.Name,
+			Available: set.Status.ReadyReplicas,
+			Total:     set.Status.Replicas,
+		}
+	}
+	pods, err := c.kc.CoreV1().Pods(lidNS(lid)).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", akashManagedLabelName),
+	})
+	if err != nil {
+		c.log.Error(err.Error())
+		return nil, errors.New("internal error")
+	}
+	for _, pod := range pods.Items {
+		service, ok := serviceStatus[pod.Labels[akashManifestServiceLabelName]]
+		if !ok {
+			continue
+		}
+		for _, cstatus := range pod.Status.ContainerStatuses {
+			service.Restarts += cstatus.RestartCount
+		}
+	}
+	services, err := c.kc.CoreV1().Services(lidNS(lid)).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", akashManagedLabelName),
+	})
+	if err != nil {
+		c.log.Error(err.Error())
+		return nil, errors.New("internal error")
+	}
+	forwarded := false
+	for _, svc := range services.Items {
+		service, ok := serviceStatus[svc.Name]
+		if !ok {
+			continue
+		}
+		for _, port := range svc.Spec.Ports {
+			if port.NodePort == 0 {
+				continue
+			}
+			service.NodePorts = append(service.NodePorts, cluster.ForwardedPort{
+				Port:     uint32(port.Port),
+				NodePort: uint32(port.NodePort),
+			})
+			forwarded = true
+		}
+	}
+	ingresses, err := listIngresses(c.kc, lidNS(lid), metav1.ListOptions{})
 	if err != nil {
 		c.log.Error(err.Error())
 		return nil, errors.New("internal error")
 	}
-	if ingress == nil || len(ingress.Items) == 0 {
+	if len(ingresses) == 0 && !forwarded {
 		return nil, errors.New("no ingress for lease")
 	}
-	for _, ing := range ingress.Items {
+	for _, ing := range ingresses {
 		service := serviceStatus[ing.Name]
 		hosts := []string{}
 
@@ -255,6 +296,9 @@ func (c *client) LeaseStatus(lid mtypes.LeaseID) (*cluster.LeaseStatus, error) {
 
 func (c *client) ServiceStatus(lid mtypes.LeaseID, name string) (*cluster.ServiceStatus, error) {
 	deployment, err := c.kc.AppsV1().Deployments(lidNS(lid)).Get(name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return c.statefulSetStatus(lid, name)
+	}
 	if err != nil {
 		c.log.Error(err.Error())
 		return nil, errors.New("internal error")
@@ -271,6 +315,21 @@ func (c *client) ServiceStatus(lid mtypes.LeaseID, name string) (*cluster.Servic
 	}, nil
 }
 
+func (c *client) statefulSetStatus(lid mtypes.LeaseID, name string) (*cluster.ServiceStatus, error) {
+	set, err := c.kc.AppsV1().StatefulSets(lidNS(lid)).Get(name, metav1.GetOptions{})
+	if err != nil {
+		c.log.Error(err.Error())
+		return nil, errors.New("internal error")
+	}
+	return &cluster.ServiceStatus{
+		ObservedGeneration: set.Status.ObservedGeneration,
+		Replicas:           set.Status.Replicas,
+		UpdatedReplicas:    set.Status.UpdatedReplicas,
+		ReadyReplicas:      set.Status.ReadyReplicas,
+		AvailableReplicas:  set.Status.ReadyReplicas,
+	}, nil
+}
+
 func (c *client) Inventory() ([]cluster.Node, error) {
 	var nodes []cluster.Node
 
@@ -338,13 +397,23 @@ func (c *client) activeNodes() (map[string]*corev1.Node, error) {
 		return nil, err
 	}
 
+	// lease pods can't be scheduled elsewhere when nodes are dedicated.
+	dkey, dvalue, err := dedicatedNodeLabel()
+	if err != nil {
+		return nil, err
+	}
+
 	retnodes := make(map[string]*corev1.Node)
 
-	for _, knode := range knodes.Items {
-		if !c.nodeIsActive(&knode) {
+	for i := range knodes.Items {
+		knode := &knodes.Items[i]
+		if !c.nodeIsActive(knode) {
+			continue
+		}
+		if val, ok := knode.Labels[dkey]; dkey != "" && (!ok || val != dvalue) {
 			continue
 		}
-		retnodes[knode.Name] = &knode
+		retnodes[knode.Name] = knode
 	}
 	return retnodes, nil
 }
@@ -5,9 +5,16 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ovrclk/akash/provider/cluster"
 	"github.com/ovrclk/akash/types"
+	mtypes "github.com/ovrclk/akash/x/market/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tendermint/tendermint/libs/log"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
 )
 
 func kubeClient(t *testing.T) Client {
@@ -24,3 +31,27 @@ func leaseID(t *testing.T) types.LeaseID {
 		Provider:   []byte(t.Name()),
 	}
 }
+
+func TestLeaseStatus_nodePorts(t *testing.T) {
+	lid := mtypes.LeaseID{DSeq: 1, GSeq: 1, OSeq: 1}
+	ns := lidNS(lid)
+	labels := map[string]string{akashManagedLabelName: "true"}
+
+	kc := kfake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns, Labels: labels}},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns, Labels: labels},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeNodePort,
+				Ports: []corev1.ServicePort{{Name: "80", Port: 80, NodePort: 31080}},
+			},
+		},
+	)
+	c := &client{kc: kc, log: log.NewNopLogger()}
+
+	// reachable through the node port alone, without an ingress
+	status, err := c.LeaseStatus(lid)
+	require.NoError(t, err)
+	require.Len(t, status.Services, 1)
+	assert.Equal(t, []cluster.ForwardedPort{{Port: 80, NodePort: 31080}}, status.Services[0].NodePorts)
+}
@@ -1,6 +1,8 @@
 package kube
 
 import (
+	"time"
+
 	"github.com/caarlos0/env"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -17,6 +19,65 @@ type config_ struct {
 	DeploymentIngressDomain string `env:"AKASH_DEPLOYMENT_INGRESS_DOMAIN"`
 
 	DeploymentIngressExposeLBHosts bool `env:"AKASH_DEPLOYMENT_INGRESS_EXPOSE_LB_HOSTS" envDefault:"true"`
+
+	// Ingress API version: "networking.k8s.io/v1" or "extensions/v1beta1".
+	// Empty selects networking.k8s.io/v1 if the cluster serves it.
+	DeploymentIngressAPI string `env:"AKASH_DEPLOYMENT_INGRESS_API"`
+
+	// PodDisruptionBudget API version: "policy/v1" or "policy/v1beta1".
+	// Empty selects policy/v1 if the cluster serves it.
+	DeploymentPDBAPI string `env:"AKASH_DEPLOYMENT_PDB_API"`
+
+	// cert-manager ClusterIssuer used to provision TLS for HTTPS exposes.  Empty disables HTTPS.
+	DeploymentIngressClusterIssuer string `env:"AKASH_DEPLOYMENT_INGRESS_CLUSTER_ISSUER"`
+
+	// Ephemeral storage given to containers that don't declare storage.
+	DeploymentEphemeralStorageDefault int64 `env:"AKASH_DEPLOYMENT_EPHEMERAL_STORAGE_DEFAULT" envDefault:"536870912"` // 512Mi
+	// Maximum ephemeral storage a single container may declare.  0 disables the cap.
+	DeploymentEphemeralStorageMax int64 `env:"AKASH_DEPLOYMENT_EPHEMERAL_STORAGE_MAX" envDefault:"0"`
+
+	// Credentials for the private registry lease images are pulled from.
+	// They are stored in a secret in each lease namespace.  An empty server
+	// pulls anonymously.
+	DeploymentRegistryServer   string `env:"AKASH_DEPLOYMENT_REGISTRY_SERVER"`
+	DeploymentRegistryUsername string `env:"AKASH_DEPLOYMENT_REGISTRY_USERNAME"`
+	DeploymentRegistryPassword string `env:"AKASH_DEPLOYMENT_REGISTRY_PASSWORD"`
+
+	// StorageClass of the persistent volume claims of services mounting
+	// their storage.  Empty uses the cluster's default class.
+	DeploymentStorageClass string `env:"AKASH_DEPLOYMENT_STORAGE_CLASS"`
+
+	// Pod annotations tenants may set from their manifest.  Entries ending in
+	// "/" allow every annotation with that prefix.  Empty allows none.
+	DeploymentPodAnnotationsAllowed []string `env:"AKASH_DEPLOYMENT_POD_ANNOTATIONS_ALLOWED" envSeparator:","`
+
+	// "key=value" label of the nodes dedicated to leases.  When set, lease
+	// pods select only nodes with this label and tolerate a NoSchedule taint
+	// of the same key and value, so the nodes can be tainted to keep other
+	// workloads off them.
+	DeploymentDedicatedNodeLabel string `env:"AKASH_DEPLOYMENT_DEDICATED_NODE_LABEL"`
+
+	// Egress bandwidth limit for each lease pod (e.g. "10M").  Empty is
+	// unlimited.  Only applied when the cluster network enforces the
+	// kubernetes.io/egress-bandwidth annotation (the CNI bandwidth plugin),
+	// which must be declared with DeploymentCNIBandwidth.
+	DeploymentEgressBandwidth string `env:"AKASH_DEPLOYMENT_EGRESS_BANDWIDTH"`
+	DeploymentCNIBandwidth    bool   `env:"AKASH_DEPLOYMENT_CNI_BANDWIDTH" envDefault:"false"`
+
+	// Update existing manifests with a merge patch rather than replacing them,
+	// preserving fields set by other controllers.
+	ManifestPatchUpdates bool `env:"AKASH_MANIFEST_PATCH_UPDATES" envDefault:"false"`
+
+	// Apply lease resources with server-side apply, creating or updating each
+	// in one request, on API servers that support it.  Namespaces are always
+	// applied with a Get then Update or Create.
+	ServerSideApply bool `env:"AKASH_KUBE_SERVER_SIDE_APPLY" envDefault:"false"`
+
+	// Attempts made to apply a resource when the API server reports a
+	// conflict or times out, and the wait before the first retry.  The wait
+	// doubles on each further retry.
+	ApplyRetryAttempts int           `env:"AKASH_KUBE_APPLY_RETRY_ATTEMPTS" envDefault:"5"`
+	ApplyRetryBackoff  time.Duration `env:"AKASH_KUBE_APPLY_RETRY_BACKOFF" envDefault:"100ms"`
 }
 
 var config = config_{}
@@ -0,0 +1,34 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+
+	akashv1 "github.com/ovrclk/akash/pkg/apis/akash.network/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+)
+
+var errManifestCRDMissing = errors.New("manifest CRD not installed")
+
+// checkManifestCRD verifies that the cluster serves the Manifest resource.
+func checkManifestCRD(dc discovery.DiscoveryInterface) error {
+	gv := akashv1.SchemeGroupVersion.String()
+
+	resources, err := dc.ServerResourcesForGroupVersion(gv)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+
+	if resources != nil {
+		for _, res := range resources.APIResources {
+			if res.Name == akashv1.CRDPlural {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: %v (%v) is not served by the cluster; "+
+		"grant the provider permission to create customresourcedefinitions "+
+		"or have a cluster administrator install it", errManifestCRDMissing, akashv1.FullCRDName, gv)
+}
@@ -0,0 +1,34 @@
+package kube
+
+import (
+	"errors"
+	"testing"
+
+	akashv1 "github.com/ovrclk/akash/pkg/apis/akash.network/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckManifestCRD(t *testing.T) {
+	kc := kfake.NewSimpleClientset()
+	dc := kc.Discovery().(*fakediscovery.FakeDiscovery)
+
+	dc.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "pods"}},
+		},
+	}
+
+	err := checkManifestCRD(dc)
+	assert.True(t, errors.Is(err, errManifestCRDMissing))
+
+	dc.Resources = append(dc.Resources, &metav1.APIResourceList{
+		GroupVersion: akashv1.SchemeGroupVersion.String(),
+		APIResources: []metav1.APIResource{{Name: akashv1.CRDPlural}},
+	})
+
+	assert.NoError(t, checkManifestCRD(dc))
+}
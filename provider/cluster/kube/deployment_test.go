@@ -1,6 +1,7 @@
 package kube
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -25,6 +26,6 @@ func TestDeploy(t *testing.T) {
 	client, err := NewClient(log, "host", "lease")
 	assert.NoError(t, err)
 
-	err = client.Deploy(lease.LeaseID, mani.Groups[0])
+	err = client.Deploy(context.Background(), lease.LeaseID, mani.Groups[0])
 	assert.NoError(t, err)
 }
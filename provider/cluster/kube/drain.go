@@ -0,0 +1,90 @@
+package kube
+
+import (
+	"fmt"
+	"time"
+
+	akashv1 "github.com/ovrclk/akash/pkg/client/clientset/versioned"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// drainNode marks a node unschedulable and evicts the lease pods running on
+// it.  Evictions go through the eviction API so that disruption budgets are
+// honored.  The leases owning the evicted pods are returned, along with the
+// pods, as namespace/name, that a disruption budget kept from being evicted.
+func drainNode(kc kubernetes.Interface, mc akashv1.Interface, mns, name string) ([]mtypes.LeaseID, []string, error) {
+	node, err := kc.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !node.Spec.Unschedulable {
+		node.Spec.Unschedulable = true
+		if _, err := kc.CoreV1().Nodes().Update(node); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	pods, err := kc.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", akashManagedLabelName),
+		FieldSelector: fmt.Sprintf("spec.nodeName=%s", name),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		leases  []mtypes.LeaseID
+		blocked []string
+	)
+	seen := make(map[string]bool)
+
+	for _, pod := range pods.Items {
+		err := evictPod(kc, pod.Namespace, pod.Name)
+		switch {
+		case errors.IsTooManyRequests(err):
+			blocked = append(blocked, pod.Namespace+"/"+pod.Name)
+			continue
+		case err != nil:
+			return leases, blocked, err
+		}
+
+		if seen[pod.Namespace] {
+			continue
+		}
+		seen[pod.Namespace] = true
+
+		// lease namespaces are named after their manifest.
+		mani, err := mc.AkashV1().Manifests(mns).Get(pod.Namespace, metav1.GetOptions{})
+		if err != nil {
+			return leases, blocked, err
+		}
+		leases = append(leases, mani.Spec.LeaseID.ToAkash())
+	}
+
+	return leases, blocked, nil
+}
+
+// evictPod evicts the pod, retrying while the API server refuses with 429
+// Too Many Requests, as it does while a disruption budget allows no more
+// disruptions.  Attempts and backoff follow the apply retry settings.
+func evictPod(kc kubernetes.Interface, ns, name string) error {
+	backoff := config.ApplyRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := kc.CoreV1().Pods(ns).Evict(&policyv1beta1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+		})
+		if err == nil || !errors.IsTooManyRequests(err) || attempt >= config.ApplyRetryAttempts {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
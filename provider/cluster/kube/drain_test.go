@@ -0,0 +1,126 @@
+package kube
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ovrclk/akash/manifest"
+	akashv1 "github.com/ovrclk/akash/pkg/apis/akash.network/v1"
+	afake "github.com/ovrclk/akash/pkg/client/clientset/versioned/fake"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestDrainNode(t *testing.T) {
+	const mns = "lease"
+
+	lid := mtypes.LeaseID{}
+	ns := lidNS(lid)
+
+	mani, err := akashv1.NewManifest(ns, lid, &manifest.Group{Name: "test"})
+	require.NoError(t, err)
+	mani.Namespace = mns
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+
+	leasePod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels:    map[string]string{akashManagedLabelName: "true"},
+			},
+			Spec: corev1.PodSpec{NodeName: node.Name},
+		}
+	}
+
+	system := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy", Namespace: "kube-system"},
+		Spec:       corev1.PodSpec{NodeName: node.Name},
+	}
+
+	kc := kfake.NewSimpleClientset(node, leasePod("web-1"), leasePod("web-2"), system)
+	mc := afake.NewSimpleClientset(mani)
+
+	leases, blocked, err := drainNode(kc, mc, mns, node.Name)
+	require.NoError(t, err)
+	assert.Empty(t, blocked)
+	require.Len(t, leases, 1)
+	assert.True(t, lid.Equals(leases[0]))
+
+	obj, err := kc.CoreV1().Nodes().Get(node.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, obj.Spec.Unschedulable)
+
+	var evicted []string
+	for _, action := range kc.Actions() {
+		if action.GetSubresource() != "eviction" {
+			continue
+		}
+		create, ok := action.(ktesting.CreateAction)
+		require.True(t, ok)
+		evicted = append(evicted, create.GetObject().(metav1.Object).GetName())
+	}
+	assert.ElementsMatch(t, []string{"web-1", "web-2"}, evicted)
+}
+
+func TestDrainNode_disruptionBudget(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.ApplyRetryAttempts = 3
+	config.ApplyRetryBackoff = time.Millisecond
+
+	const mns = "lease"
+
+	lid := mtypes.LeaseID{}
+	ns := lidNS(lid)
+
+	mani, err := akashv1.NewManifest(ns, lid, &manifest.Group{Name: "test"})
+	require.NoError(t, err)
+	mani.Namespace = mns
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+
+	var pods []runtime.Object
+	for _, name := range []string{"web-1", "web-2", "web-3"} {
+		pods = append(pods, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels:    map[string]string{akashManagedLabelName: "true"},
+			},
+			Spec: corev1.PodSpec{NodeName: node.Name},
+		})
+	}
+
+	kc := kfake.NewSimpleClientset(append(pods, node)...)
+	mc := afake.NewSimpleClientset(mani)
+
+	// the budget blocks web-2 throughout and web-3 once
+	attempts := make(map[string]int)
+	kc.PrependReactor("create", "pods", func(action ktesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		name := action.(ktesting.CreateAction).GetObject().(metav1.Object).GetName()
+		attempts[name]++
+		if name == "web-2" || (name == "web-3" && attempts[name] == 1) {
+			return true, nil, errors.NewTooManyRequests("disruption budget", 0)
+		}
+		return false, nil, nil
+	})
+
+	leases, blocked, err := drainNode(kc, mc, mns, node.Name)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ns + "/web-2"}, blocked)
+	require.Len(t, leases, 1)
+	assert.True(t, lid.Equals(leases[0]))
+
+	assert.Equal(t, map[string]int{"web-1": 1, "web-2": 3, "web-3": 2}, attempts)
+}
@@ -0,0 +1,127 @@
+package kube
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	akashv1 "github.com/ovrclk/akash/pkg/client/clientset/versioned"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	orphanKindNamespace = "namespace"
+	orphanKindManifest  = "manifest"
+)
+
+// OrphanedResource is a lease resource left in the cluster without an active
+// lease, typically by a crash or a missed teardown.
+type OrphanedResource struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func (r OrphanedResource) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// OrphanedResources returns the lease resources in the cluster that belong to
+// none of the provider's active leases.
+func (c *client) OrphanedResources(provider sdk.AccAddress, active []mtypes.LeaseID) ([]OrphanedResource, error) {
+	return orphanedResources(c.kc, c.mc, c.ns, provider, active)
+}
+
+// DeleteOrphanedResources deletes resources returned by OrphanedResources.
+func (c *client) DeleteOrphanedResources(orphans []OrphanedResource) error {
+	return deleteOrphanedResources(c.kc, c.mc, orphans)
+}
+
+// orphanedResources returns the lease namespaces and the manifests in mns,
+// found by the akash.network label, that belong to none of the provider's
+// active leases.  Leases of other providers are ignored.  An empty provider
+// is refused, as every lease resource would be reported.
+//
+// Leases were once all deployed to one shared namespace, a hash of the empty
+// string, with manifests of the same name.  Active leases are redeployed to
+// their own namespace on the next reconcile, after which the shared
+// namespace and any manifest not named after its lease's namespace are
+// reported here.
+func orphanedResources(kc kubernetes.Interface, mc akashv1.Interface, mns string,
+	provider sdk.AccAddress, leases []mtypes.LeaseID) ([]OrphanedResource, error) {
+	if provider.Empty() {
+		return nil, mtypes.ErrEmptyProvider
+	}
+
+	selector := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", akashManagedLabelName),
+	}
+
+	var active []mtypes.LeaseID
+	live := make(map[string]bool, len(leases))
+	for _, lid := range leases {
+		if lid.Provider.Equals(provider) {
+			active = append(active, lid)
+			live[lidNS(lid)] = true
+		}
+	}
+
+	var orphans []OrphanedResource
+
+	namespaces, err := kc.CoreV1().Namespaces().List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range namespaces.Items {
+		if !live[ns.Name] {
+			orphans = append(orphans, OrphanedResource{Kind: orphanKindNamespace, Name: ns.Name})
+		}
+	}
+
+	manifests, err := mc.AkashV1().Manifests(mns).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, mani := range manifests.Items {
+		lid := mani.Spec.LeaseID.ToAkash()
+		if mani.Name != lidNS(lid) || !leaseActive(active, lid) {
+			orphans = append(orphans, OrphanedResource{Kind: orphanKindManifest, Namespace: mns, Name: mani.Name})
+		}
+	}
+
+	return orphans, nil
+}
+
+func leaseActive(active []mtypes.LeaseID, lid mtypes.LeaseID) bool {
+	for _, id := range active {
+		if id.Equals(lid) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteOrphanedResources deletes resources found by orphanedResources.
+// Resources already gone are skipped.
+func deleteOrphanedResources(kc kubernetes.Interface, mc akashv1.Interface, orphans []OrphanedResource) error {
+	for _, orphan := range orphans {
+		var err error
+		switch orphan.Kind {
+		case orphanKindNamespace:
+			err = kc.CoreV1().Namespaces().Delete(orphan.Name, &metav1.DeleteOptions{})
+		case orphanKindManifest:
+			err = mc.AkashV1().Manifests(orphan.Namespace).Delete(orphan.Name, &metav1.DeleteOptions{})
+		default:
+			err = fmt.Errorf("unknown resource kind %q", orphan.Kind)
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package kube
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ovrclk/akash/manifest"
+	akashv1 "github.com/ovrclk/akash/pkg/apis/akash.network/v1"
+	afake "github.com/ovrclk/akash/pkg/client/clientset/versioned/fake"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestOrphanedResources(t *testing.T) {
+	const mns = "lease"
+
+	provider := sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+	live := mtypes.LeaseID{Owner: sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address()), DSeq: 1, GSeq: 1, OSeq: 1, Provider: provider}
+	dead := mtypes.LeaseID{Owner: live.Owner, DSeq: 2, GSeq: 1, OSeq: 1, Provider: provider}
+
+	leaseNS := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{akashManagedLabelName: "true"},
+			},
+		}
+	}
+
+	leaseManifest := func(name string, lid mtypes.LeaseID) *akashv1.Manifest {
+		mani, err := akashv1.NewManifest(name, lid, &manifest.Group{Name: "test"})
+		require.NoError(t, err)
+		mani.Namespace = mns
+		mani.Labels = map[string]string{akashManagedLabelName: "true"}
+		return mani
+	}
+
+	system := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}
+
+	kc := kfake.NewSimpleClientset(leaseNS(lidNS(live)), leaseNS("stale"), system)
+	mc := afake.NewSimpleClientset(leaseManifest(lidNS(live), live), leaseManifest("dead", dead))
+
+	orphans, err := orphanedResources(kc, mc, mns, provider, []mtypes.LeaseID{live})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []OrphanedResource{
+		{Kind: orphanKindNamespace, Name: "stale"},
+		{Kind: orphanKindManifest, Namespace: mns, Name: "dead"},
+	}, orphans)
+
+	require.NoError(t, deleteOrphanedResources(kc, mc, orphans))
+
+	_, err = kc.CoreV1().Namespaces().Get("stale", metav1.GetOptions{})
+	assert.Error(t, err)
+	_, err = mc.AkashV1().Manifests(mns).Get("dead", metav1.GetOptions{})
+	assert.Error(t, err)
+
+	// live resources and unmanaged namespaces are untouched
+	_, err = kc.CoreV1().Namespaces().Get(lidNS(live), metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = kc.CoreV1().Namespaces().Get(system.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = mc.AkashV1().Manifests(mns).Get(lidNS(live), metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	orphans, err = orphanedResources(kc, mc, mns, provider, []mtypes.LeaseID{live})
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+
+	// already deleted resources are skipped
+	assert.NoError(t, deleteOrphanedResources(kc, mc, []OrphanedResource{
+		{Kind: orphanKindNamespace, Name: "stale"},
+	}))
+}
+
+func TestOrphanedResources_emptyProvider(t *testing.T) {
+	const mns = "lease"
+
+	lid := mtypes.LeaseID{
+		Owner:    sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address()),
+		DSeq:     1,
+		GSeq:     1,
+		OSeq:     1,
+		Provider: sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address()),
+	}
+
+	kc := kfake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   lidNS(lid),
+			Labels: map[string]string{akashManagedLabelName: "true"},
+		},
+	})
+	mani, err := akashv1.NewManifest(lidNS(lid), lid, &manifest.Group{Name: "test"})
+	require.NoError(t, err)
+	mani.Namespace = mns
+	mani.Labels = map[string]string{akashManagedLabelName: "true"}
+	mc := afake.NewSimpleClientset(mani)
+
+	orphans, err := orphanedResources(kc, mc, mns, nil, []mtypes.LeaseID{lid})
+	assert.Equal(t, mtypes.ErrEmptyProvider, err)
+	assert.Empty(t, orphans)
+
+	require.NoError(t, deleteOrphanedResources(kc, mc, orphans))
+
+	_, err = kc.CoreV1().Namespaces().Get(lidNS(lid), metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = mc.AkashV1().Manifests(mns).Get(lidNS(lid), metav1.GetOptions{})
+	assert.NoError(t, err)
+}
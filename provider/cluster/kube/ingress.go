@@ -0,0 +1,237 @@
+package kube
+
+import (
+	"encoding/json"
+
+	"github.com/tendermint/tendermint/libs/log"
+	extv1 "k8s.io/api/extensions/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// Ingress API versions.  extensions/v1beta1 is removed from Kubernetes 1.22.
+const (
+	ingressAPIExtensionsV1beta1 = "extensions/v1beta1"
+	ingressAPINetworkingV1      = "networking.k8s.io/v1"
+)
+
+var netv1IngressKind = schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}
+
+// detectIngressAPI returns the networking.k8s.io/v1 ingress API if the
+// cluster serves it, and extensions/v1beta1 otherwise.
+func detectIngressAPI(dc discovery.DiscoveryInterface) (string, error) {
+	resources, err := dc.ServerResourcesForGroupVersion(ingressAPINetworkingV1)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return "", err
+	}
+
+	if resources != nil {
+		for _, res := range resources.APIResources {
+			if res.Name == "ingresses" {
+				return ingressAPINetworkingV1, nil
+			}
+		}
+	}
+
+	return ingressAPIExtensionsV1beta1, nil
+}
+
+// configureIngressAPI selects the ingress API the cluster serves, unless
+// one is configured.
+func configureIngressAPI(log log.Logger, dc discovery.DiscoveryInterface) error {
+	if config.DeploymentIngressAPI == "" {
+		api, err := detectIngressAPI(dc)
+		if err != nil {
+			return err
+		}
+		config.DeploymentIngressAPI = api
+	}
+	log.Info("using ingress API", "version", config.DeploymentIngressAPI)
+	return nil
+}
+
+func ingressAPIV1() bool {
+	return config.DeploymentIngressAPI == ingressAPINetworkingV1
+}
+
+// listIngresses returns the ingresses in ns matching opts, in the
+// extensions/v1beta1 schema whichever API serves them.
+func listIngresses(kc kubernetes.Interface, ns string, opts metav1.ListOptions) ([]extv1.Ingress, error) {
+	if !ingressAPIV1() {
+		list, err := kc.ExtensionsV1beta1().Ingresses(ns).List(opts)
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+
+	list, err := netv1Ingresses(kc, ns).List(opts)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]extv1.Ingress, 0, len(list.Items))
+	for _, obj := range list.Items {
+		items = append(items, obj.toExtensions())
+	}
+	return items, nil
+}
+
+func deleteIngressCollection(kc kubernetes.Interface, ns string, opts metav1.ListOptions) error {
+	if ingressAPIV1() {
+		return netv1Ingresses(kc, ns).DeleteCollection(opts)
+	}
+	return kc.ExtensionsV1beta1().Ingresses(ns).DeleteCollection(&metav1.DeleteOptions{}, opts)
+}
+
+// netv1Ingress is a networking.k8s.io/v1 Ingress.  The vendored k8s.io/api
+// predates the type, so only the fields the provider sets or reads are
+// declared.  TLS and status have the same schema as in extensions/v1beta1.
+type netv1Ingress struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   netv1IngressSpec    `json:"spec,omitempty"`
+	Status extv1.IngressStatus `json:"status,omitempty"`
+}
+
+type netv1IngressList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []netv1Ingress `json:"items"`
+}
+
+type netv1IngressSpec struct {
+	TLS   []extv1.IngressTLS `json:"tls,omitempty"`
+	Rules []netv1IngressRule `json:"rules,omitempty"`
+}
+
+type netv1IngressRule struct {
+	Host string                     `json:"host,omitempty"`
+	HTTP *netv1HTTPIngressRuleValue `json:"http,omitempty"`
+}
+
+type netv1HTTPIngressRuleValue struct {
+	Paths []netv1HTTPIngressPath `json:"paths"`
+}
+
+type netv1HTTPIngressPath struct {
+	Path     string              `json:"path,omitempty"`
+	PathType string              `json:"pathType"`
+	Backend  netv1IngressBackend `json:"backend"`
+}
+
+type netv1IngressBackend struct {
+	Service *netv1IngressServiceBackend `json:"service,omitempty"`
+}
+
+type netv1IngressServiceBackend struct {
+	Name string                  `json:"name"`
+	Port netv1ServiceBackendPort `json:"port"`
+}
+
+type netv1ServiceBackendPort struct {
+	Name   string `json:"name,omitempty"`
+	Number int32  `json:"number,omitempty"`
+}
+
+func (obj *netv1Ingress) toExtensions() extv1.Ingress {
+	ing := extv1.Ingress{
+		ObjectMeta: obj.ObjectMeta,
+		Spec: extv1.IngressSpec{
+			TLS: obj.Spec.TLS,
+		},
+		Status: obj.Status,
+	}
+	for _, rule := range obj.Spec.Rules {
+		erule := extv1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			erule.HTTP = &extv1.HTTPIngressRuleValue{}
+			for _, path := range rule.HTTP.Paths {
+				epath := extv1.HTTPIngressPath{Path: path.Path}
+				if svc := path.Backend.Service; svc != nil {
+					epath.Backend.ServiceName = svc.Name
+					if svc.Port.Name != "" {
+						epath.Backend.ServicePort = intstr.FromString(svc.Port.Name)
+					} else {
+						epath.Backend.ServicePort = intstr.FromInt(int(svc.Port.Number))
+					}
+				}
+				erule.HTTP.Paths = append(erule.HTTP.Paths, epath)
+			}
+		}
+		ing.Spec.Rules = append(ing.Spec.Rules, erule)
+	}
+	return ing
+}
+
+// netv1IngressClient reads and writes networking.k8s.io/v1 ingresses
+// through the REST client of the group, decoding responses as JSON.
+type netv1IngressClient struct {
+	rc rest.Interface
+	ns string
+}
+
+func netv1Ingresses(kc kubernetes.Interface, ns string) *netv1IngressClient {
+	return &netv1IngressClient{rc: kc.NetworkingV1().RESTClient(), ns: ns}
+}
+
+func (c *netv1IngressClient) Get(name string) (*netv1Ingress, error) {
+	obj := &netv1Ingress{}
+	err := c.do(c.rc.Get().Namespace(c.ns).Resource("ingresses").Name(name), obj)
+	return obj, err
+}
+
+func (c *netv1IngressClient) List(opts metav1.ListOptions) (*netv1IngressList, error) {
+	list := &netv1IngressList{}
+	err := c.do(c.rc.Get().Namespace(c.ns).Resource("ingresses").
+		VersionedParams(&opts, scheme.ParameterCodec), list)
+	return list, err
+}
+
+func (c *netv1IngressClient) Create(obj *netv1Ingress) (*netv1Ingress, error) {
+	obj.SetGroupVersionKind(netv1IngressKind)
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	result := &netv1Ingress{}
+	err = c.do(c.rc.Post().Namespace(c.ns).Resource("ingresses").Body(data), result)
+	return result, err
+}
+
+func (c *netv1IngressClient) Update(obj *netv1Ingress) (*netv1Ingress, error) {
+	obj.SetGroupVersionKind(netv1IngressKind)
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	result := &netv1Ingress{}
+	err = c.do(c.rc.Put().Namespace(c.ns).Resource("ingresses").Name(obj.Name).Body(data), result)
+	return result, err
+}
+
+func (c *netv1IngressClient) Delete(name string) error {
+	return c.rc.Delete().Namespace(c.ns).Resource("ingresses").Name(name).Do().Error()
+}
+
+func (c *netv1IngressClient) DeleteCollection(opts metav1.ListOptions) error {
+	return c.rc.Delete().Namespace(c.ns).Resource("ingresses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Error()
+}
+
+func (c *netv1IngressClient) do(req *rest.Request, into interface{}) error {
+	data, err := req.Do().Raw()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, into)
+}
@@ -0,0 +1,86 @@
+package kube
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDetectIngressAPI(t *testing.T) {
+	kc := kfake.NewSimpleClientset()
+	dc := kc.Discovery().(*fakediscovery.FakeDiscovery)
+
+	dc.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: ingressAPIExtensionsV1beta1,
+			APIResources: []metav1.APIResource{{Name: "ingresses"}},
+		},
+		{
+			GroupVersion: ingressAPINetworkingV1,
+			APIResources: []metav1.APIResource{{Name: "networkpolicies"}},
+		},
+	}
+
+	api, err := detectIngressAPI(dc)
+	require.NoError(t, err)
+	assert.Equal(t, ingressAPIExtensionsV1beta1, api)
+
+	dc.Resources[1].APIResources = append(dc.Resources[1].APIResources, metav1.APIResource{Name: "ingresses"})
+
+	api, err = detectIngressAPI(dc)
+	require.NoError(t, err)
+	assert.Equal(t, ingressAPINetworkingV1, api)
+}
+
+func TestNetv1Ingress_toExtensions(t *testing.T) {
+	// as served by the API server
+	data := []byte(`{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind": "Ingress",
+		"metadata": {"name": "web", "namespace": "lease"},
+		"spec": {
+			"tls": [{"hosts": ["example.com"], "secretName": "web-tls"}],
+			"rules": [{
+				"host": "example.com",
+				"http": {"paths": [{
+					"path": "/",
+					"pathType": "Prefix",
+					"backend": {"service": {"name": "web", "port": {"number": 80}}}
+				}]}
+			}]
+		},
+		"status": {"loadBalancer": {"ingress": [{"ip": "10.0.0.1"}]}}
+	}`)
+
+	var obj netv1Ingress
+	require.NoError(t, json.Unmarshal(data, &obj))
+
+	assert.Equal(t, extv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "lease"},
+		Spec: extv1.IngressSpec{
+			TLS: []extv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "web-tls"}},
+			Rules: []extv1.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: extv1.IngressRuleValue{HTTP: &extv1.HTTPIngressRuleValue{
+					Paths: []extv1.HTTPIngressPath{{
+						Path:    "/",
+						Backend: extv1.IngressBackend{ServiceName: "web", ServicePort: intstr.FromInt(80)},
+					}},
+				}},
+			}},
+		},
+		Status: extv1.IngressStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}},
+			},
+		},
+	}, obj.toExtensions())
+}
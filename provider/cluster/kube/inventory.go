@@ -0,0 +1,170 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ovrclk/akash/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeInventory describes the resources of a single cluster node.
+// Committed is the sum of the limits of the lease pods scheduled on it.
+type NodeInventory struct {
+	Name        string     `json:"name"`
+	Capacity    types.Unit `json:"capacity"`
+	Allocatable types.Unit `json:"allocatable"`
+	Committed   types.Unit `json:"committed"`
+}
+
+// Available returns the allocatable resources not committed to leases.
+func (n NodeInventory) Available() types.Unit {
+	return types.Unit{
+		CPU:     n.Allocatable.CPU - minUint32(n.Allocatable.CPU, n.Committed.CPU),
+		Memory:  n.Allocatable.Memory - minUint64(n.Allocatable.Memory, n.Committed.Memory),
+		Storage: n.Allocatable.Storage - minUint64(n.Allocatable.Storage, n.Committed.Storage),
+	}
+}
+
+// TotalInventory sums the resources of the given nodes.
+func TotalInventory(nodes []NodeInventory) NodeInventory {
+	total := NodeInventory{Name: "total"}
+	for _, node := range nodes {
+		addUnit(&total.Capacity, node.Capacity)
+		addUnit(&total.Allocatable, node.Allocatable)
+		addUnit(&total.Committed, node.Committed)
+	}
+	return total
+}
+
+// FitResources reports whether every instance of the resources can be
+// scheduled on the available capacity of the nodes, placing each instance on
+// the first node with room.  When they can't, the resource ("cpu", "memory"
+// or "storage") that limited placement is returned.
+func FitResources(nodes []NodeInventory, resources []types.Resource) (bool, string) {
+	avail := make([]types.Unit, 0, len(nodes))
+	for _, node := range nodes {
+		avail = append(avail, node.Available())
+	}
+
+	for _, res := range resources {
+		for count := uint32(0); count < res.Count; count++ {
+			placed := false
+			for i := range avail {
+				if unitFits(res.Unit, avail[i]) {
+					subUnit(&avail[i], res.Unit)
+					placed = true
+					break
+				}
+			}
+			if !placed {
+				return false, limitingResource(res.Unit, avail)
+			}
+		}
+	}
+	return true, ""
+}
+
+// limitingResource names the resource that the most nodes lack for unit.
+func limitingResource(unit types.Unit, avail []types.Unit) string {
+	var cpu, memory, storage int
+	for _, a := range avail {
+		if a.CPU < unit.CPU {
+			cpu++
+		}
+		if a.Memory < unit.Memory {
+			memory++
+		}
+		if a.Storage < unit.Storage {
+			storage++
+		}
+	}
+	switch {
+	case cpu >= memory && cpu >= storage:
+		return "cpu"
+	case memory >= storage:
+		return "memory"
+	default:
+		return "storage"
+	}
+}
+
+func (c *client) NodeInventory() ([]NodeInventory, error) {
+	knodes, err := c.activeNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := c.kc.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", akashManagedLabelName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	committed := make(map[string]types.Unit)
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		unit := committed[pod.Spec.NodeName]
+		for _, container := range pod.Spec.Containers {
+			addUnit(&unit, resourceListUnit(container.Resources.Limits))
+		}
+		committed[pod.Spec.NodeName] = unit
+	}
+
+	nodes := make([]NodeInventory, 0, len(knodes))
+	for _, knode := range knodes {
+		nodes = append(nodes, NodeInventory{
+			Name:        knode.Name,
+			Capacity:    resourceListUnit(knode.Status.Capacity),
+			Allocatable: resourceListUnit(knode.Status.Allocatable),
+			Committed:   committed[knode.Name],
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, nil
+}
+
+func resourceListUnit(rl corev1.ResourceList) types.Unit {
+	return types.Unit{
+		CPU:     uint32(rl.Cpu().MilliValue()),
+		Memory:  uint64(rl.Memory().Value()),
+		Storage: uint64(rl.StorageEphemeral().Value()),
+	}
+}
+
+func addUnit(dst *types.Unit, src types.Unit) {
+	dst.CPU += src.CPU
+	dst.Memory += src.Memory
+	dst.Storage += src.Storage
+}
+
+func subUnit(dst *types.Unit, src types.Unit) {
+	dst.CPU -= src.CPU
+	dst.Memory -= src.Memory
+	dst.Storage -= src.Storage
+}
+
+func unitFits(unit, avail types.Unit) bool {
+	return unit.CPU <= avail.CPU && unit.Memory <= avail.Memory && unit.Storage <= avail.Storage
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
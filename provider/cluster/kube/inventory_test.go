@@ -0,0 +1,149 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/ovrclk/akash/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNodeInventory(t *testing.T) {
+	resources := func(cpu, memory, storage string) corev1.ResourceList {
+		return corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse(cpu),
+			corev1.ResourceMemory:           resource.MustParse(memory),
+			corev1.ResourceEphemeralStorage: resource.MustParse(storage),
+		}
+	}
+
+	node := func(name string, ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Capacity:    resources("4", "8Gi", "100Gi"),
+				Allocatable: resources("3", "6Gi", "80Gi"),
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: ready},
+				},
+			},
+		}
+	}
+
+	pod := func(name, nodeName string, managed bool, phase corev1.PodPhase) *corev1.Pod {
+		labels := map[string]string{}
+		if managed {
+			labels[akashManagedLabelName] = "true"
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "lease", Labels: labels},
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+				Containers: []corev1.Container{{
+					Resources: corev1.ResourceRequirements{Limits: resources("500m", "1Gi", "10Gi")},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	kc := kfake.NewSimpleClientset(
+		node("node-b", corev1.ConditionTrue),
+		node("node-a", corev1.ConditionTrue),
+		node("node-down", corev1.ConditionFalse),
+		pod("web-1", "node-a", true, corev1.PodRunning),
+		pod("web-2", "node-a", true, corev1.PodRunning),
+		pod("web-3", "node-b", true, corev1.PodSucceeded),
+		pod("kube-proxy", "node-b", false, corev1.PodRunning),
+	)
+
+	c := &client{kc: kc, log: log.NewNopLogger()}
+
+	nodes, err := c.NodeInventory()
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+
+	const gi = 1024 * 1024 * 1024
+
+	assert.Equal(t, "node-a", nodes[0].Name)
+	assert.Equal(t, types.Unit{CPU: 4000, Memory: 8 * gi, Storage: 100 * gi}, nodes[0].Capacity)
+	assert.Equal(t, types.Unit{CPU: 3000, Memory: 6 * gi, Storage: 80 * gi}, nodes[0].Allocatable)
+	assert.Equal(t, types.Unit{CPU: 1000, Memory: 2 * gi, Storage: 20 * gi}, nodes[0].Committed)
+	assert.Equal(t, types.Unit{CPU: 2000, Memory: 4 * gi, Storage: 60 * gi}, nodes[0].Available())
+
+	assert.Equal(t, "node-b", nodes[1].Name)
+	assert.Equal(t, types.Unit{}, nodes[1].Committed)
+
+	total := TotalInventory(nodes)
+	assert.Equal(t, types.Unit{CPU: 6000, Memory: 12 * gi, Storage: 160 * gi}, total.Allocatable)
+	assert.Equal(t, types.Unit{CPU: 1000, Memory: 2 * gi, Storage: 20 * gi}, total.Committed)
+	assert.Equal(t, types.Unit{CPU: 5000, Memory: 10 * gi, Storage: 140 * gi}, total.Available())
+}
+
+func TestFitResources(t *testing.T) {
+	resources := func(cpu, memory, storage string) corev1.ResourceList {
+		return corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse(cpu),
+			corev1.ResourceMemory:           resource.MustParse(memory),
+			corev1.ResourceEphemeralStorage: resource.MustParse(storage),
+		}
+	}
+
+	node := func(name string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Capacity:    resources("1", "16Gi", "100Gi"),
+				Allocatable: resources("1", "16Gi", "100Gi"),
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				},
+			},
+		}
+	}
+
+	kc := kfake.NewSimpleClientset(
+		node("node-a"),
+		node("node-b"),
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-1",
+				Namespace: "lease",
+				Labels:    map[string]string{akashManagedLabelName: "true"},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "node-a",
+				Containers: []corev1.Container{{
+					Resources: corev1.ResourceRequirements{Limits: resources("500m", "1Gi", "1Gi")},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	)
+
+	c := &client{kc: kc, log: log.NewNopLogger()}
+
+	nodes, err := c.NodeInventory()
+	require.NoError(t, err)
+
+	const gi = 1024 * 1024 * 1024
+	unit := types.Unit{CPU: 800, Memory: gi, Storage: gi}
+
+	ok, limit := FitResources(nodes, []types.Resource{{Unit: unit, Count: 1}})
+	assert.True(t, ok)
+	assert.Empty(t, limit)
+
+	// plenty of memory and storage, but only one node has 800m cpu free
+	ok, limit = FitResources(nodes, []types.Resource{{Unit: unit, Count: 2}})
+	assert.False(t, ok)
+	assert.Equal(t, "cpu", limit)
+
+	ok, limit = FitResources(nodes, []types.Resource{{Unit: types.Unit{CPU: 100, Memory: 32 * gi, Storage: gi}, Count: 1}})
+	assert.False(t, ok)
+	assert.Equal(t, "memory", limit)
+}
@@ -0,0 +1,63 @@
+package kube
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/ovrclk/akash/provider/cluster"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// podLogOpener opens the log stream of a single pod.
+type podLogOpener func(ctx context.Context, kc kubernetes.Interface, ns, name string, opts *corev1.PodLogOptions) (io.ReadCloser, error)
+
+func openPodLogs(ctx context.Context, kc kubernetes.Interface, ns, name string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+	return kc.CoreV1().Pods(ns).GetLogs(name, opts).Context(ctx).Stream()
+}
+
+// leaseLogs opens a log stream for every pod in the lease namespace.  A
+// non-positive tailLines returns the whole log; a non-zero since limits it
+// to lines newer than that.  Any streams already opened are closed if one
+// fails.
+func leaseLogs(ctx context.Context, kc kubernetes.Interface, lid mtypes.LeaseID,
+	tailLines int64, follow bool, since time.Duration, open podLogOpener) ([]*cluster.ServiceLog, error) {
+
+	ns := lidNS(lid)
+
+	pods, err := kc.CoreV1().Pods(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &corev1.PodLogOptions{
+		Follow:     follow,
+		Timestamps: true,
+	}
+	if tailLines > 0 {
+		opts.TailLines = &tailLines
+	}
+	if since > 0 {
+		seconds := int64(since.Round(time.Second) / time.Second)
+		if seconds == 0 {
+			seconds = 1
+		}
+		opts.SinceSeconds = &seconds
+	}
+
+	streams := make([]*cluster.ServiceLog, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		stream, err := open(ctx, kc, ns, pod.Name, opts)
+		if err != nil {
+			for _, sl := range streams {
+				sl.Stream.Close()
+			}
+			return nil, err
+		}
+		streams = append(streams, cluster.NewServiceLog(pod.Name, stream))
+	}
+	return streams, nil
+}
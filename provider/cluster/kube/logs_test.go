@@ -0,0 +1,52 @@
+package kube
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLeaseLogs(t *testing.T) {
+	lid := mtypes.LeaseID{DSeq: 1}
+	ns := lidNS(lid)
+
+	kc := kfake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: ns}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}},
+	)
+
+	var opened []string
+	var options *corev1.PodLogOptions
+
+	open := func(_ context.Context, _ kubernetes.Interface, pns, name string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		opened = append(opened, pns+"/"+name)
+		options = opts
+		return ioutil.NopCloser(strings.NewReader("hello\n")), nil
+	}
+
+	logs, err := leaseLogs(context.Background(), kc, lid, 0, true, 90*time.Second, open)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	assert.Equal(t, []string{ns + "/web-0"}, opened)
+	assert.Equal(t, "web-0", logs[0].Name)
+	require.True(t, logs[0].Scanner.Scan())
+	assert.Equal(t, "hello", logs[0].Scanner.Text())
+
+	require.NotNil(t, options)
+	assert.True(t, options.Follow)
+	assert.Nil(t, options.TailLines)
+	require.NotNil(t, options.SinceSeconds)
+	assert.Equal(t, int64(90), *options.SinceSeconds)
+}
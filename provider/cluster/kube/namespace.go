@@ -0,0 +1,44 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// errNamespaceTerminating is returned when a namespace from a previous lease
+// is still being deleted.  Applying again later may succeed.
+var errNamespaceTerminating = errors.New("namespace still terminating")
+
+var (
+	// how long to wait for a terminating namespace to be deleted.
+	nsTerminatingTimeout = 30 * time.Second
+	// how often to check whether it is gone.
+	nsTerminatingInterval = time.Second
+)
+
+// getNamespace gets the named namespace.  If it is terminating, it waits
+// until the deletion completes, after which the namespace is reported as not
+// found so that callers recreate it.  Waiting stops early if ctx is done.
+func getNamespace(ctx context.Context, kc kubernetes.Interface, name string) (*corev1.Namespace, error) {
+	deadline := time.Now().Add(nsTerminatingTimeout)
+	for {
+		obj, err := kc.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+		if err != nil || obj.Status.Phase != corev1.NamespaceTerminating {
+			return obj, err
+		}
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("%w: %v", errNamespaceTerminating, name)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(nsTerminatingInterval):
+		}
+	}
+}
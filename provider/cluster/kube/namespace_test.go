@@ -0,0 +1,126 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ovrclk/akash/manifest"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestNSBuilder_labels(t *testing.T) {
+	lid := mtypes.LeaseID{
+		Owner:    sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address()),
+		DSeq:     120,
+		GSeq:     2,
+		OSeq:     3,
+		Provider: sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address()),
+	}
+	b := newNSBuilder(lid, &manifest.Group{Name: "test"})
+
+	obj, err := b.create()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		akashManagedLabelName:       "true",
+		akashLeaseIDLabelName:       "120.2.3",
+		akashLeaseOwnerLabelName:    lid.Owner.String(),
+		akashLeaseProviderLabelName: lid.Provider.String(),
+	}, obj.Labels)
+	assert.Equal(t, map[string]string{
+		akashLeaseAnnotationName:         lid.Owner.String() + "/120/2/3/" + lid.Provider.String(),
+		akashCreatedHeightAnnotationName: "120",
+	}, obj.Annotations)
+
+	// labels and annotations set by others are kept
+	obj.Labels = map[string]string{"team": "ops", akashLeaseIDLabelName: "stale"}
+	obj.Annotations = map[string]string{"note": "keep"}
+	obj, err = b.update(obj)
+	require.NoError(t, err)
+	assert.Equal(t, "ops", obj.Labels["team"])
+	assert.Equal(t, "120.2.3", obj.Labels[akashLeaseIDLabelName])
+	assert.Equal(t, lid.Owner.String(), obj.Labels[akashLeaseOwnerLabelName])
+	assert.Equal(t, "keep", obj.Annotations["note"])
+	assert.Equal(t, "120", obj.Annotations[akashCreatedHeightAnnotationName])
+}
+
+func TestApplyNS_perLease(t *testing.T) {
+	owner := sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+	provider := sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+	group := &manifest.Group{Name: "test"}
+
+	kc := kfake.NewSimpleClientset()
+
+	var builders []*nsBuilder
+	for oseq := uint32(1); oseq <= 2; oseq++ {
+		lid := mtypes.LeaseID{Owner: owner, DSeq: 1, GSeq: 1, OSeq: oseq, Provider: provider}
+		b := newNSBuilder(lid, group)
+		require.NoError(t, applyNS(context.Background(), kc, b))
+		builders = append(builders, b)
+	}
+
+	// each lease keeps a namespace, and labels, of its own
+	require.NotEqual(t, builders[0].name(), builders[1].name())
+	for _, b := range builders {
+		obj, err := kc.CoreV1().Namespaces().Get(b.name(), metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, b.labels(), obj.Labels)
+		assert.Equal(t, b.annotations(), obj.Annotations)
+	}
+}
+
+func TestApplyNS_terminating(t *testing.T) {
+	defer func(prev time.Duration) { nsTerminatingInterval = prev }(nsTerminatingInterval)
+	nsTerminatingInterval = 0
+
+	b := newNSBuilder(mtypes.LeaseID{DSeq: 1}, &manifest.Group{Name: "test"})
+
+	kc := kfake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: b.name()},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+	})
+
+	// the namespace finishes deleting after it has been seen terminating twice.
+	gets := 0
+	kc.PrependReactor("get", "namespaces", func(ktesting.Action) (bool, runtime.Object, error) {
+		gets++
+		if gets == 3 {
+			gvr := corev1.SchemeGroupVersion.WithResource("namespaces")
+			require.NoError(t, kc.Tracker().Delete(gvr, "", b.name()))
+		}
+		return false, nil, nil
+	})
+
+	require.NoError(t, applyNS(context.Background(), kc, b))
+	assert.Equal(t, 3, gets)
+
+	obj, err := kc.CoreV1().Namespaces().Get(b.name(), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotEqual(t, corev1.NamespaceTerminating, obj.Status.Phase)
+	assert.Equal(t, b.labels(), obj.Labels)
+}
+
+func TestApplyNS_terminatingTimeout(t *testing.T) {
+	defer func(prev time.Duration) { nsTerminatingTimeout = prev }(nsTerminatingTimeout)
+	nsTerminatingTimeout = 0
+
+	b := newNSBuilder(mtypes.LeaseID{DSeq: 1}, &manifest.Group{Name: "test"})
+
+	kc := kfake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: b.name()},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+	})
+
+	err := applyNS(context.Background(), kc, b)
+	assert.True(t, errors.Is(err, errNamespaceTerminating))
+}
@@ -0,0 +1,154 @@
+package kube
+
+import (
+	"encoding/json"
+
+	"github.com/tendermint/tendermint/libs/log"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// PodDisruptionBudget API versions.  policy/v1beta1 is removed from
+// Kubernetes 1.25.
+const (
+	pdbAPIPolicyV1beta1 = "policy/v1beta1"
+	pdbAPIPolicyV1      = "policy/v1"
+)
+
+var policyV1PDBKind = schema.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}
+
+// detectPDBAPI returns the policy/v1 disruption budget API if the cluster
+// serves it, and policy/v1beta1 otherwise.
+func detectPDBAPI(dc discovery.DiscoveryInterface) (string, error) {
+	resources, err := dc.ServerResourcesForGroupVersion(pdbAPIPolicyV1)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return "", err
+	}
+
+	if resources != nil {
+		for _, res := range resources.APIResources {
+			if res.Name == "poddisruptionbudgets" {
+				return pdbAPIPolicyV1, nil
+			}
+		}
+	}
+
+	return pdbAPIPolicyV1beta1, nil
+}
+
+// configurePDBAPI selects the disruption budget API the cluster serves,
+// unless one is configured.
+func configurePDBAPI(log log.Logger, dc discovery.DiscoveryInterface) error {
+	if config.DeploymentPDBAPI == "" {
+		api, err := detectPDBAPI(dc)
+		if err != nil {
+			return err
+		}
+		config.DeploymentPDBAPI = api
+	}
+	log.Info("using disruption budget API", "version", config.DeploymentPDBAPI)
+	return nil
+}
+
+func pdbAPIV1() bool {
+	return config.DeploymentPDBAPI == pdbAPIPolicyV1
+}
+
+func deletePDB(kc kubernetes.Interface, ns, name string) error {
+	if pdbAPIV1() {
+		return policyV1PDBs(kc, ns).Delete(name)
+	}
+	return kc.PolicyV1beta1().PodDisruptionBudgets(ns).Delete(name, &metav1.DeleteOptions{})
+}
+
+func deletePDBCollection(kc kubernetes.Interface, ns string, opts metav1.ListOptions) error {
+	if pdbAPIV1() {
+		return policyV1PDBs(kc, ns).DeleteCollection(opts)
+	}
+	return kc.PolicyV1beta1().PodDisruptionBudgets(ns).DeleteCollection(&metav1.DeleteOptions{}, opts)
+}
+
+// policyV1REST sends requests to the policy/v1 API.  The vendored client-go
+// predates the group version, so the policy/v1beta1 REST client is used with
+// the path replaced.
+type policyV1REST struct {
+	rest.Interface
+}
+
+const policyV1Path = "/apis/policy/v1"
+
+func (c policyV1REST) Get() *rest.Request    { return c.Interface.Get().AbsPath(policyV1Path) }
+func (c policyV1REST) Post() *rest.Request   { return c.Interface.Post().AbsPath(policyV1Path) }
+func (c policyV1REST) Put() *rest.Request    { return c.Interface.Put().AbsPath(policyV1Path) }
+func (c policyV1REST) Delete() *rest.Request { return c.Interface.Delete().AbsPath(policyV1Path) }
+
+func (c policyV1REST) Patch(pt types.PatchType) *rest.Request {
+	return c.Interface.Patch(pt).AbsPath(policyV1Path)
+}
+
+// policyV1PDBClient reads and writes policy/v1 disruption budgets.  The
+// vendored k8s.io/api has no policy/v1 types; the fields the provider sets
+// have the same schema in policy/v1beta1, so its type is sent with the
+// policy/v1 kind.
+type policyV1PDBClient struct {
+	rc rest.Interface
+	ns string
+}
+
+func policyV1PDBs(kc kubernetes.Interface, ns string) *policyV1PDBClient {
+	return &policyV1PDBClient{rc: policyV1REST{kc.PolicyV1beta1().RESTClient()}, ns: ns}
+}
+
+func (c *policyV1PDBClient) Get(name string) (*policyv1beta1.PodDisruptionBudget, error) {
+	obj := &policyv1beta1.PodDisruptionBudget{}
+	err := c.do(c.rc.Get().Namespace(c.ns).Resource("poddisruptionbudgets").Name(name), obj)
+	return obj, err
+}
+
+func (c *policyV1PDBClient) Create(obj *policyv1beta1.PodDisruptionBudget) (*policyv1beta1.PodDisruptionBudget, error) {
+	obj.SetGroupVersionKind(policyV1PDBKind)
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	result := &policyv1beta1.PodDisruptionBudget{}
+	err = c.do(c.rc.Post().Namespace(c.ns).Resource("poddisruptionbudgets").Body(data), result)
+	return result, err
+}
+
+func (c *policyV1PDBClient) Update(obj *policyv1beta1.PodDisruptionBudget) (*policyv1beta1.PodDisruptionBudget, error) {
+	obj.SetGroupVersionKind(policyV1PDBKind)
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	result := &policyv1beta1.PodDisruptionBudget{}
+	err = c.do(c.rc.Put().Namespace(c.ns).Resource("poddisruptionbudgets").Name(obj.Name).Body(data), result)
+	return result, err
+}
+
+func (c *policyV1PDBClient) Delete(name string) error {
+	return c.rc.Delete().Namespace(c.ns).Resource("poddisruptionbudgets").Name(name).Do().Error()
+}
+
+func (c *policyV1PDBClient) DeleteCollection(opts metav1.ListOptions) error {
+	return c.rc.Delete().Namespace(c.ns).Resource("poddisruptionbudgets").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Error()
+}
+
+func (c *policyV1PDBClient) do(req *rest.Request, into interface{}) error {
+	data, err := req.Do().Raw()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, into)
+}
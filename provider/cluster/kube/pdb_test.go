@@ -0,0 +1,88 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/ovrclk/akash/manifest"
+	"github.com/ovrclk/akash/types"
+	"github.com/ovrclk/akash/types/unit"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestDetectPDBAPI(t *testing.T) {
+	kc := kfake.NewSimpleClientset()
+	dc := kc.Discovery().(*fakediscovery.FakeDiscovery)
+
+	dc.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: pdbAPIPolicyV1beta1,
+			APIResources: []metav1.APIResource{{Name: "poddisruptionbudgets"}},
+		},
+	}
+
+	api, err := detectPDBAPI(dc)
+	require.NoError(t, err)
+	assert.Equal(t, pdbAPIPolicyV1beta1, api)
+
+	dc.Resources = append(dc.Resources, &metav1.APIResourceList{
+		GroupVersion: pdbAPIPolicyV1,
+		APIResources: []metav1.APIResource{{Name: "poddisruptionbudgets"}},
+	})
+
+	api, err = detectPDBAPI(dc)
+	require.NoError(t, err)
+	assert.Equal(t, pdbAPIPolicyV1, api)
+}
+
+func TestApplyPDB_policyV1(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentPDBAPI = pdbAPIPolicyV1
+
+	server, requests := ssaServer(t, false, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound)
+		case http.MethodPost:
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write(body)
+		default:
+			t.Errorf("unexpected request: %v %v", r.Method, r.URL.Path)
+		}
+	})
+	defer server.Close()
+
+	kc, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})
+	service.Count = 3
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+	b := newPDBBuilder(log.NewNopLogger(), mtypes.LeaseID{}, group, service)
+
+	require.NoError(t, applyPDB(context.Background(), kc, b))
+
+	reqs := requests()
+	require.Len(t, reqs, 2)
+	for _, req := range reqs {
+		assert.Contains(t, req.path, "/apis/policy/v1/namespaces/"+b.ns()+"/poddisruptionbudgets")
+	}
+
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(reqs[1].body), &created))
+	assert.Equal(t, "policy/v1", created["apiVersion"])
+	assert.Equal(t, "PodDisruptionBudget", created["kind"])
+}
@@ -0,0 +1,47 @@
+package kube
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// retryApply runs fn, an apply's Get then Update or Create, until it
+// succeeds, fails with an error that isn't transient, or has been tried
+// config.ApplyRetryAttempts times.  The wait between attempts starts at
+// config.ApplyRetryBackoff and doubles each time.  fn reads the object
+// again on every attempt, so updates retried after a conflict carry a
+// fresh resourceVersion.  ctx is checked before each attempt.
+func retryApply(ctx context.Context, fn func() error) error {
+	backoff := config.ApplyRetryBackoff
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil || !transientApplyError(err) || attempt >= config.ApplyRetryAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// transientApplyError reports whether err may not recur: a conflict with a
+// concurrent writer, or a timeout reaching or within the API server.
+func transientApplyError(err error) bool {
+	if errors.IsConflict(err) || errors.IsServerTimeout(err) || errors.IsTimeout(err) {
+		return true
+	}
+	var nerr net.Error
+	return stderrors.As(err, &nerr) && nerr.Timeout()
+}
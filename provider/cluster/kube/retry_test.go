@@ -0,0 +1,81 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ovrclk/akash/types"
+	"github.com/ovrclk/akash/types/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestApplyDeployment_retry(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.ApplyRetryAttempts = 3
+	config.ApplyRetryBackoff = time.Millisecond
+
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	tests := []struct {
+		name     string
+		failures int
+		err      error
+		updates  int
+		ok       bool
+	}{
+		{"conflict then success", 1, errors.NewConflict(gr, "web", fmt.Errorf("stale")), 2, true},
+		{"timeout then success", 2, errors.NewServerTimeout(gr, "update", 1), 3, true},
+		{"conflicts past attempts", 5, errors.NewConflict(gr, "web", fmt.Errorf("stale")), 3, false},
+		{"invalid spec", 1, errors.NewBadRequest("invalid"), 1, false},
+	}
+
+	for _, test := range tests {
+		b := testDeploymentBuilder(testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi}))
+		existing, err := b.create()
+		require.NoError(t, err)
+		existing.Namespace = b.ns()
+
+		kc := kfake.NewSimpleClientset(existing)
+
+		updates := 0
+		kc.PrependReactor("update", "deployments", func(ktesting.Action) (bool, runtime.Object, error) {
+			updates++
+			if updates <= test.failures {
+				return true, nil, test.err
+			}
+			return false, nil, nil
+		})
+
+		b.service.Count = 3
+		err = applyDeployment(context.Background(), kc, b)
+		assert.Equal(t, test.updates, updates, test.name)
+
+		gets := 0
+		for _, action := range kc.Actions() {
+			if action.GetVerb() == "get" {
+				gets++
+			}
+		}
+		// the deployment is read again before every update
+		assert.Equal(t, test.updates, gets, test.name)
+
+		if !test.ok {
+			assert.Equal(t, test.err, err, test.name)
+			continue
+		}
+		require.NoError(t, err, test.name)
+
+		obj, err := kc.AppsV1().Deployments(b.ns()).Get(b.name(), metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), *obj.Spec.Replicas, test.name)
+	}
+}
@@ -0,0 +1,59 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// types.ApplyPatchType; the vendored apimachinery predates it.
+	applyPatchType types.PatchType = "application/apply-patch+yaml"
+
+	// Owner of the fields set by the provider's applies.
+	applyFieldManager = "akash-provider"
+)
+
+type applyObject interface {
+	metav1.Object
+	GetObjectKind() schema.ObjectKind
+}
+
+// serverSideApply sends obj, the whole desired state of a resource, as a
+// server-side apply patch, creating or updating the resource in a single
+// request.  Fields set by obj are taken over from any other manager; fields
+// it leaves out keep their value unless the provider set them before.
+//
+// It returns false, and no error, if the API server doesn't support
+// server-side apply, in which case the caller falls back to its Get then
+// Update or Create.
+func serverSideApply(ctx context.Context, rc rest.Interface, resource, ns string,
+	gvk schema.GroupVersionKind, obj applyObject) (bool, error) {
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return true, err
+	}
+
+	err = rc.Patch(applyPatchType).
+		Context(ctx).
+		Namespace(ns).
+		Resource(resource).
+		Name(obj.GetName()).
+		Param("fieldManager", applyFieldManager).
+		Param("force", "true").
+		Body(data).
+		Do().
+		Error()
+
+	if errors.IsUnsupportedMediaType(err) {
+		return false, nil
+	}
+	return true, err
+}
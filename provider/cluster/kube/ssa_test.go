@@ -0,0 +1,230 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ovrclk/akash/manifest"
+	"github.com/ovrclk/akash/types"
+	"github.com/ovrclk/akash/types/unit"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+type ssaRequest struct {
+	method      string
+	path        string
+	contentType string
+	query       map[string]string
+	body        string
+}
+
+// ssaServer runs an API server recording the requests made to it.  Apply
+// patches are echoed back if supported and get 415 Unsupported Media Type
+// otherwise; other requests are answered by fallback.
+func ssaServer(t *testing.T, supported bool, fallback http.HandlerFunc) (*httptest.Server, func() []ssaRequest) {
+	var (
+		requests []ssaRequest
+		lock     sync.Mutex
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		lock.Lock()
+		requests = append(requests, ssaRequest{
+			method:      r.Method,
+			path:        r.URL.Path,
+			contentType: r.Header.Get("Content-Type"),
+			query: map[string]string{
+				"fieldManager": r.URL.Query().Get("fieldManager"),
+				"force":        r.URL.Query().Get("force"),
+			},
+			body: string(body),
+		})
+		lock.Unlock()
+
+		switch {
+		case r.Method == http.MethodPatch && supported:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body)
+		case r.Method == http.MethodPatch:
+			writeStatus(w, http.StatusUnsupportedMediaType, metav1.StatusReasonUnsupportedMediaType)
+		default:
+			fallback(w, r)
+		}
+	}))
+	return server, func() []ssaRequest {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]ssaRequest(nil), requests...)
+	}
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason metav1.StatusReason) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(&metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Reason:   reason,
+		Code:     int32(code),
+	})
+}
+
+func TestApplyDeployment_serverSide(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.ServerSideApply = true
+
+	server, requests := ssaServer(t, true, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %v %v", r.Method, r.URL.Path)
+	})
+	defer server.Close()
+
+	kc, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	b := testDeploymentBuilder(testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi}))
+
+	require.NoError(t, applyDeployment(context.Background(), kc, b))
+	require.NoError(t, applyDeployment(context.Background(), kc, b))
+
+	// one request per apply, no read before writing
+	reqs := requests()
+	require.Len(t, reqs, 2)
+	for _, req := range reqs {
+		assert.Equal(t, http.MethodPatch, req.method)
+		assert.Equal(t, "/apis/apps/v1/namespaces/"+b.ns()+"/deployments/web", req.path)
+		assert.Equal(t, string(applyPatchType), req.contentType)
+		assert.Equal(t, map[string]string{"fieldManager": applyFieldManager, "force": "true"}, req.query)
+	}
+
+	// re-applying an unchanged manifest sends the same configuration, which
+	// the API server applies as a no-op
+	assert.Equal(t, reqs[0].body, reqs[1].body)
+
+	var applied map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(reqs[0].body), &applied))
+	assert.Equal(t, "apps/v1", applied["apiVersion"])
+	assert.Equal(t, "Deployment", applied["kind"])
+}
+
+func TestApplyDeployment_serverSideUnsupported(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.ServerSideApply = true
+
+	server, requests := ssaServer(t, false, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound)
+		case http.MethodPost:
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write(body)
+		default:
+			t.Errorf("unexpected request: %v %v", r.Method, r.URL.Path)
+		}
+	})
+	defer server.Close()
+
+	kc, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	b := testDeploymentBuilder(testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi}))
+	require.NoError(t, applyDeployment(context.Background(), kc, b))
+
+	// falls back to Get then Create
+	var methods []string
+	for _, req := range requests() {
+		methods = append(methods, req.method)
+	}
+	assert.Equal(t, []string{http.MethodPatch, http.MethodGet, http.MethodPost}, methods)
+}
+
+func TestApplyWorkloadResources_serverSide(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.ServerSideApply = true
+	config.DeploymentRegistryServer = "registry.example.com"
+	config.DeploymentRegistryUsername = "user"
+	config.DeploymentRegistryPassword = "pass"
+
+	server, requests := ssaServer(t, true, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %v %v", r.Method, r.URL.Path)
+	})
+	defer server.Close()
+
+	kc, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	service := testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi, Storage: 10 * unit.Gi})
+	service.Stateful = true
+	service.StorageMount = "/data"
+	group := &manifest.Group{Name: "test", Services: []manifest.Service{*service}}
+	lid := mtypes.LeaseID{}
+	ns := lidNS(lid)
+	ctx := context.Background()
+
+	tests := []struct {
+		apply func() error
+		path  string
+		kind  string
+	}{
+		{
+			apply: func() error {
+				return applyStatefulSet(ctx, kc, newStatefulSetBuilder(log.NewNopLogger(), lid, group, service))
+			},
+			path: "/apis/apps/v1/namespaces/" + ns + "/statefulsets/web",
+			kind: "StatefulSet",
+		},
+		{
+			apply: func() error {
+				return applyHeadlessService(ctx, kc, newHeadlessServiceBuilder(log.NewNopLogger(), lid, group, service))
+			},
+			path: "/api/v1/namespaces/" + ns + "/services/web-headless",
+			kind: "Service",
+		},
+		{
+			apply: func() error {
+				return applyPVC(ctx, kc, newPVCBuilder(log.NewNopLogger(), lid, group, service))
+			},
+			path: "/api/v1/namespaces/" + ns + "/persistentvolumeclaims/web-storage",
+			kind: "PersistentVolumeClaim",
+		},
+		{
+			apply: func() error {
+				return applyPullSecret(ctx, kc, newPullSecretBuilder(log.NewNopLogger(), lid, group))
+			},
+			path: "/api/v1/namespaces/" + ns + "/secrets/" + pullSecretName,
+			kind: "Secret",
+		},
+	}
+
+	for _, test := range tests {
+		seen := len(requests())
+		require.NoError(t, test.apply(), test.kind)
+
+		// a single apply patch, no read before writing
+		reqs := requests()[seen:]
+		require.Len(t, reqs, 1, test.kind)
+		assert.Equal(t, http.MethodPatch, reqs[0].method)
+		assert.Equal(t, test.path, reqs[0].path)
+		assert.Equal(t, string(applyPatchType), reqs[0].contentType)
+
+		var applied map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(reqs[0].body), &applied))
+		assert.Equal(t, test.kind, applied["kind"])
+	}
+}
@@ -0,0 +1,145 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	akashv1 "github.com/ovrclk/akash/pkg/client/clientset/versioned"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// teardownLease deletes the resources applyLease created for a lease, in
+// reverse order: ingresses, services, deployments and stateful sets, then
+// the namespace and finally the manifest in mns.  The namespace is only
+// deleted if it carries the lease's labels (see deleteLeaseNS).  Resources
+// already gone are skipped, so a failed teardown can be run again.
+func teardownLease(ctx context.Context, kc kubernetes.Interface, mc akashv1.Interface, mns string, lid mtypes.LeaseID) error {
+	ns := lidNS(lid)
+	selector := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", akashManagedLabelName),
+	}
+
+	ingresses, err := listIngresses(kc, ns, selector)
+	if err != nil {
+		return err
+	}
+	for _, obj := range ingresses {
+		if err := deleteIngress(ctx, kc, ns, obj.Name); err != nil {
+			return err
+		}
+	}
+
+	services, err := kc.CoreV1().Services(ns).List(selector)
+	if err != nil {
+		return err
+	}
+	for _, obj := range services.Items {
+		if err := deleteService(ctx, kc, ns, obj.Name); err != nil {
+			return err
+		}
+	}
+
+	deployments, err := kc.AppsV1().Deployments(ns).List(selector)
+	if err != nil {
+		return err
+	}
+	for _, obj := range deployments.Items {
+		if err := deleteDeployment(ctx, kc, ns, obj.Name); err != nil {
+			return err
+		}
+	}
+
+	statefulSets, err := kc.AppsV1().StatefulSets(ns).List(selector)
+	if err != nil {
+		return err
+	}
+	for _, obj := range statefulSets.Items {
+		if err := deleteStatefulSet(ctx, kc, ns, obj.Name); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteLeaseNS(ctx, kc, lid); err != nil {
+		return err
+	}
+
+	return deleteManifest(ctx, mc, mns, ns)
+}
+
+// deleteLeaseNS deletes the namespace of lid if it is labelled as belonging
+// to that lease.  Namespaces without the lease labels, such as those created
+// before namespaces were per lease, may hold other leases' workloads and
+// are left in place.
+func deleteLeaseNS(ctx context.Context, kc kubernetes.Interface, lid mtypes.LeaseID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b := newNSBuilder(lid, nil)
+	obj, err := kc.CoreV1().Namespaces().Get(b.name(), metav1.GetOptions{})
+	if err != nil {
+		return ignoreNotFound(err)
+	}
+	for name, value := range b.labels() {
+		if obj.Labels[name] != value {
+			return nil
+		}
+	}
+
+	return deleteNS(ctx, kc, b.name())
+}
+
+func deleteNS(ctx context.Context, kc kubernetes.Interface, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ignoreNotFound(kc.CoreV1().Namespaces().Delete(name, &metav1.DeleteOptions{}))
+}
+
+func deleteDeployment(ctx context.Context, kc kubernetes.Interface, ns, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ignoreNotFound(kc.AppsV1().Deployments(ns).Delete(name, &metav1.DeleteOptions{}))
+}
+
+func deleteStatefulSet(ctx context.Context, kc kubernetes.Interface, ns, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ignoreNotFound(kc.AppsV1().StatefulSets(ns).Delete(name, &metav1.DeleteOptions{}))
+}
+
+func deleteService(ctx context.Context, kc kubernetes.Interface, ns, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ignoreNotFound(kc.CoreV1().Services(ns).Delete(name, &metav1.DeleteOptions{}))
+}
+
+func deleteIngress(ctx context.Context, kc kubernetes.Interface, ns, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ingressAPIV1() {
+		return ignoreNotFound(netv1Ingresses(kc, ns).Delete(name))
+	}
+	return ignoreNotFound(kc.ExtensionsV1beta1().Ingresses(ns).Delete(name, &metav1.DeleteOptions{}))
+}
+
+func deleteManifest(ctx context.Context, mc akashv1.Interface, ns, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ignoreNotFound(mc.AkashV1().Manifests(ns).Delete(name, &metav1.DeleteOptions{}))
+}
+
+func ignoreNotFound(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
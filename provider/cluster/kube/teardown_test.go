@@ -0,0 +1,87 @@
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ovrclk/akash/manifest"
+	afake "github.com/ovrclk/akash/pkg/client/clientset/versioned/fake"
+	"github.com/ovrclk/akash/types"
+	"github.com/ovrclk/akash/types/unit"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTeardownLease(t *testing.T) {
+	defer func(prev config_) { config = prev }(config)
+	config.DeploymentIngressDomain = "provider.test"
+
+	const mns = "lease"
+
+	lid := mtypes.LeaseID{DSeq: 1, GSeq: 1, OSeq: 1}
+	group := &manifest.Group{
+		Name:     "test",
+		Services: []manifest.Service{*testService(types.Unit{CPU: 100, Memory: 128 * unit.Mi})},
+	}
+
+	kc := kfake.NewSimpleClientset()
+	mc := afake.NewSimpleClientset()
+	require.NoError(t, applyLease(context.Background(), kc, mc, log.NewNopLogger(), "host", mns, lid, group))
+
+	ns := lidNS(lid)
+	all := metav1.ListOptions{}
+
+	// deleting twice is the same as deleting once
+	for i := 0; i < 2; i++ {
+		require.NoError(t, teardownLease(context.Background(), kc, mc, mns, lid))
+
+		ingresses, err := kc.ExtensionsV1beta1().Ingresses(ns).List(all)
+		require.NoError(t, err)
+		assert.Empty(t, ingresses.Items)
+
+		services, err := kc.CoreV1().Services(ns).List(all)
+		require.NoError(t, err)
+		assert.Empty(t, services.Items)
+
+		deployments, err := kc.AppsV1().Deployments(ns).List(all)
+		require.NoError(t, err)
+		assert.Empty(t, deployments.Items)
+
+		_, err = kc.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
+		assert.Error(t, err)
+
+		manifests, err := mc.AkashV1().Manifests(mns).List(all)
+		require.NoError(t, err)
+		assert.Empty(t, manifests.Items)
+	}
+
+	ctx := context.Background()
+	assert.NoError(t, deleteLeaseNS(ctx, kc, lid))
+	assert.NoError(t, deleteNS(ctx, kc, ns))
+	assert.NoError(t, deleteDeployment(ctx, kc, ns, "web"))
+	assert.NoError(t, deleteService(ctx, kc, ns, "web"))
+	assert.NoError(t, deleteIngress(ctx, kc, ns, "web"))
+	assert.NoError(t, deleteManifest(ctx, mc, mns, ns))
+}
+
+func TestDeleteLeaseNS_unlabelled(t *testing.T) {
+	lid := mtypes.LeaseID{DSeq: 1, GSeq: 1, OSeq: 1}
+	ns := lidNS(lid)
+
+	kc := kfake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   ns,
+			Labels: map[string]string{akashManagedLabelName: "true"},
+		},
+	})
+
+	require.NoError(t, deleteLeaseNS(context.Background(), kc, lid))
+
+	_, err := kc.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
+	assert.NoError(t, err)
+}
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -24,6 +25,7 @@ const (
 )
 
 type deploymentManager struct {
+	config  config
 	bus     pubsub.Bus
 	client  Client
 	session session.Session
@@ -39,14 +41,20 @@ type deploymentManager struct {
 	updatech   chan *manifest.Group
 	teardownch chan struct{}
 
+	// cancels in-flight deploys on shutdown.
+	ctx    context.Context
+	cancel context.CancelFunc
+
 	log log.Logger
 	lc  lifecycle.Lifecycle
 }
 
 func newDeploymentManager(s *service, lease mtypes.LeaseID, mgroup *manifest.Group) *deploymentManager {
 	log := s.log.With("cmp", "deployment-manager", "lease", lease, "manifest-group", mgroup.Name)
+	ctx, cancel := context.WithCancel(context.Background())
 
 	dm := &deploymentManager{
+		config:     s.config,
 		bus:        s.bus,
 		client:     s.client,
 		session:    s.session,
@@ -56,6 +64,8 @@ func newDeploymentManager(s *service, lease mtypes.LeaseID, mgroup *manifest.Gro
 		wg:         sync.WaitGroup{},
 		updatech:   make(chan *manifest.Group),
 		teardownch: make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
 		log:        log,
 		lc:         lifecycle.New(),
 	}
@@ -159,6 +169,8 @@ loop:
 		}
 	}
 
+	dm.cancel()
+
 	if runch != nil {
 		<-runch
 	}
@@ -194,11 +206,11 @@ func (dm *deploymentManager) startTeardown() <-chan error {
 }
 
 func (dm *deploymentManager) doDeploy() error {
-	return dm.client.Deploy(dm.lease, dm.mgroup)
+	return dm.client.Deploy(dm.ctx, dm.lease, dm.mgroup)
 }
 
 func (dm *deploymentManager) doTeardown() error {
-	return dm.client.TeardownLease(dm.lease)
+	return dm.client.TeardownLease(dm.ctx, dm.lease)
 }
 
 func (dm *deploymentManager) do(fn func() error) <-chan error {
@@ -21,6 +21,9 @@ const (
 
 	monitorHealthcheckPeriodMin    = time.Second * 10
 	monitorHealthcheckPeriodJitter = time.Second * 5
+
+	// must be comfortably below the on-chain lease heartbeat threshold.
+	monitorHeartbeatPeriod = time.Minute * 10
 )
 
 type deploymentMonitor struct {
@@ -31,20 +34,23 @@ type deploymentMonitor struct {
 	lease  mtypes.LeaseID
 	mgroup *manifest.Group
 
-	attempts int
-	log      log.Logger
-	lc       lifecycle.Lifecycle
+	attempts      int
+	lastHeartbeat time.Time
+	restarts      *restartTracker
+	log           log.Logger
+	lc            lifecycle.Lifecycle
 }
 
 func newDeploymentMonitor(dm *deploymentManager) *deploymentMonitor {
 	m := &deploymentMonitor{
-		bus:     dm.bus,
-		session: dm.session,
-		client:  dm.client,
-		lease:   dm.lease,
-		mgroup:  dm.mgroup,
-		log:     dm.log.With("cmp", "deployment-monitor"),
-		lc:      lifecycle.New(),
+		bus:      dm.bus,
+		session:  dm.session,
+		client:   dm.client,
+		lease:    dm.lease,
+		mgroup:   dm.mgroup,
+		restarts: newRestartTracker(dm.config),
+		log:      dm.log.With("cmp", "deployment-monitor"),
+		lc:       lifecycle.New(),
 	}
 
 	go m.lc.WatchChannel(dm.lc.ShuttingDown())
@@ -65,8 +71,9 @@ func (m *deploymentMonitor) run() {
 	defer m.lc.ShutdownCompleted()
 
 	var (
-		runch   <-chan runner.Result
-		closech <-chan runner.Result
+		runch       <-chan runner.Result
+		closech     <-chan runner.Result
+		heartbeatch <-chan runner.Result
 	)
 
 	tickch := m.scheduleRetry()
@@ -90,15 +97,36 @@ loop:
 				m.log.Error("monitor check", "err", err)
 			}
 
-			ok := result.Value().(bool)
+			check := result.Value().(monitorCheckResult)
+			ok := check.ok
 
 			m.log.Info("check result", "ok", ok, "attempt", m.attempts)
 
+			restarts := restartStateHealthy
+			if result.Error() == nil {
+				restarts = m.restarts.observe(time.Now(), check.restarts)
+			}
+
+			if restarts == restartStateClose {
+				m.log.Error("deployment crash looping.  closing lease.", "restarts", check.restarts)
+				m.publishStatus(event.ClusterDeploymentDegraded)
+				closech = m.runCloseLease("crash-loop")
+				break
+			}
+
 			if ok {
 				// healthy
 				m.attempts = 0
 				tickch = m.scheduleHealthcheck()
-				m.publishStatus(event.ClusterDeploymentDeployed)
+				if restarts == restartStateDegraded {
+					m.log.Error("deployment crash looping", "restarts", check.restarts)
+					m.publishStatus(event.ClusterDeploymentDegraded)
+				} else {
+					m.publishStatus(event.ClusterDeploymentDeployed)
+				}
+				if heartbeatch == nil && time.Since(m.lastHeartbeat) >= monitorHeartbeatPeriod {
+					heartbeatch = m.runHeartbeat()
+				}
 				break
 			}
 
@@ -111,10 +139,16 @@ loop:
 			}
 
 			m.log.Error("deployment failed.  closing lease.")
-			closech = m.runCloseLease()
+			closech = m.runCloseLease("deployment-failed")
 
 		case <-closech:
 			closech = nil
+
+		case result := <-heartbeatch:
+			heartbeatch = nil
+			if result.Error() == nil {
+				m.lastHeartbeat = time.Now()
+			}
 		}
 	}
 
@@ -125,6 +159,10 @@ loop:
 	if closech != nil {
 		<-closech
 	}
+
+	if heartbeatch != nil {
+		<-heartbeatch
+	}
 }
 
 func (m *deploymentMonitor) runCheck() <-chan runner.Result {
@@ -135,15 +173,21 @@ func (m *deploymentMonitor) runCheck() <-chan runner.Result {
 	})
 }
 
-func (m *deploymentMonitor) doCheck() (bool, error) {
+type monitorCheckResult struct {
+	ok       bool
+	restarts int32
+}
+
+func (m *deploymentMonitor) doCheck() (monitorCheckResult, error) {
 	status, err := m.client.LeaseStatus(m.lease)
 
 	if err != nil {
 		m.log.Error("lease status", "err", err)
-		return false, err
+		return monitorCheckResult{}, err
 	}
 
 	badsvc := 0
+	restarts := int32(0)
 
 	for _, spec := range m.mgroup.Services {
 		found := false
@@ -152,6 +196,7 @@ func (m *deploymentMonitor) doCheck() (bool, error) {
 				continue
 			}
 			found = true
+			restarts += svc.Restarts
 
 			if uint32(svc.Available) < spec.Count {
 				badsvc++
@@ -169,19 +214,31 @@ func (m *deploymentMonitor) doCheck() (bool, error) {
 		}
 	}
 
-	return badsvc == 0, nil
+	return monitorCheckResult{ok: badsvc == 0, restarts: restarts}, nil
 }
 
-func (m *deploymentMonitor) runCloseLease() <-chan runner.Result {
+func (m *deploymentMonitor) runCloseLease(reason string) <-chan runner.Result {
 	return runner.Do(func() runner.Result {
 		// TODO: retry
 		err := m.session.Client().Tx().Broadcast(mtypes.MsgCloseBid{
 			BidID: m.lease.BidID(),
 		})
 		if err != nil {
-			m.log.Error("closing deployment", "err", err)
+			m.log.Error("closing deployment", "err", err, "reason", reason)
 		} else {
-			m.log.Info("lease closed")
+			m.log.Info("lease closed", "reason", reason)
+		}
+		return runner.NewResult(nil, err)
+	})
+}
+
+func (m *deploymentMonitor) runHeartbeat() <-chan runner.Result {
+	return runner.Do(func() runner.Result {
+		err := m.session.Client().Tx().Broadcast(mtypes.MsgLeaseHeartbeat{
+			LeaseID: m.lease,
+		})
+		if err != nil {
+			m.log.Error("sending lease heartbeat", "err", err)
 		}
 		return runner.NewResult(nil, err)
 	})
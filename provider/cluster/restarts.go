@@ -0,0 +1,65 @@
+package cluster
+
+import "time"
+
+type restartState string
+
+const (
+	restartStateHealthy  restartState = "healthy"
+	restartStateDegraded restartState = "degraded"
+	restartStateClose    restartState = "close"
+)
+
+type restartSample struct {
+	at       time.Time
+	restarts int32
+}
+
+// restartTracker watches the container restart count of a lease and
+// reports when it crosses the configured crash-loop threshold.
+type restartTracker struct {
+	threshold  uint32
+	window     time.Duration
+	closeLease bool
+
+	samples []restartSample
+}
+
+func newRestartTracker(config config) *restartTracker {
+	return &restartTracker{
+		threshold:  config.MonitorRestartThreshold,
+		window:     config.MonitorRestartWindow,
+		closeLease: config.MonitorRestartCloseLease,
+	}
+}
+
+// observe records the total restart count seen at the given time and returns
+// the state of the lease.
+func (t *restartTracker) observe(at time.Time, restarts int32) restartState {
+	if t.threshold == 0 {
+		return restartStateHealthy
+	}
+
+	// pods were replaced; counts start over.
+	if len(t.samples) > 0 && restarts < t.samples[len(t.samples)-1].restarts {
+		t.samples = nil
+	}
+
+	t.samples = append(t.samples, restartSample{at: at, restarts: restarts})
+
+	// keep the newest sample at or before the window start as the baseline.
+	start := at.Add(-t.window)
+	idx := 0
+	for idx < len(t.samples)-1 && !t.samples[idx+1].at.After(start) {
+		idx++
+	}
+	t.samples = t.samples[idx:]
+
+	if uint32(restarts-t.samples[0].restarts) < t.threshold {
+		return restartStateHealthy
+	}
+	if t.closeLease {
+		return restartStateClose
+	}
+	return restartStateDegraded
+}
@@ -0,0 +1,53 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestartTracker(t *testing.T) {
+	start := time.Now()
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	// disabled by default
+	tracker := newRestartTracker(config{})
+	assert.Equal(t, restartStateHealthy, tracker.observe(at(0), 0))
+	assert.Equal(t, restartStateHealthy, tracker.observe(at(1), 100))
+
+	tracker = newRestartTracker(config{
+		MonitorRestartThreshold: 5,
+		MonitorRestartWindow:    10 * time.Minute,
+	})
+
+	// restarts before monitoring began don't count
+	assert.Equal(t, restartStateHealthy, tracker.observe(at(0), 20))
+
+	// slow restarts stay below the threshold within any window
+	assert.Equal(t, restartStateHealthy, tracker.observe(at(10), 22))
+	assert.Equal(t, restartStateHealthy, tracker.observe(at(20), 24))
+
+	// crash loop
+	assert.Equal(t, restartStateHealthy, tracker.observe(at(21), 26))
+	assert.Equal(t, restartStateDegraded, tracker.observe(at(22), 29))
+
+	// stabilizes once the window passes
+	assert.Equal(t, restartStateDegraded, tracker.observe(at(30), 29))
+	assert.Equal(t, restartStateHealthy, tracker.observe(at(33), 29))
+
+	// pods replaced
+	assert.Equal(t, restartStateHealthy, tracker.observe(at(34), 0))
+	assert.Equal(t, restartStateDegraded, tracker.observe(at(35), 5))
+
+	tracker = newRestartTracker(config{
+		MonitorRestartThreshold:  3,
+		MonitorRestartWindow:     5 * time.Minute,
+		MonitorRestartCloseLease: true,
+	})
+	assert.Equal(t, restartStateHealthy, tracker.observe(at(0), 0))
+	assert.Equal(t, restartStateHealthy, tracker.observe(at(1), 2))
+	assert.Equal(t, restartStateClose, tracker.observe(at(2), 4))
+}
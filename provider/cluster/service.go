@@ -64,6 +64,7 @@ func NewService(ctx context.Context, session session.Session, bus pubsub.Bus, cl
 	}
 
 	s := &service{
+		config:    config,
 		session:   session,
 		client:    client,
 		bus:       bus,
@@ -83,6 +84,7 @@ func NewService(ctx context.Context, session session.Session, bus pubsub.Bus, cl
 }
 
 type service struct {
+	config  config
 	session session.Session
 	client  Client
 	bus     pubsub.Bus
@@ -1,6 +1,10 @@
 package cluster
 
-import atypes "github.com/ovrclk/akash/types"
+import (
+	"time"
+
+	atypes "github.com/ovrclk/akash/types"
+)
 
 type Status struct {
 	Leases    uint32
@@ -11,6 +15,9 @@ type InventoryStatus struct {
 	Active    []atypes.Unit
 	Pending   []atypes.Unit
 	Available []atypes.Unit
+
+	// Time of the last successful inventory fetch from the cluster.
+	LastReconcile time.Time
 }
 
 type ServiceStatus struct {
@@ -19,6 +26,12 @@ type ServiceStatus struct {
 	Total     int32
 	URIs      []string
 
+	// Container restarts across the service's current pods.
+	Restarts int32
+
+	// Ports of NodePort and LoadBalancer services reachable on the nodes.
+	NodePorts []ForwardedPort
+
 	ObservedGeneration int64
 	Replicas           int32
 	UpdatedReplicas    int32
@@ -26,6 +39,12 @@ type ServiceStatus struct {
 	AvailableReplicas  int32
 }
 
+// ForwardedPort is a service port forwarded from NodePort on every node.
+type ForwardedPort struct {
+	Port     uint32
+	NodePort uint32
+}
+
 type LeaseStatus struct {
 	Services []*ServiceStatus
 }
@@ -34,6 +34,7 @@ type ClusterDeploymentStatus string
 const (
 	ClusterDeploymentPending  ClusterDeploymentStatus = "pending"
 	ClusterDeploymentDeployed ClusterDeploymentStatus = "deployed"
+	ClusterDeploymentDegraded ClusterDeploymentStatus = "degraded"
 )
 
 type ClusterDeployment struct {
@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ovrclk/akash/provider/bidengine"
+	"github.com/ovrclk/akash/provider/cluster"
+)
+
+const (
+	// inventory fetches older than this mark the cluster as out of sync.
+	healthReconcileMaxAge = time.Minute
+
+	healthCheckCluster   = "cluster"
+	healthCheckCRD       = "manifest-crd"
+	healthCheckBidengine = "bidengine"
+	healthCheckReconcile = "reconcile"
+)
+
+var errNoReconcile = errors.New("cluster inventory never fetched")
+
+type HealthClient interface {
+	Health(context.Context) *Health
+}
+
+type HealthCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Health summarizes the state of a running provider.  OK is false if any
+// check failed.
+type Health struct {
+	OK            bool          `json:"ok"`
+	Checks        []HealthCheck `json:"checks"`
+	LastReconcile time.Time     `json:"last-reconcile"`
+	ActiveLeases  uint32        `json:"active-leases"`
+}
+
+func (h *Health) add(name string, err error) {
+	check := HealthCheck{Name: name, OK: err == nil}
+	if err != nil {
+		check.Error = err.Error()
+		h.OK = false
+	}
+	h.Checks = append(h.Checks, check)
+}
+
+// implemented by cluster clients that depend on the manifest CRD.
+type manifestCRDChecker interface {
+	CheckManifestCRD() error
+}
+
+func checkHealth(ctx context.Context, cclient cluster.Client, cstatus cluster.StatusClient, bengine bidengine.Service) *Health {
+	health := &Health{OK: true}
+
+	if cclient != nil {
+		_, err := cclient.Inventory()
+		health.add(healthCheckCluster, err)
+
+		if checker, ok := cclient.(manifestCRDChecker); ok {
+			health.add(healthCheckCRD, checker.CheckManifestCRD())
+		}
+	}
+
+	select {
+	case <-bengine.Done():
+		health.add(healthCheckBidengine, bidengine.ErrNotRunning)
+	default:
+		_, err := bengine.Status(ctx)
+		health.add(healthCheckBidengine, err)
+	}
+
+	status, err := cstatus.Status(ctx)
+	if err == nil {
+		health.ActiveLeases = status.Leases
+		health.LastReconcile = status.Inventory.LastReconcile
+		switch {
+		case health.LastReconcile.IsZero():
+			err = errNoReconcile
+		case time.Since(health.LastReconcile) > healthReconcileMaxAge:
+			err = fmt.Errorf("cluster inventory last fetched at %v", health.LastReconcile.Format(time.RFC3339))
+		}
+	}
+	health.add(healthCheckReconcile, err)
+
+	return health
+}
+
+// HealthHandler serves the provider's health as JSON, responding with
+// 503 Service Unavailable when degraded.
+func HealthHandler(client HealthClient) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := client.Health(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		if !health.OK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(health)
+	})
+}
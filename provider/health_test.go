@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ovrclk/akash/provider/bidengine"
+	"github.com/ovrclk/akash/provider/cluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth_healthy(t *testing.T) {
+	reconciled := time.Now().Add(-time.Second)
+
+	hc := &testHealthClient{
+		cclient: &testHealthClusterClient{},
+		cstatus: &testHealthClusterStatus{status: &cluster.Status{
+			Leases:    3,
+			Inventory: cluster.InventoryStatus{LastReconcile: reconciled},
+		}},
+		bengine: newTestHealthBidengine(),
+	}
+
+	health, code := requestHealth(t, hc)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, health.OK)
+	assert.Equal(t, uint32(3), health.ActiveLeases)
+	assert.True(t, reconciled.Equal(health.LastReconcile))
+
+	require.Len(t, health.Checks, 4)
+	for _, check := range health.Checks {
+		assert.True(t, check.OK, check.Name)
+		assert.Empty(t, check.Error, check.Name)
+	}
+}
+
+func TestHealth_degraded(t *testing.T) {
+	bengine := newTestHealthBidengine()
+	close(bengine.done)
+
+	hc := &testHealthClient{
+		cclient: &testHealthClusterClient{crdErr: errors.New("crd missing")},
+		cstatus: &testHealthClusterStatus{status: &cluster.Status{
+			Leases:    1,
+			Inventory: cluster.InventoryStatus{LastReconcile: time.Now().Add(-2 * healthReconcileMaxAge)},
+		}},
+		bengine: bengine,
+	}
+
+	health, code := requestHealth(t, hc)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, health.OK)
+	assert.Equal(t, uint32(1), health.ActiveLeases)
+
+	checks := make(map[string]HealthCheck)
+	for _, check := range health.Checks {
+		checks[check.Name] = check
+	}
+	assert.True(t, checks[healthCheckCluster].OK)
+	assert.False(t, checks[healthCheckCRD].OK)
+	assert.Equal(t, "crd missing", checks[healthCheckCRD].Error)
+	assert.False(t, checks[healthCheckBidengine].OK)
+	assert.Equal(t, bidengine.ErrNotRunning.Error(), checks[healthCheckBidengine].Error)
+	assert.False(t, checks[healthCheckReconcile].OK)
+
+	// cluster unreachable and never reconciled
+	hc.cclient = &testHealthClusterClient{inventoryErr: errors.New("connection refused")}
+	hc.cstatus = &testHealthClusterStatus{status: &cluster.Status{}}
+	hc.bengine = newTestHealthBidengine()
+
+	health, code = requestHealth(t, hc)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, health.OK)
+	for _, check := range health.Checks {
+		switch check.Name {
+		case healthCheckCluster:
+			assert.Equal(t, "connection refused", check.Error)
+		case healthCheckReconcile:
+			assert.Equal(t, errNoReconcile.Error(), check.Error)
+		default:
+			assert.True(t, check.OK, check.Name)
+		}
+	}
+}
+
+func requestHealth(t *testing.T, client HealthClient) (Health, int) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	HealthHandler(client).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	var health Health
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&health))
+	return health, rec.Code
+}
+
+type testHealthClient struct {
+	cclient cluster.Client
+	cstatus cluster.StatusClient
+	bengine bidengine.Service
+}
+
+func (c *testHealthClient) Health(ctx context.Context) *Health {
+	return checkHealth(ctx, c.cclient, c.cstatus, c.bengine)
+}
+
+type testHealthClusterClient struct {
+	cluster.Client
+	inventoryErr error
+	crdErr       error
+}
+
+func (c *testHealthClusterClient) Inventory() ([]cluster.Node, error) {
+	return nil, c.inventoryErr
+}
+
+func (c *testHealthClusterClient) CheckManifestCRD() error {
+	return c.crdErr
+}
+
+type testHealthClusterStatus struct {
+	status *cluster.Status
+	err    error
+}
+
+func (c *testHealthClusterStatus) Status(context.Context) (*cluster.Status, error) {
+	return c.status, c.err
+}
+
+type testHealthBidengine struct {
+	done chan struct{}
+}
+
+func newTestHealthBidengine() *testHealthBidengine {
+	return &testHealthBidengine{done: make(chan struct{})}
+}
+
+func (b *testHealthBidengine) Status(context.Context) (*bidengine.Status, error) {
+	return &bidengine.Status{}, nil
+}
+
+func (b *testHealthBidengine) Close() error {
+	return nil
+}
+
+func (b *testHealthBidengine) Done() <-chan struct{} {
+	return b.done
+}
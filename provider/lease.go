@@ -0,0 +1,183 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ovrclk/akash/provider/cluster"
+	dquery "github.com/ovrclk/akash/x/deployment/query"
+	mquery "github.com/ovrclk/akash/x/market/query"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/tendermint/tendermint/crypto"
+	cryptoamino "github.com/tendermint/tendermint/crypto/encoding/amino"
+)
+
+const (
+	// LeasePrefix is the gateway path prefix serving tenant requests about
+	// their leases.
+	LeasePrefix = "/lease/"
+
+	// headers carrying the tenant's proof of lease ownership.  The
+	// signature is over LeaseSignBytes.
+	LeasePubKeyHeader    = "X-Akash-Pubkey"
+	LeaseSignatureHeader = "X-Akash-Signature"
+	LeaseTimestampHeader = "X-Akash-Timestamp"
+
+	// LeaseSignatureMaxAge is how far the signed timestamp of a lease request
+	// may be from the provider's clock.
+	LeaseSignatureMaxAge = time.Minute
+)
+
+var (
+	errLeaseSignature = errors.New("invalid lease owner signature")
+	errLeaseNotOwner  = errors.New("signer does not own lease")
+	errLeaseStale     = errors.New("lease request timestamp out of range")
+)
+
+// LeaseSigner signs msg with the lease owner's key.
+type LeaseSigner func(msg []byte) ([]byte, crypto.PubKey, error)
+
+// LeaseSignBytes returns the bytes a tenant signs to make a request about a
+// lease: the lease, including its provider, the request path and query, and
+// the unix time the request was made.  A signature is only good for the
+// request it was made for, and only for LeaseSignatureMaxAge.
+func LeaseSignBytes(lid mtypes.LeaseID, path string, query url.Values, timestamp int64) []byte {
+	return []byte(fmt.Sprintf("%s\n%s\n%s\n%d",
+		mquery.LeasePath(lid), path, query.Encode(), timestamp))
+}
+
+// LeaseHandler serves the requests of lease owners for leases held by
+// provider: logs (see LeaseLogsHandler) and status (see LeaseStatusHandler).
+func LeaseHandler(provider sdk.AccAddress, cclient cluster.Client) http.Handler {
+	logs := LeaseLogsHandler(provider, cclient)
+	status := LeaseStatusHandler(provider, cclient)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, leaseLogsSuffix):
+			logs.ServeHTTP(w, r)
+		case strings.HasSuffix(r.URL.Path, leaseStatusSuffix):
+			status.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// NewLeaseRequest returns a GET request for path on the provider gateway
+// about lid, signed by sign.
+func NewLeaseRequest(ctx context.Context, gateway *url.URL, lid mtypes.LeaseID, path string,
+	query url.Values, sign LeaseSigner) (*http.Request, error) {
+	uri := *gateway
+	uri.Path = path
+	uri.RawQuery = query.Encode()
+
+	timestamp := time.Now().Unix()
+	sig, pubkey, err := sign(LeaseSignBytes(lid, path, query, timestamp))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(LeasePubKeyHeader, base64.StdEncoding.EncodeToString(pubkey.Bytes()))
+	req.Header.Set(LeaseSignatureHeader, base64.StdEncoding.EncodeToString(sig))
+	req.Header.Set(LeaseTimestampHeader, strconv.FormatInt(timestamp, 10))
+	return req, nil
+}
+
+// leasePath returns the gateway path of the given lease with suffix.
+func leasePath(lid mtypes.LeaseID, suffix string) string {
+	return fmt.Sprintf("%s%s/%v/%v/%v%s",
+		LeasePrefix, lid.Owner, lid.DSeq, lid.GSeq, lid.OSeq, suffix)
+}
+
+func parseLeasePath(provider sdk.AccAddress, path, suffix string) (mtypes.LeaseID, error) {
+	if !strings.HasPrefix(path, LeasePrefix) || !strings.HasSuffix(path, suffix) {
+		return mtypes.LeaseID{}, fmt.Errorf("invalid path")
+	}
+	path = strings.TrimSuffix(strings.TrimPrefix(path, LeasePrefix), suffix)
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 4 {
+		return mtypes.LeaseID{}, fmt.Errorf("invalid path")
+	}
+
+	gid, err := dquery.ParseGroupPath(parts[0:3])
+	if err != nil {
+		return mtypes.LeaseID{}, err
+	}
+
+	oseq, err := strconv.ParseUint(parts[3], 10, 32)
+	if err != nil {
+		return mtypes.LeaseID{}, err
+	}
+
+	oid := mtypes.MakeOrderID(gid, uint32(oseq))
+	return mtypes.MakeBidID(oid, provider).LeaseID(), nil
+}
+
+// authorizeLease parses the lease from the request path and checks that the
+// request is signed by its owner, writing an error response if not.
+func authorizeLease(w http.ResponseWriter, r *http.Request,
+	provider sdk.AccAddress, suffix string) (mtypes.LeaseID, bool) {
+	lid, err := parseLeasePath(provider, r.URL.Path, suffix)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return lid, false
+	}
+
+	if err := verifyLeaseOwner(r, lid, time.Now()); err != nil {
+		code := http.StatusUnauthorized
+		if err == errLeaseNotOwner {
+			code = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), code)
+		return lid, false
+	}
+
+	return lid, true
+}
+
+// verifyLeaseOwner checks that the request was signed by the owner of lid
+// within LeaseSignatureMaxAge of now.
+func verifyLeaseOwner(r *http.Request, lid mtypes.LeaseID, now time.Time) error {
+	timestamp, err := strconv.ParseInt(r.Header.Get(LeaseTimestampHeader), 10, 64)
+	if err != nil {
+		return errLeaseSignature
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > LeaseSignatureMaxAge || age < -LeaseSignatureMaxAge {
+		return errLeaseStale
+	}
+
+	pkbuf, err := base64.StdEncoding.DecodeString(r.Header.Get(LeasePubKeyHeader))
+	if err != nil || len(pkbuf) == 0 {
+		return errLeaseSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get(LeaseSignatureHeader))
+	if err != nil || len(sig) == 0 {
+		return errLeaseSignature
+	}
+
+	pubkey, err := cryptoamino.PubKeyFromBytes(pkbuf)
+	if err != nil {
+		return errLeaseSignature
+	}
+	if !pubkey.VerifyBytes(LeaseSignBytes(lid, r.URL.Path, r.URL.Query(), timestamp), sig) {
+		return errLeaseSignature
+	}
+	if !sdk.AccAddress(pubkey.Address()).Equals(lid.Owner) {
+		return errLeaseNotOwner
+	}
+	return nil
+}
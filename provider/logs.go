@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ovrclk/akash/provider/cluster"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+)
+
+const leaseLogsSuffix = "/logs"
+
+// LeaseLogsPath returns the gateway path for the logs of the given lease.
+func LeaseLogsPath(lid mtypes.LeaseID) string {
+	return leasePath(lid, leaseLogsSuffix)
+}
+
+// LeaseLogsHandler streams the pod logs of a lease held by provider to its
+// owner.  Query parameters "follow", "since" (a duration) and "tail" (a line
+// count) are passed through to the cluster.
+func LeaseLogsHandler(provider sdk.AccAddress, cclient cluster.Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lid, ok := authorizeLease(w, r, provider, leaseLogsSuffix)
+		if !ok {
+			return
+		}
+
+		follow, tail, since, err := parseLeaseLogsQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := cclient.LeaseStatus(lid); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		logs, err := cclient.ServiceLogs(r.Context(), lid, tail, follow, since)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		streamLeaseLogs(r.Context(), w, logs)
+	})
+}
+
+func parseLeaseLogsQuery(r *http.Request) (follow bool, tail int64, since time.Duration, err error) {
+	query := r.URL.Query()
+
+	if val := query.Get("follow"); val != "" {
+		if follow, err = strconv.ParseBool(val); err != nil {
+			return
+		}
+	}
+	if val := query.Get("tail"); val != "" {
+		if tail, err = strconv.ParseInt(val, 10, 64); err != nil {
+			return
+		}
+	}
+	if val := query.Get("since"); val != "" {
+		if since, err = time.ParseDuration(val); err != nil {
+			return
+		}
+	}
+	return
+}
+
+type leaseLogLine struct {
+	name string
+	text string
+}
+
+// streamLeaseLogs writes the lines of all logs, prefixed by pod name, until
+// every log ends or ctx is done.
+func streamLeaseLogs(ctx context.Context, w http.ResponseWriter, logs []*cluster.ServiceLog) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	linech := make(chan leaseLogLine)
+
+	var wg sync.WaitGroup
+	for _, log := range logs {
+		wg.Add(1)
+		go func(log *cluster.ServiceLog) {
+			defer wg.Done()
+			for log.Scanner.Scan() {
+				select {
+				case linech <- leaseLogLine{name: log.Name, text: log.Scanner.Text()}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(log)
+	}
+
+	donech := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(donech)
+	}()
+
+	defer func() {
+		// unblock scanners waiting on follow streams.
+		for _, log := range logs {
+			log.Stream.Close()
+		}
+	}()
+
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-donech:
+			return
+		case line := <-linech:
+			if _, err := fmt.Fprintf(w, "[%s] %s\n", line.name, line.text); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ovrclk/akash/provider/cluster"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+)
+
+func TestLeaseLogsHandler(t *testing.T) {
+	owner := ed25519.GenPrivKey()
+	provider := sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+
+	lid := mtypes.LeaseID{
+		Owner:    sdk.AccAddress(owner.PubKey().Address()),
+		DSeq:     1,
+		GSeq:     2,
+		OSeq:     3,
+		Provider: provider,
+	}
+
+	cclient := &testLogsClusterClient{
+		logs: map[string]string{
+			"web-0": "line one\nline two\n",
+		},
+	}
+	handler := LeaseLogsHandler(provider, cclient)
+
+	signed := func(key crypto.PrivKey, query string, signedQuery string, at time.Time) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, LeaseLogsPath(lid)+query, nil)
+		if key != nil {
+			values, err := url.ParseQuery(strings.TrimPrefix(signedQuery, "?"))
+			require.NoError(t, err)
+			sig, err := key.Sign(LeaseSignBytes(lid, LeaseLogsPath(lid), values, at.Unix()))
+			require.NoError(t, err)
+			req.Header.Set(LeasePubKeyHeader, base64.StdEncoding.EncodeToString(key.PubKey().Bytes()))
+			req.Header.Set(LeaseSignatureHeader, base64.StdEncoding.EncodeToString(sig))
+			req.Header.Set(LeaseTimestampHeader, strconv.FormatInt(at.Unix(), 10))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	request := func(key crypto.PrivKey, query string) *httptest.ResponseRecorder {
+		return signed(key, query, query, time.Now())
+	}
+
+	// unsigned
+	rec := request(nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// signed by someone other than the owner
+	rec = request(ed25519.GenPrivKey(), "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Nil(t, cclient.lid)
+
+	// stale signature
+	rec = signed(owner, "", "", time.Now().Add(-2*LeaseSignatureMaxAge))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Nil(t, cclient.lid)
+
+	// signature for a different request
+	rec = signed(owner, "?tail=10", "?tail=1", time.Now())
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Nil(t, cclient.lid)
+
+	// owner
+	rec = request(owner, "?follow=true&since=5m&tail=10")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "[web-0] line one\n[web-0] line two\n", rec.Body.String())
+
+	require.NotNil(t, cclient.lid)
+	assert.True(t, lid.Equals(*cclient.lid))
+	assert.True(t, cclient.follow)
+	assert.Equal(t, 5*time.Minute, cclient.since)
+	assert.Equal(t, int64(10), cclient.tail)
+
+	rec = request(owner, "?since=soon")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func testLeaseSigner(key crypto.PrivKey) LeaseSigner {
+	return func(msg []byte) ([]byte, crypto.PubKey, error) {
+		sig, err := key.Sign(msg)
+		return sig, key.PubKey(), err
+	}
+}
+
+type testLogsClusterClient struct {
+	cluster.Client
+	logs map[string]string
+
+	lid    *mtypes.LeaseID
+	tail   int64
+	follow bool
+	since  time.Duration
+}
+
+func (c *testLogsClusterClient) LeaseStatus(mtypes.LeaseID) (*cluster.LeaseStatus, error) {
+	return &cluster.LeaseStatus{}, nil
+}
+
+func (c *testLogsClusterClient) ServiceLogs(_ context.Context, lid mtypes.LeaseID,
+	tail int64, follow bool, since time.Duration) ([]*cluster.ServiceLog, error) {
+	c.lid, c.tail, c.follow, c.since = &lid, tail, follow, since
+
+	var logs []*cluster.ServiceLog
+	for name, text := range c.logs {
+		logs = append(logs, cluster.NewServiceLog(name, ioutil.NopCloser(strings.NewReader(text))))
+	}
+	return logs, nil
+}
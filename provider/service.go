@@ -20,6 +20,7 @@ type Service interface {
 	Done() <-chan struct{}
 
 	StatusClient
+	HealthClient
 }
 
 type StatusClient interface {
@@ -72,6 +73,7 @@ func NewService(ctx context.Context, session session.Session, bus pubsub.Bus, cc
 
 	service := &service{
 		session:   session,
+		cclient:   cclient,
 		bus:       bus,
 		cluster:   cluster,
 		bidengine: bidengine,
@@ -90,6 +92,7 @@ func NewService(ctx context.Context, session session.Session, bus pubsub.Bus, cc
 type service struct {
 	session session.Session
 	bus     pubsub.Bus
+	cclient cluster.Client
 
 	cluster   cluster.Service
 	bidengine bidengine.Service
@@ -133,6 +136,10 @@ func (s *service) Status(ctx context.Context) (*Status, error) {
 	}, nil
 }
 
+func (s *service) Health(ctx context.Context) *Health {
+	return checkHealth(ctx, s.cclient, s.cluster, s.bidengine)
+}
+
 func (s *service) run() {
 	defer s.lc.ShutdownCompleted()
 
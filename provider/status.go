@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ovrclk/akash/provider/cluster"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+)
+
+const leaseStatusSuffix = "/status"
+
+// LeaseStatusPath returns the gateway path for the status of the given lease.
+func LeaseStatusPath(lid mtypes.LeaseID) string {
+	return leasePath(lid, leaseStatusSuffix)
+}
+
+// LeaseStatusHandler returns the cluster status of a lease held by provider
+// to its owner as JSON.
+func LeaseStatusHandler(provider sdk.AccAddress, cclient cluster.Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lid, ok := authorizeLease(w, r, provider, leaseStatusSuffix)
+		if !ok {
+			return
+		}
+
+		status, err := cclient.LeaseStatus(lid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// FetchLeaseStatus requests the status of a lease from the provider gateway,
+// proving ownership with a request signed by sign.
+func FetchLeaseStatus(ctx context.Context, gateway *url.URL, lid mtypes.LeaseID,
+	sign LeaseSigner) (*cluster.LeaseStatus, error) {
+	req, err := NewLeaseRequest(ctx, gateway, lid, LeaseStatusPath(lid), url.Values{}, sign)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%v: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	status := &cluster.LeaseStatus{}
+	if err := json.NewDecoder(resp.Body).Decode(status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
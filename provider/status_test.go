@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ovrclk/akash/provider/cluster"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+)
+
+func TestFetchLeaseStatus(t *testing.T) {
+	owner := ed25519.GenPrivKey()
+	provider := sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+
+	lid := mtypes.LeaseID{
+		Owner:    sdk.AccAddress(owner.PubKey().Address()),
+		DSeq:     1,
+		GSeq:     2,
+		OSeq:     3,
+		Provider: provider,
+	}
+
+	status := &cluster.LeaseStatus{
+		Services: []*cluster.ServiceStatus{
+			{
+				Name:          "web",
+				Available:     1,
+				Total:         2,
+				URIs:          []string{"web.example.com"},
+				Restarts:      3,
+				ReadyReplicas: 1,
+			},
+		},
+	}
+
+	server := httptest.NewServer(LeaseHandler(provider, &testStatusClusterClient{status: status}))
+	defer server.Close()
+
+	gateway, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	result, err := FetchLeaseStatus(context.Background(), gateway, lid, testLeaseSigner(owner))
+	require.NoError(t, err)
+	assert.Equal(t, status, result)
+
+	// signed by someone other than the owner
+	_, err = FetchLeaseStatus(context.Background(), gateway, lid, testLeaseSigner(ed25519.GenPrivKey()))
+	assert.Error(t, err)
+}
+
+type testStatusClusterClient struct {
+	cluster.Client
+	status *cluster.LeaseStatus
+}
+
+func (c *testStatusClusterClient) LeaseStatus(mtypes.LeaseID) (*cluster.LeaseStatus, error) {
+	return c.status, nil
+}
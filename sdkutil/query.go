@@ -1,6 +1,8 @@
 package sdkutil
 
 import (
+	"context"
+
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
 )
@@ -12,3 +14,26 @@ func RenderQueryResponse(cdc *codec.Codec, obj interface{}) ([]byte, *sdkerrors.
 	}
 	return response, nil
 }
+
+// RenderQueryResponseCtx is RenderQueryResponse bounded by ctx.  If ctx is
+// done before marshaling completes the context error is returned; the
+// marshaling goroutine is left to finish in the background.
+func RenderQueryResponseCtx(ctx context.Context, cdc *codec.Codec, obj interface{}) ([]byte, *sdkerrors.Error) {
+	type result struct {
+		buf []byte
+		err *sdkerrors.Error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		buf, err := RenderQueryResponse(cdc, obj)
+		ch <- result{buf: buf, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, sdkerrors.New("sdkutil", 2, ctx.Err().Error())
+	case res := <-ch:
+		return res.buf, res.err
+	}
+}
@@ -0,0 +1,55 @@
+package sdkutil
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type slowObject struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (obj slowObject) MarshalJSON() ([]byte, error) {
+	close(obj.started)
+	<-obj.release
+	return json.Marshal("done")
+}
+
+func TestRenderQueryResponseCtx(t *testing.T) {
+	cdc := codec.New()
+
+	value := struct {
+		Name string `json:"name"`
+	}{"akash"}
+
+	buf, err := RenderQueryResponseCtx(context.Background(), cdc, value)
+	require.Nil(t, err)
+	assert.JSONEq(t, `{"name":"akash"}`, string(buf))
+
+	obj := slowObject{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(obj.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-obj.started
+		cancel()
+	}()
+
+	buf, err = RenderQueryResponseCtx(ctx, cdc, obj)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), context.Canceled.Error())
+	assert.Nil(t, buf)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = RenderQueryResponseCtx(ctx, cdc, slowObject{started: make(chan struct{}), release: obj.release})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), context.DeadlineExceeded.Error())
+}
@@ -28,10 +28,17 @@ type v1 struct {
 
 type v1Service struct {
 	Image        string
-	Args         []string       `yaml:",omitempty"`
-	Env          []string       `yaml:",omitempty"`
-	Expose       []v1Expose     `yaml:",omitempty"`
-	Dependencies []v1Dependency `yaml:",omitempty"`
+	Command      []string          `yaml:",omitempty"`
+	Args         []string          `yaml:",omitempty"`
+	Env          []string          `yaml:",omitempty"`
+	Annotations  map[string]string `yaml:",omitempty"`
+	Expose       []v1Expose        `yaml:",omitempty"`
+	Dependencies []v1Dependency    `yaml:",omitempty"`
+	Probe        *v1Probe          `yaml:",omitempty"`
+	// mount the compute profile's storage here, persisted across restarts
+	StorageMount string `yaml:"storage-mount,omitempty"`
+	// stable replica identities and ordered rollout
+	Stateful bool `yaml:",omitempty"`
 }
 
 type v1Expose struct {
@@ -40,6 +47,10 @@ type v1Expose struct {
 	Proto  string       `yaml:",omitempty"`
 	To     []v1ExposeTo `yaml:",omitempty"`
 	Accept v1Accept
+	// ClusterIP, NodePort or LoadBalancer
+	ServiceType string `yaml:"service-type,omitempty"`
+	// terminate TLS at the ingress
+	HTTPS bool `yaml:",omitempty"`
 }
 
 type v1Accept struct {
@@ -51,6 +62,16 @@ type v1ExposeTo struct {
 	Global  bool   `yaml:",omitempty"`
 }
 
+// v1Probe overrides the health check derived from the exposed ports.
+type v1Probe struct {
+	Path             string `yaml:",omitempty"`
+	Port             uint32 `yaml:",omitempty"`
+	InitialDelay     uint32 `yaml:"initial-delay,omitempty"`
+	Period           uint32 `yaml:",omitempty"`
+	Timeout          uint32 `yaml:",omitempty"`
+	FailureThreshold uint32 `yaml:"failure-threshold,omitempty"`
+}
+
 type v1Dependency struct {
 	Service string
 }
@@ -69,6 +90,14 @@ type v1ComputeProfile struct {
 type v1PlacementProfile struct {
 	Attributes map[string]string
 	Pricing    map[string]v1PricingProfile
+	// Lease length in blocks.  Zero for no fixed end.
+	Duration int64 `yaml:",omitempty"`
+	// Percentage (0-100) of bid selection weighted toward provider uptime.
+	UptimeWeight uint32 `yaml:"uptime-weight,omitempty"`
+	// Highest bid price accepted.  Defaults to the sum of the pricing.
+	MaxPrice *v1PricingProfile `yaml:"max-price,omitempty"`
+	// Bid selection: "lowest-bid" (the default) or "dutch".
+	PricingMode string `yaml:"pricing-mode,omitempty"`
 }
 
 // TODO: make coin parsing "just work".  wtf.
@@ -112,6 +141,17 @@ func (sdl *v1) Validate() error {
 	return nil
 }
 
+func v1PricingMode(mode string) (dtypes.PricingMode, error) {
+	switch mode {
+	case "", "lowest-bid":
+		return dtypes.PricingLowestBid, nil
+	case "dutch":
+		return dtypes.PricingDutch, nil
+	default:
+		return 0, fmt.Errorf("unknown pricing mode %q", mode)
+	}
+}
+
 func (sdl *v1) DeploymentGroups() ([]*dtypes.GroupSpec, error) {
 	groups := make(map[string]*dtypes.GroupSpec)
 
@@ -139,8 +179,20 @@ func (sdl *v1) DeploymentGroups() ([]*dtypes.GroupSpec, error) {
 			group := groups[placementName]
 
 			if group == nil {
+				mode, err := v1PricingMode(infra.PricingMode)
+				if err != nil {
+					return nil, fmt.Errorf("%v: %v", placementName, err)
+				}
+
 				group = &dtypes.GroupSpec{
-					Name: placementName,
+					Name:         placementName,
+					Duration:     infra.Duration,
+					UptimeWeight: infra.UptimeWeight,
+					PricingMode:  mode,
+				}
+
+				if infra.MaxPrice != nil {
+					group.MaxPrice = infra.MaxPrice.ToCoin()
 				}
 
 				for k, v := range infra.Attributes {
@@ -216,10 +268,14 @@ func (sdl *v1) Manifest() (manifest.Manifest, error) {
 			}
 
 			msvc := &manifest.Service{
-				Name:  svcName,
-				Image: svc.Image,
-				Args:  svc.Args,
-				Env:   svc.Env,
+				Name:         svcName,
+				Image:        svc.Image,
+				Command:      svc.Command,
+				Args:         svc.Args,
+				Env:          svc.Env,
+				Annotations:  svc.Annotations,
+				StorageMount: svc.StorageMount,
+				Stateful:     svc.Stateful,
 				Unit: types.Unit{
 					CPU:     uint32(compute.CPU),
 					Memory:  uint64(compute.Memory),
@@ -228,6 +284,17 @@ func (sdl *v1) Manifest() (manifest.Manifest, error) {
 				Count: svcdepl.Count,
 			}
 
+			if probe := svc.Probe; probe != nil {
+				msvc.Probe = manifest.ServiceProbe{
+					Path:             probe.Path,
+					Port:             probe.Port,
+					InitialDelay:     probe.InitialDelay,
+					Period:           probe.Period,
+					Timeout:          probe.Timeout,
+					FailureThreshold: probe.FailureThreshold,
+				}
+			}
+
 			for _, expose := range svc.Expose {
 				for _, to := range expose.To {
 					msvc.Expose = append(msvc.Expose, manifest.ServiceExpose{
@@ -237,6 +304,8 @@ func (sdl *v1) Manifest() (manifest.Manifest, error) {
 						Proto:        expose.Proto,
 						Global:       to.Global,
 						Hosts:        expose.Accept.Items,
+						ServiceType:  expose.ServiceType,
+						HTTPS:        expose.HTTPS,
 					})
 				}
 			}
@@ -15,6 +15,14 @@ func ValidateDeploymentGroups(groups []dtypes.GroupSpec) error {
 	if err := validateDeploymentResourceLists(defaultConfig, rlists); err != nil {
 		return fmt.Errorf("deployment groups: %v", err)
 	}
+	for _, group := range groups {
+		if err := validateUptimeWeight(group); err != nil {
+			return fmt.Errorf("deployment groups: %v", err)
+		}
+		if err := validateMaxPrice(group); err != nil {
+			return fmt.Errorf("deployment groups: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -36,6 +44,37 @@ func ValidateGroupSpecs(groups []*dtypes.GroupSpec) error {
 	if err := validateDeploymentResourceLists(defaultConfig, rlists); err != nil {
 		return fmt.Errorf("group specs: %v", err)
 	}
+	for _, group := range groups {
+		if err := validateUptimeWeight(*group); err != nil {
+			return fmt.Errorf("group specs: %v", err)
+		}
+		if err := validateMaxPrice(*group); err != nil {
+			return fmt.Errorf("group specs: %v", err)
+		}
+	}
+	return nil
+}
+
+func validateUptimeWeight(group dtypes.GroupSpec) error {
+	if group.UptimeWeight > dtypes.MaxUptimeWeight {
+		return fmt.Errorf("group %v: uptime weight %v over %v", group.Name, group.UptimeWeight, dtypes.MaxUptimeWeight)
+	}
+	return nil
+}
+
+func validateMaxPrice(group dtypes.GroupSpec) error {
+	if group.MaxPrice.Denom == "" {
+		return nil
+	}
+	if !group.MaxPrice.IsValid() || !group.MaxPrice.IsPositive() {
+		return fmt.Errorf("group %v: invalid max price %v", group.Name, group.MaxPrice)
+	}
+	for _, resource := range group.Resources {
+		if resource.Price.Denom != group.MaxPrice.Denom {
+			return fmt.Errorf("group %v: max price denomination %v does not match resource price %v",
+				group.Name, group.MaxPrice.Denom, resource.Price)
+		}
+	}
 	return nil
 }
 
@@ -1,9 +1,13 @@
 package cli
 
 import (
+	"fmt"
+	"strings"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ovrclk/akash/x/deployment/types"
 	"github.com/spf13/pflag"
+	tmkv "github.com/tendermint/tendermint/libs/kv"
 )
 
 func AddDeploymentIDFlags(flags *pflag.FlagSet) {
@@ -48,3 +52,22 @@ func GroupIDFromFlags(flags *pflag.FlagSet) (types.GroupID, error) {
 	}
 	return types.MakeGroupID(prev, gseq), nil
 }
+
+const flagTag = "tag"
+
+func tagsFromFlags(flags *pflag.FlagSet) ([]tmkv.Pair, error) {
+	values, err := flags.GetStringArray(flagTag)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]tmkv.Pair, 0, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid tag %q: expected key=value", value)
+		}
+		tags = append(tags, tmkv.Pair{Key: []byte(parts[0]), Value: []byte(parts[1])})
+	}
+	return tags, nil
+}
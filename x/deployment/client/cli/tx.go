@@ -52,6 +52,11 @@ func cmdCreate(key string, cdc *codec.Codec) *cobra.Command {
 				return err
 			}
 
+			tags, err := tagsFromFlags(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
 			msg := types.MsgCreate{
 				Owner: ctx.GetFromAddress(),
 				// Version:  []byte{0x1, 0x2},
@@ -59,6 +64,7 @@ func cmdCreate(key string, cdc *codec.Codec) *cobra.Command {
 			}
 
 			for _, group := range groups {
+				group.Tags = tags
 				msg.Groups = append(msg.Groups, *group)
 			}
 
@@ -70,6 +76,8 @@ func cmdCreate(key string, cdc *codec.Codec) *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringArray(flagTag, nil, "Tag the deployment's leases (key=value, repeatable)")
+
 	return cmd
 }
 
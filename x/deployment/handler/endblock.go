@@ -25,8 +25,12 @@ func OnEndBlock(ctx sdk.Context, keeper keeper.Keeper, mkeeper MarketKeeper) {
 
 			// TODO: check for active order.
 
-			// create order.
-			mkeeper.CreateOrder(ctx, group.ID(), group.GroupSpec)
+			// create order; the group stays open and is retried next block
+			// if the owner is at their open order cap.
+			if _, err := mkeeper.CreateOrder(ctx, group.ID(), group.GroupSpec); err != nil {
+				ctx.Logger().Info("creating order", "group", group.ID(), "err", err)
+				continue
+			}
 
 			// set state to ordered
 			keeper.OnOrderCreated(ctx, group)
@@ -7,6 +7,6 @@ import (
 )
 
 type MarketKeeper interface {
-	CreateOrder(ctx sdk.Context, id types.GroupID, spec types.GroupSpec) mtypes.Order
+	CreateOrder(ctx sdk.Context, id types.GroupID, spec types.GroupSpec) (mtypes.Order, error)
 	OnGroupClosed(ctx sdk.Context, id types.GroupID)
 }
@@ -2,6 +2,8 @@ package types
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -40,8 +42,40 @@ type GroupSpec struct {
 	Name         string      `json:"name"`
 	Requirements []tmkv.Pair `json:"requirements"`
 	Resources    []Resource  `json:"resources"`
+
+	// Lease length, in blocks, requested by the tenant.  Zero for no fixed end.
+	Duration int64 `json:"duration,omitempty"`
+
+	// Tenant bookkeeping labels carried onto the lease.  Not used for matching.
+	Tags []tmkv.Pair `json:"tags,omitempty"`
+
+	// Percentage of bid selection weighted toward provider uptime rather than
+	// price.  Zero selects the cheapest bid.
+	UptimeWeight uint32 `json:"uptime-weight,omitempty"`
+
+	// Highest bid price accepted for the group.  Unset accepts bids up to the
+	// sum of the resource prices.
+	MaxPrice sdk.Coin `json:"max-price,omitempty"`
+
+	// How the winning bid is chosen.  Zero awards the cheapest bid.
+	PricingMode PricingMode `json:"pricing-mode,omitempty"`
 }
 
+// PricingMode selects how the market chooses the winning bid for a group.
+type PricingMode uint8
+
+const (
+	// PricingLowestBid awards the group to the cheapest bid.
+	PricingLowestBid PricingMode = iota
+	// PricingDutch awards the group to the first bid at or below a ceiling
+	// price that falls over time from the group's price limit.
+	PricingDutch PricingMode = iota
+)
+
+// MaxUptimeWeight is the largest GroupSpec UptimeWeight: selection by uptime
+// alone.
+const MaxUptimeWeight = 100
+
 func (g GroupSpec) GetResources() []types.Resource {
 	resources := make([]types.Resource, 0, len(g.Resources))
 	for _, r := range g.Resources {
@@ -69,6 +103,36 @@ func (g GroupSpec) Price() sdk.Coin {
 	return price
 }
 
+// PriceLimit returns the highest bid price accepted for the group: MaxPrice
+// if set, otherwise the sum of the resource prices.  Bids must be in its
+// denomination.
+func (g GroupSpec) PriceLimit() sdk.Coin {
+	if g.MaxPrice.Denom != "" {
+		return g.MaxPrice
+	}
+	return g.Price()
+}
+
+// ResourcesHash identifies the resources the group requests: the unit and
+// count of each resource, in order.  Names, requirements and prices are not
+// included, so groups with equal hashes request the same resources.
+func (g GroupSpec) ResourcesHash() []byte {
+	h := sha256.New()
+	for _, r := range g.Resources {
+		binary.Write(h, binary.BigEndian, r.Unit.CPU)
+		binary.Write(h, binary.BigEndian, r.Unit.Memory)
+		binary.Write(h, binary.BigEndian, r.Unit.Storage)
+		binary.Write(h, binary.BigEndian, r.Count)
+	}
+	return h.Sum(nil)
+}
+
+// WithinDuration reports whether a lease for the group fits in max blocks.
+// A zero max allows any duration.
+func (g GroupSpec) WithinDuration(max int64) bool {
+	return max == 0 || (g.Duration > 0 && g.Duration <= max)
+}
+
 func (g GroupSpec) MatchAttributes(attrs []tmkv.Pair) bool {
 loop:
 	for _, req := range g.Requirements {
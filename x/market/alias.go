@@ -1,13 +1,16 @@
 package market
 
 import (
+	"github.com/ovrclk/akash/x/market/handler"
 	"github.com/ovrclk/akash/x/market/keeper"
 	"github.com/ovrclk/akash/x/market/types"
 )
 
 const (
-	StoreKey   = types.StoreKey
-	ModuleName = types.ModuleName
+	StoreKey          = types.StoreKey
+	RouterKey         = types.RouterKey
+	ModuleName        = types.ModuleName
+	DefaultParamspace = types.DefaultParamspace
 )
 
 type (
@@ -15,5 +18,6 @@ type (
 )
 
 var (
-	NewKeeper = keeper.NewKeeper
+	NewKeeper          = keeper.NewKeeper
+	NewProposalHandler = handler.NewProposalHandler
 )
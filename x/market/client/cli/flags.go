@@ -7,6 +7,11 @@ import (
 	"github.com/spf13/pflag"
 )
 
+const (
+	flagPage  = "page"
+	flagLimit = "limit"
+)
+
 func AddOrderIDFlags(flags *pflag.FlagSet) {
 	dcli.AddGroupIDFlags(flags)
 	flags.Uint32("oseq", 0, "Order Sequence")
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"bufio"
+	"io/ioutil"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/auth/client/utils"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	"github.com/ovrclk/akash/x/market/types"
+	"github.com/spf13/cobra"
+)
+
+// LeasePriceUpdateProposalJSON is the proposal file read by
+// GetCmdSubmitLeasePriceUpdateProposal.
+type LeasePriceUpdateProposalJSON struct {
+	Title       string                   `json:"title" yaml:"title"`
+	Description string                   `json:"description" yaml:"description"`
+	Updates     []types.LeasePriceUpdate `json:"updates" yaml:"updates"`
+	Deposit     sdk.Coins                `json:"deposit" yaml:"deposit"`
+}
+
+// GetCmdSubmitLeasePriceUpdateProposal submits a lease price update proposal
+// read from a JSON file.
+func GetCmdSubmitLeasePriceUpdateProposal(cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "lease-price-update [proposal-file]",
+		Short: "Submit a lease price update proposal",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inBuf := bufio.NewReader(cmd.InOrStdin())
+			bldr := auth.NewTxBuilderFromCLI(inBuf).WithTxEncoder(utils.GetTxEncoder(cdc))
+			ctx := context.NewCLIContextWithInput(inBuf).WithCodec(cdc)
+
+			contents, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			var proposal LeasePriceUpdateProposalJSON
+			if err := cdc.UnmarshalJSON(contents, &proposal); err != nil {
+				return err
+			}
+
+			content := types.LeasePriceUpdateProposal{
+				Title:       proposal.Title,
+				Description: proposal.Description,
+				Updates:     proposal.Updates,
+			}
+
+			msg := govtypes.NewMsgSubmitProposal(content, proposal.Deposit, ctx.GetFromAddress())
+			if err := msg.ValidateBasic(); err != nil {
+				return err
+			}
+
+			return utils.GenerateOrBroadcastMsgs(ctx, bldr, []sdk.Msg{msg})
+		},
+	}
+}
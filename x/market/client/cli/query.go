@@ -5,6 +5,7 @@ import (
 	"github.com/cosmos/cosmos-sdk/client/flags"
 	"github.com/cosmos/cosmos-sdk/client/context"
 	"github.com/cosmos/cosmos-sdk/codec"
+	dcli "github.com/ovrclk/akash/x/deployment/client/cli"
 	"github.com/ovrclk/akash/x/market/query"
 	"github.com/ovrclk/akash/x/market/types"
 	"github.com/spf13/cobra"
@@ -22,8 +23,10 @@ func GetQueryCmd(key string, cdc *codec.Codec) *cobra.Command {
 
 	cmd.AddCommand(flags.GetCommands(
 		cmdGetOrders(key, cdc),
+		cmdGetOpenOrders(key, cdc),
 		cmdGetBids(key, cdc),
 		cmdGetLeases(key, cdc),
+		cmdGetDeploymentLeases(key, cdc),
 	)...)
 
 	return cmd
@@ -43,18 +46,63 @@ func cmdGetOrders(key string, cdc *codec.Codec) *cobra.Command {
 	}
 }
 
-func cmdGetBids(key string, cdc *codec.Codec) *cobra.Command {
+func cmdGetOpenOrders(key string, cdc *codec.Codec) *cobra.Command {
 	return &cobra.Command{
-		Use: "bids",
+		Use:   "open-orders",
+		Short: "Query orders open for bidding",
+		Args:  cobra.ExactArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.NewCLIContext().WithCodec(cdc)
-			obj, err := query.NewClient(ctx, key).Bids()
+			obj, err := query.NewClient(ctx, key).OrdersByState(types.OrderOpen)
+			if err != nil {
+				return err
+			}
+			return ctx.PrintOutput(obj)
+		},
+	}
+}
+
+func cmdGetBids(key string, cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bids",
+		Short: "Query bids, optionally paging through the bids of a single order",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.NewCLIContext().WithCodec(cdc)
+
+			if !cmd.Flags().Changed("owner") {
+				obj, err := query.NewClient(ctx, key).Bids()
+				if err != nil {
+					return err
+				}
+				return ctx.PrintOutput(obj)
+			}
+
+			id, err := OrderIDFromFlags(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			page, err := cmd.Flags().GetUint32(flagPage)
+			if err != nil {
+				return err
+			}
+
+			limit, err := cmd.Flags().GetUint32(flagLimit)
+			if err != nil {
+				return err
+			}
+
+			obj, err := query.NewClient(ctx, key).OrderBids(id, page, limit)
 			if err != nil {
 				return err
 			}
 			return ctx.PrintOutput(obj)
 		},
 	}
+	AddOrderIDFlags(cmd.Flags())
+	cmd.Flags().Uint32(flagPage, 1, "Page of order bids to query")
+	cmd.Flags().Uint32(flagLimit, 0, "Bids per page (capped at 100)")
+	return cmd
 }
 
 func cmdGetLeases(key string, cdc *codec.Codec) *cobra.Command {
@@ -70,3 +118,27 @@ func cmdGetLeases(key string, cdc *codec.Codec) *cobra.Command {
 		},
 	}
 }
+
+func cmdGetDeploymentLeases(key string, cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deployment-leases",
+		Short: "Query leases for a deployment generation",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.NewCLIContext().WithCodec(cdc)
+
+			id, err := dcli.DeploymentIDFromFlags(cmd.Flags(), ctx.FromAddress.String())
+			if err != nil {
+				return err
+			}
+
+			obj, err := query.NewClient(ctx, key).DeploymentLeases(id)
+			if err != nil {
+				return err
+			}
+			return ctx.PrintOutput(obj)
+		},
+	}
+	dcli.AddDeploymentIDFlags(cmd.Flags())
+	return cmd
+}
@@ -25,7 +25,10 @@ func GetTxCmd(key string, cdc *codec.Codec) *cobra.Command {
 	cmd.AddCommand(flags.PostCommands(
 		cmdCreateBid(key, cdc),
 		cmdCloseBid(key, cdc),
+		cmdWithdrawBid(key, cdc),
 		cmdCloseOrder(key, cdc),
+		cmdCancelOrder(key, cdc),
+		cmdProviderCloseLease(key, cdc),
 	)...)
 	return cmd
 }
@@ -49,15 +52,32 @@ func cmdCreateBid(key string, cdc *codec.Codec) *cobra.Command {
 				return err
 			}
 
+			maxDuration, err := cmd.Flags().GetInt64("max-duration")
+			if err != nil {
+				return err
+			}
+
+			deposit, err := cmd.Flags().GetString("deposit")
+			if err != nil {
+				return err
+			}
+
+			depositCoin, err := sdk.ParseCoin(deposit)
+			if err != nil {
+				return err
+			}
+
 			id, err := OrderIDFromFlags(cmd.Flags())
 			if err != nil {
 				return err
 			}
 
 			msg := types.MsgCreateBid{
-				Order:    id,
-				Provider: ctx.GetFromAddress(),
-				Price:    coins,
+				Order:       id,
+				Provider:    ctx.GetFromAddress(),
+				Price:       coins,
+				MaxDuration: maxDuration,
+				Deposit:     depositCoin,
 			}
 
 			if err := msg.ValidateBasic(); err != nil {
@@ -68,6 +88,8 @@ func cmdCreateBid(key string, cdc *codec.Codec) *cobra.Command {
 	}
 	AddOrderIDFlags(cmd.Flags())
 	cmd.Flags().String("price", "", "Bid Price")
+	cmd.Flags().Int64("max-duration", 0, "Maximum lease duration in blocks (0 for no limit)")
+	cmd.Flags().String("deposit", "0akash", "Deposit held in escrow until the bid is lost or closed")
 	return cmd
 }
 
@@ -97,6 +119,32 @@ func cmdCloseBid(key string, cdc *codec.Codec) *cobra.Command {
 	return cmd
 }
 
+func cmdWithdrawBid(key string, cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bid-withdraw",
+		Short: "Withdraw an open bid",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.NewCLIContext().WithCodec(cdc)
+			bldr := auth.NewTxBuilderFromCLI(os.Stdin).WithTxEncoder(utils.GetTxEncoder(cdc))
+
+			id, err := BidIDFromFlags(ctx, cmd.Flags())
+			if err != nil {
+				return err
+			}
+			msg := types.MsgWithdrawBid{
+				BidID: id,
+			}
+			if err := msg.ValidateBasic(); err != nil {
+				return err
+			}
+			return utils.GenerateOrBroadcastMsgs(ctx, bldr, []sdk.Msg{msg})
+		},
+	}
+	AddBidIDFlags(cmd.Flags())
+	return cmd
+}
+
 func cmdCloseOrder(key string, cdc *codec.Codec) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "order-close",
@@ -124,3 +172,59 @@ func cmdCloseOrder(key string, cdc *codec.Codec) *cobra.Command {
 	AddOrderIDFlags(cmd.Flags())
 	return cmd
 }
+
+func cmdCancelOrder(key string, cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "order-cancel",
+		Short: "Cancel an order that has not been leased",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.NewCLIContext().WithCodec(cdc)
+			bldr := auth.NewTxBuilderFromCLI(os.Stdin).WithTxEncoder(utils.GetTxEncoder(cdc))
+
+			id, err := OrderIDFromFlags(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			msg := types.MsgCancelOrder{
+				OrderID: id,
+			}
+
+			if err := msg.ValidateBasic(); err != nil {
+				return err
+			}
+			return utils.GenerateOrBroadcastMsgs(ctx, bldr, []sdk.Msg{msg})
+		},
+	}
+	AddOrderIDFlags(cmd.Flags())
+	return cmd
+}
+
+func cmdProviderCloseLease(key string, cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lease-close",
+		Short: "Close lease after the notice window",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.NewCLIContext().WithCodec(cdc)
+			bldr := auth.NewTxBuilderFromCLI(os.Stdin).WithTxEncoder(utils.GetTxEncoder(cdc))
+
+			id, err := BidIDFromFlags(ctx, cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			msg := types.MsgProviderCloseLease{
+				LeaseID: id.LeaseID(),
+			}
+
+			if err := msg.ValidateBasic(); err != nil {
+				return err
+			}
+			return utils.GenerateOrBroadcastMsgs(ctx, bldr, []sdk.Msg{msg})
+		},
+	}
+	AddBidIDFlags(cmd.Flags())
+	return cmd
+}
@@ -0,0 +1,11 @@
+package client
+
+import (
+	govclient "github.com/cosmos/cosmos-sdk/x/gov/client"
+	"github.com/ovrclk/akash/x/market/client/cli"
+	"github.com/ovrclk/akash/x/market/client/rest"
+)
+
+// ProposalHandler adds lease price update proposals to the gov CLI and REST
+// routes.
+var ProposalHandler = govclient.NewProposalHandler(cli.GetCmdSubmitLeasePriceUpdateProposal, rest.ProposalRESTHandler)
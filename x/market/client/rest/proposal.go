@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/rest"
+	"github.com/cosmos/cosmos-sdk/x/auth/client/utils"
+	govrest "github.com/cosmos/cosmos-sdk/x/gov/client/rest"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	"github.com/ovrclk/akash/x/market/types"
+)
+
+// LeasePriceUpdateProposalReq is the request body of the lease price update
+// proposal route.
+type LeasePriceUpdateProposalReq struct {
+	BaseReq     rest.BaseReq             `json:"base_req" yaml:"base_req"`
+	Title       string                   `json:"title" yaml:"title"`
+	Description string                   `json:"description" yaml:"description"`
+	Updates     []types.LeasePriceUpdate `json:"updates" yaml:"updates"`
+	Proposer    sdk.AccAddress           `json:"proposer" yaml:"proposer"`
+	Deposit     sdk.Coins                `json:"deposit" yaml:"deposit"`
+}
+
+// ProposalRESTHandler exposes lease price update proposals under the gov
+// proposal routes.
+func ProposalRESTHandler(ctx context.CLIContext) govrest.ProposalRESTHandler {
+	return govrest.ProposalRESTHandler{
+		SubRoute: "lease_price_update",
+		Handler:  postProposalHandler(ctx),
+	}
+}
+
+func postProposalHandler(ctx context.CLIContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req LeasePriceUpdateProposalReq
+		if !rest.ReadRESTReq(w, r, ctx.Codec, &req) {
+			return
+		}
+
+		req.BaseReq = req.BaseReq.Sanitize()
+		if !req.BaseReq.ValidateBasic(w) {
+			return
+		}
+
+		content := types.LeasePriceUpdateProposal{
+			Title:       req.Title,
+			Description: req.Description,
+			Updates:     req.Updates,
+		}
+
+		msg := govtypes.NewMsgSubmitProposal(content, req.Deposit, req.Proposer)
+		if err := msg.ValidateBasic(); err != nil {
+			rest.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		utils.WriteGenerateStdTxResponse(w, ctx, req.BaseReq, []sdk.Msg{msg})
+	}
+}
@@ -2,28 +2,87 @@ package market
 
 import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
 	"github.com/ovrclk/akash/x/market/keeper"
 	"github.com/ovrclk/akash/x/market/types"
 	abci "github.com/tendermint/tendermint/abci/types"
 )
 
+// OrderSeq is the last oseq assigned within a group.
+type OrderSeq struct {
+	GroupID dtypes.GroupID `json:"group_id"`
+	Seq     uint32         `json:"seq"`
+}
+
 type GenesisState struct {
-	Orders []types.Order `json:"orders"`
-	Leases []types.Lease `json:"leases"`
+	Orders    []types.Order `json:"orders"`
+	Bids      []types.Bid   `json:"bids"`
+	Leases    []types.Lease `json:"leases"`
+	OrderSeqs []OrderSeq    `json:"order_seqs"`
+	Params    types.Params  `json:"params"`
 }
 
 func ValidateGenesis(data GenesisState) error {
-	return nil
+	return data.Params.Validate()
 }
 
 func DefaultGenesisState() GenesisState {
-	return GenesisState{}
+	return GenesisState{
+		Params: types.DefaultParams(),
+	}
 }
 
-func InitGenesis(ctx sdk.Context, keeper keeper.Keeper, data GenesisState) []abci.ValidatorUpdate {
+func InitGenesis(ctx sdk.Context, k keeper.Keeper, data GenesisState) []abci.ValidatorUpdate {
+	k.SetParams(ctx, data.Params)
+	k.SetStoreVersion(ctx, keeper.CurrentStoreVersion)
+
+	for _, order := range data.Orders {
+		k.ImportOrder(ctx, order)
+	}
+	for _, bid := range data.Bids {
+		k.ImportBid(ctx, bid)
+	}
+	for _, lease := range data.Leases {
+		k.ImportLease(ctx, lease)
+	}
+	for _, seq := range data.OrderSeqs {
+		k.ImportOrderSeq(ctx, seq.GroupID, seq.Seq)
+	}
+	k.RebuildProviderBidIndex(ctx)
+
 	return []abci.ValidatorUpdate{}
 }
 
 func ExportGenesis(ctx sdk.Context, k keeper.Keeper) GenesisState {
-	return GenesisState{}
+	var orders []types.Order
+	k.WithOrders(ctx, func(order types.Order) bool {
+		orders = append(orders, order)
+		return false
+	})
+
+	var bids []types.Bid
+	k.WithBids(ctx, func(bid types.Bid) bool {
+		bids = append(bids, bid)
+		return false
+	})
+
+	var leases []types.Lease
+	k.WithLeases(ctx, func(lease types.Lease) bool {
+		leases = append(leases, lease)
+		return false
+	})
+
+	var seqs []OrderSeq
+	k.WithOrderSeqs(ctx, func(gid dtypes.GroupID, seq uint32) bool {
+		seqs = append(seqs, OrderSeq{GroupID: gid, Seq: seq})
+		return false
+	})
+
+	return GenesisState{
+		Orders:    orders,
+		Bids:      bids,
+		Leases:    leases,
+		OrderSeqs: seqs,
+		Params:    k.GetParams(ctx),
+	}
 }
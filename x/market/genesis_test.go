@@ -0,0 +1,106 @@
+package market_test
+
+import (
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/params"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
+	"github.com/ovrclk/akash/x/market"
+	"github.com/ovrclk/akash/x/market/keeper"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/libs/log"
+	dbm "github.com/tendermint/tm-db"
+)
+
+func TestGenesis_roundTrip(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	owner := testAddress()
+	provider := testAddress()
+	price := sdk.NewInt64Coin("akash", 10)
+	nodeposit := sdk.NewInt64Coin("akash", 0)
+
+	// a leased order, a group with a closed order, and an open order with a
+	// withdrawn bid
+	gid := dtypes.GroupID{Owner: owner, DSeq: 1, GSeq: 1}
+	leased, err := k.CreateOrder(ctx, gid, dtypes.GroupSpec{Name: "test"})
+	require.NoError(t, err)
+	require.NoError(t, k.CreateBid(ctx, leased.ID(), provider, price, 0, nodeposit))
+	bid, _ := k.GetBid(ctx, mtypes.MakeBidID(leased.ID(), provider))
+	_, err = k.AwardLease(ctx, leased.ID(), bid)
+	require.NoError(t, err)
+
+	closed, err := k.CreateOrder(ctx, dtypes.GroupID{Owner: owner, DSeq: 2, GSeq: 1}, dtypes.GroupSpec{Name: "test"})
+	require.NoError(t, err)
+	require.NoError(t, k.CancelOrder(ctx, closed.ID()))
+
+	open, err := k.CreateOrder(ctx, dtypes.GroupID{Owner: owner, DSeq: 3, GSeq: 1}, dtypes.GroupSpec{Name: "test"})
+	require.NoError(t, err)
+	require.NoError(t, k.CreateBid(ctx, open.ID(), provider, price, 0, nodeposit))
+	require.NoError(t, k.WithdrawBid(ctx, mtypes.MakeBidID(open.ID(), provider), provider))
+
+	exported := market.ExportGenesis(ctx, k)
+	assert.Len(t, exported.Orders, 3)
+	assert.Len(t, exported.Bids, 2)
+	assert.Len(t, exported.Leases, 1)
+	assert.Len(t, exported.OrderSeqs, 3)
+
+	var data market.GenesisState
+	mtypes.MustUnmarshalJSON(mtypes.MustMarshalJSON(exported), &data)
+
+	ictx, ik := setupKeeper(t)
+	market.InitGenesis(ictx, ik, data)
+	assert.Equal(t, exported, market.ExportGenesis(ictx, ik))
+
+	// indexes and counters are restored
+	var providerBids []mtypes.BidID
+	ik.WithBidsForProvider(ictx, provider, func(bid mtypes.Bid) bool {
+		providerBids = append(providerBids, bid.ID())
+		return false
+	})
+	assert.Equal(t, []mtypes.BidID{bid.ID()}, providerBids)
+
+	order, err := ik.CreateOrder(ictx, gid, dtypes.GroupSpec{Name: "test"})
+	require.NoError(t, err)
+	assert.Equal(t, leased.OSeq+1, order.OSeq)
+
+	assert.Equal(t, keeper.CurrentStoreVersion, ik.GetStoreVersion(ictx))
+}
+
+func setupKeeper(t *testing.T) (sdk.Context, keeper.Keeper) {
+	t.Helper()
+
+	key := sdk.NewKVStoreKey(mtypes.StoreKey)
+	pkey := sdk.NewKVStoreKey(params.StoreKey)
+	tkey := sdk.NewTransientStoreKey(params.TStoreKey)
+
+	db := dbm.NewMemDB()
+	ms := store.NewCommitMultiStore(db)
+	ms.MountStoreWithDB(key, sdk.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(pkey, sdk.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(tkey, sdk.StoreTypeTransient, db)
+	require.NoError(t, ms.LoadLatestVersion())
+
+	ctx := sdk.NewContext(ms, abci.Header{Height: 1}, false, log.NewNopLogger())
+
+	cdc := codec.New()
+	mtypes.RegisterCodec(cdc)
+
+	pkeeper := params.NewKeeper(cdc, pkey, tkey)
+
+	k := keeper.NewKeeper(cdc, key, pkeeper.Subspace(mtypes.DefaultParamspace), nil, nil)
+	k.SetParams(ctx, mtypes.DefaultParams())
+
+	return ctx, k
+}
+
+func testAddress() sdk.AccAddress {
+	return sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+}
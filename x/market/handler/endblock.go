@@ -1,107 +1,101 @@
 package handler
 
 import (
-	"sort"
-
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ovrclk/akash/x/market/types"
 )
 
 func OnEndBlock(ctx sdk.Context, keepers Keepers) error {
+	if err := closeNoticedLeases(ctx, keepers); err != nil {
+		return err
+	}
+	if err := closeUnresponsiveLeases(ctx, keepers); err != nil {
+		return err
+	}
 	if err := transferFundsForActiveLeases(ctx, keepers); err != nil {
 		return err
 	}
 	if err := matchOrders(ctx, keepers); err != nil {
 		return err
 	}
+	keepers.Market.PruneEventLog(ctx)
 	return nil
 }
 
-func transferFundsForActiveLeases(ctx sdk.Context, keepers Keepers) error {
+func closeNoticedLeases(ctx sdk.Context, keepers Keepers) error {
 
-	// for all active leases, transfer funds
-	count := 0
+	// find leases whose provider-initiated close notice has elapsed or that
+	// have reached their maximum duration
+	var leases []types.Lease
 	keepers.Market.WithLeases(ctx, func(lease types.Lease) bool {
-
-		if lease.State != types.LeaseActive {
-			return false
+		if lease.CloseDue(ctx.BlockHeight()) {
+			leases = append(leases, lease)
 		}
+		return false
+	})
 
-		amt := sdk.NewCoins(lease.Price)
-
-		if !keepers.Bank.HasCoins(ctx, lease.Owner, amt) {
-			keepers.Deployment.OnLeaseInsufficientFunds(ctx, lease.GroupID())
-			keepers.Market.OnInsufficientFunds(ctx, lease)
-			return false
+	for _, lease := range leases {
+		if bid, ok := keepers.Market.GetBid(ctx, lease.BidID()); ok {
+			if err := keepers.Market.OnBidClosed(ctx, bid); err != nil {
+				ctx.Logger().Error("closing bid", "bid", bid.ID(), "err", err)
+			}
 		}
-
-		err := keepers.Bank.SendCoins(ctx, lease.Owner, lease.Provider, amt)
-
-		if err != nil {
-			ctx.Logger().Error("error transferring funds", "err", err)
-			// TODO: cancel order, lease.
-			// TODO: notify deployment module.
-			return false
+		if err := keepers.Market.OnLeaseClosed(ctx, lease); err != nil {
+			ctx.Logger().Error("closing lease", "lease", lease.ID(), "err", err)
+			continue
 		}
+		if order, ok := keepers.Market.GetOrder(ctx, lease.OrderID()); ok {
+			if err := keepers.Market.OnOrderClosed(ctx, order); err != nil {
+				ctx.Logger().Error("closing order", "order", order.ID(), "err", err)
+			}
+		}
+		keepers.Deployment.OnLeaseClosed(ctx, lease.GroupID())
+	}
 
-		count++
-		return false
-	})
-
-	ctx.Logger().Info("processed active leases", "count", count)
+	return nil
+}
 
+func closeUnresponsiveLeases(ctx sdk.Context, keepers Keepers) error {
+	for _, lease := range keepers.Market.CloseUnresponsiveLeases(ctx) {
+		keepers.Deployment.OnLeaseClosed(ctx, lease.GroupID())
+	}
 	return nil
 }
 
-func matchOrders(ctx sdk.Context, keepers Keepers) error {
+func transferFundsForActiveLeases(ctx sdk.Context, keepers Keepers) error {
 
-	// match unmatched orders.
-	keepers.Market.WithOrders(ctx, func(order types.Order) bool {
-		if err := order.ValidateCanMatch(ctx.BlockHeight()); err != nil {
-			return false
+	// settle every active lease for the blocks since it was last paid
+	var leases []types.Lease
+	keepers.Market.WithLeases(ctx, func(lease types.Lease) bool {
+		if lease.State == types.LeaseActive {
+			leases = append(leases, lease)
 		}
+		return false
+	})
 
-		var bids []types.Bid
-
-		keepers.Market.WithBidsForOrder(ctx, order.ID(), func(bid types.Bid) bool {
-			if bid.State != types.BidOpen {
-				return false
-			}
-			bids = append(bids, bid)
-			return false
-		})
-
-		// no open bids
-		if len(bids) == 0 {
-			return false
+	count := 0
+	for _, lease := range leases {
+		err := keepers.Market.SettleLease(ctx, lease)
+		switch {
+		case err == nil:
+			count++
+		case types.ErrLeaseUnderfunded.Is(err):
+			keepers.Deployment.OnLeaseInsufficientFunds(ctx, lease.GroupID())
+		default:
+			ctx.Logger().Error("error settling lease", "lease", lease.ID(), "err", err)
 		}
+	}
 
-		// open bids; match by lowest price
-		sort.Slice(bids, func(i, j int) bool {
-			// TODO handle same price
-			return bids[i].Price.IsLT(bids[j].Price)
-		})
-
-		winner := bids[0]
-
-		// create lease
-		keepers.Market.CreateLease(ctx, winner)
-
-		// set winning bid state to matched
-		keepers.Market.OnBidMatched(ctx, winner)
-
-		// set losing bids to state lost
-		for _, bid := range bids[1:] {
-			keepers.Market.OnBidLost(ctx, bid)
-		}
+	ctx.Logger().Info("processed active leases", "count", count)
 
-		// set order state to matched
-		keepers.Market.OnOrderMatched(ctx, order)
+	return nil
+}
 
-		// notify group of match
-		keepers.Deployment.OnLeaseCreated(ctx, order.GroupID())
+func matchOrders(ctx sdk.Context, keepers Keepers) error {
 
-		return false
-	})
+	// match open orders; notify the groups of the created leases.
+	for _, lease := range keepers.Market.MatchOrders(ctx) {
+		keepers.Deployment.OnLeaseCreated(ctx, lease.GroupID())
+	}
 	return nil
 }
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/params"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
+	"github.com/ovrclk/akash/x/market/keeper"
+	"github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/libs/log"
+	dbm "github.com/tendermint/tm-db"
+)
+
+func TestCloseNoticedLeases(t *testing.T) {
+	ctx, keepers := setupKeepers(t)
+
+	params := keepers.Market.GetParams(ctx)
+	params.LeaseCloseNotice = 5
+	keepers.Market.SetParams(ctx, params)
+
+	lease := createLease(t, ctx, keepers.Market)
+	require.NoError(t, keepers.Market.ProviderCloseLease(ctx, lease.ID(), lease.Provider))
+
+	// notice window still open
+	require.NoError(t, closeNoticedLeases(ctx.WithBlockHeight(ctx.BlockHeight()+4), keepers))
+	lease, _ = keepers.Market.GetLease(ctx, lease.ID())
+	assert.Equal(t, types.LeaseActive, lease.State)
+
+	// notice window elapsed
+	require.NoError(t, closeNoticedLeases(ctx.WithBlockHeight(ctx.BlockHeight()+5), keepers))
+
+	lease, _ = keepers.Market.GetLease(ctx, lease.ID())
+	assert.Equal(t, types.LeaseClosed, lease.State)
+
+	bid, _ := keepers.Market.GetBid(ctx, lease.BidID())
+	assert.Equal(t, types.BidClosed, bid.State)
+
+	order, _ := keepers.Market.GetOrder(ctx, lease.OrderID())
+	assert.Equal(t, types.OrderClosed, order.State)
+
+	dkeeper := keepers.Deployment.(*testDeploymentKeeper)
+	assert.Equal(t, []dtypes.GroupID{lease.GroupID()}, dkeeper.closed)
+}
+
+func TestCloseExpiredLeases(t *testing.T) {
+	ctx, keepers := setupKeepers(t)
+
+	gid := dtypes.GroupID{Owner: testAddress(), DSeq: 1, GSeq: 1}
+	order, err := keepers.Market.CreateOrder(ctx, gid, dtypes.GroupSpec{Name: "test", Duration: 10})
+	require.NoError(t, err)
+
+	provider := testAddress()
+	require.NoError(t, keepers.Market.CreateBid(ctx, order.ID(), provider, sdk.NewInt64Coin("akash", 10), 10, sdk.NewInt64Coin("akash", 0)))
+	bid, ok := keepers.Market.GetBid(ctx, types.MakeBidID(order.ID(), provider))
+	require.True(t, ok)
+
+	lease, err := keepers.Market.AwardLease(ctx, order.ID(), bid)
+	require.NoError(t, err)
+	assert.Equal(t, ctx.BlockHeight()+10, lease.ExpiresAt)
+
+	require.NoError(t, closeNoticedLeases(ctx.WithBlockHeight(ctx.BlockHeight()+9), keepers))
+	lease, _ = keepers.Market.GetLease(ctx, lease.ID())
+	assert.Equal(t, types.LeaseActive, lease.State)
+
+	require.NoError(t, closeNoticedLeases(ctx.WithBlockHeight(ctx.BlockHeight()+10), keepers))
+	lease, _ = keepers.Market.GetLease(ctx, lease.ID())
+	assert.Equal(t, types.LeaseClosed, lease.State)
+}
+
+type testDeploymentKeeper struct {
+	closed []dtypes.GroupID
+}
+
+func (k *testDeploymentKeeper) GetGroup(ctx sdk.Context, id dtypes.GroupID) (dtypes.Group, bool) {
+	return dtypes.Group{}, false
+}
+
+func (k *testDeploymentKeeper) OnLeaseCreated(ctx sdk.Context, id dtypes.GroupID) {}
+
+func (k *testDeploymentKeeper) OnLeaseInsufficientFunds(ctx sdk.Context, id dtypes.GroupID) {}
+
+func (k *testDeploymentKeeper) OnLeaseClosed(ctx sdk.Context, id dtypes.GroupID) {
+	k.closed = append(k.closed, id)
+}
+
+func setupKeepers(t *testing.T) (sdk.Context, Keepers) {
+	t.Helper()
+
+	key := sdk.NewKVStoreKey(types.StoreKey)
+	pkey := sdk.NewKVStoreKey(params.StoreKey)
+	tkey := sdk.NewTransientStoreKey(params.TStoreKey)
+
+	db := dbm.NewMemDB()
+	ms := store.NewCommitMultiStore(db)
+	ms.MountStoreWithDB(key, sdk.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(pkey, sdk.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(tkey, sdk.StoreTypeTransient, db)
+	require.NoError(t, ms.LoadLatestVersion())
+
+	ctx := sdk.NewContext(ms, abci.Header{Height: 1}, false, log.NewNopLogger())
+
+	cdc := codec.New()
+	types.RegisterCodec(cdc)
+
+	pkeeper := params.NewKeeper(cdc, pkey, tkey)
+
+	mkeeper := keeper.NewKeeper(cdc, key, pkeeper.Subspace(types.DefaultParamspace), nil, nil)
+	mkeeper.SetParams(ctx, types.DefaultParams())
+
+	return ctx, Keepers{
+		Market:     mkeeper,
+		Deployment: &testDeploymentKeeper{},
+	}
+}
+
+func testAddress() sdk.AccAddress {
+	return sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+}
+
+func createLease(t *testing.T, ctx sdk.Context, k keeper.Keeper) types.Lease {
+	t.Helper()
+
+	gid := dtypes.GroupID{Owner: testAddress(), DSeq: 1, GSeq: 1}
+	order, err := k.CreateOrder(ctx, gid, dtypes.GroupSpec{Name: "test"})
+	require.NoError(t, err)
+
+	provider := testAddress()
+	require.NoError(t, k.CreateBid(ctx, order.ID(), provider, sdk.NewInt64Coin("akash", 10), 0, sdk.NewInt64Coin("akash", 0)))
+
+	bid, ok := k.GetBid(ctx, types.MakeBidID(order.ID(), provider))
+	require.True(t, ok)
+
+	require.NoError(t, k.CreateLease(ctx, bid))
+	k.OnBidMatched(ctx, bid)
+	k.OnOrderMatched(ctx, order)
+
+	lease, ok := k.GetLease(ctx, bid.ID().LeaseID())
+	require.True(t, ok)
+	return lease
+}
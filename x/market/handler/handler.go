@@ -13,8 +13,16 @@ func NewHandler(keepers Keepers) sdk.Handler {
 			return handleMsgCreateBid(ctx, keepers, msg)
 		case types.MsgCloseBid:
 			return handleMsgCloseBid(ctx, keepers, msg)
+		case types.MsgWithdrawBid:
+			return handleMsgWithdrawBid(ctx, keepers, msg)
 		case types.MsgCloseOrder:
 			return handleMsgCloseOrder(ctx, keepers, msg)
+		case types.MsgCancelOrder:
+			return handleMsgCancelOrder(ctx, keepers, msg)
+		case types.MsgProviderCloseLease:
+			return handleMsgProviderCloseLease(ctx, keepers, msg)
+		case types.MsgLeaseHeartbeat:
+			return handleMsgLeaseHeartbeat(ctx, keepers, msg)
 		default:
 			return nil, sdkerrors.ErrUnknownRequest
 		}
@@ -31,8 +39,8 @@ func handleMsgCreateBid(ctx sdk.Context, keepers Keepers, msg types.MsgCreateBid
 		return nil, types.ErrInternal
 	}
 
-	if order.Price().IsLT(msg.Price) {
-		return nil, types.ErrBidOverOrder
+	if err := validateBidPrice(order, msg.Price); err != nil {
+		return nil, err
 	}
 
 	provider, ok := keepers.Provider.Get(ctx, msg.Provider)
@@ -44,15 +52,39 @@ func handleMsgCreateBid(ctx sdk.Context, keepers Keepers, msg types.MsgCreateBid
 		return nil, types.ErrAtributeMismatch
 	}
 
-	// TODO: ensure not a current bid from this provider
+	if !order.Spec.WithinDuration(msg.MaxDuration) {
+		return nil, types.ErrLeaseDurationExceeded
+	}
 
-	keepers.Market.CreateBid(ctx, msg.Order, msg.Provider, msg.Price)
+	if err := keepers.Market.CreateBid(ctx, msg.Order, msg.Provider, msg.Price, msg.MaxDuration, msg.Deposit); err != nil {
+		return nil, err
+	}
 
 	return &sdk.Result{
 		Events: ctx.EventManager().Events(),
 	}, nil
 }
 
+// validateBidPrice checks the price is positive, in the order's denomination
+// and no higher than the order's price limit.
+func validateBidPrice(order types.Order, price sdk.Coin) error {
+	limit := order.Spec.PriceLimit()
+
+	if !price.IsValid() || !price.IsPositive() {
+		return types.ErrInvalidBidPrice
+	}
+
+	if price.Denom != limit.Denom {
+		return types.ErrBidDenomMismatch
+	}
+
+	if limit.IsLT(price) {
+		return types.ErrBidOverOrder
+	}
+
+	return nil
+}
+
 func handleMsgCloseBid(ctx sdk.Context, keepers Keepers, msg types.MsgCloseBid) (*sdk.Result, error) {
 	bid, ok := keepers.Market.GetBid(ctx, msg.BidID)
 	if !ok {
@@ -77,9 +109,15 @@ func handleMsgCloseBid(ctx sdk.Context, keepers Keepers, msg types.MsgCloseBid)
 		return nil, types.ErrBidNotMatched
 	}
 
-	keepers.Market.OnBidClosed(ctx, bid)
-	keepers.Market.OnLeaseClosed(ctx, lease)
-	keepers.Market.OnOrderClosed(ctx, order)
+	if err := keepers.Market.OnBidClosed(ctx, bid); err != nil {
+		return nil, err
+	}
+	if err := keepers.Market.OnLeaseClosed(ctx, lease); err != nil {
+		return nil, err
+	}
+	if err := keepers.Market.OnOrderClosed(ctx, order); err != nil {
+		return nil, err
+	}
 	keepers.Deployment.OnLeaseClosed(ctx, order.GroupID())
 
 	return &sdk.Result{
@@ -87,19 +125,48 @@ func handleMsgCloseBid(ctx sdk.Context, keepers Keepers, msg types.MsgCloseBid)
 	}, nil
 }
 
+func handleMsgWithdrawBid(ctx sdk.Context, keepers Keepers, msg types.MsgWithdrawBid) (*sdk.Result, error) {
+	if err := keepers.Market.WithdrawBid(ctx, msg.BidID, msg.Provider); err != nil {
+		return nil, err
+	}
+
+	return &sdk.Result{
+		Events: ctx.EventManager().Events(),
+	}, nil
+}
+
 func handleMsgCloseOrder(ctx sdk.Context, keepers Keepers, msg types.MsgCloseOrder) (*sdk.Result, error) {
-	order, ok := keepers.Market.GetOrder(ctx, msg.OrderID)
-	if !ok {
-		return nil, types.ErrUnknownOrder
+	if err := keepers.Market.CloseOrder(ctx, msg.OrderID); err != nil {
+		return nil, err
 	}
+	keepers.Deployment.OnLeaseClosed(ctx, msg.OrderID.GroupID())
+	return &sdk.Result{
+		Events: ctx.EventManager().Events(),
+	}, nil
+}
 
-	lease, ok := keepers.Market.LeaseForOrder(ctx, order.ID())
-	if !ok {
-		return nil, types.ErrNoLeaseForOrder
+func handleMsgCancelOrder(ctx sdk.Context, keepers Keepers, msg types.MsgCancelOrder) (*sdk.Result, error) {
+	if err := keepers.Market.CancelOrder(ctx, msg.OrderID); err != nil {
+		return nil, err
+	}
+	return &sdk.Result{
+		Events: ctx.EventManager().Events(),
+	}, nil
+}
+
+func handleMsgProviderCloseLease(ctx sdk.Context, keepers Keepers, msg types.MsgProviderCloseLease) (*sdk.Result, error) {
+	if err := keepers.Market.ProviderCloseLease(ctx, msg.LeaseID, msg.Provider); err != nil {
+		return nil, err
+	}
+	return &sdk.Result{
+		Events: ctx.EventManager().Events(),
+	}, nil
+}
+
+func handleMsgLeaseHeartbeat(ctx sdk.Context, keepers Keepers, msg types.MsgLeaseHeartbeat) (*sdk.Result, error) {
+	if err := keepers.Market.RecordLeaseHeartbeat(ctx, msg.LeaseID, msg.Provider); err != nil {
+		return nil, err
 	}
-	keepers.Market.OnOrderClosed(ctx, order)
-	keepers.Market.OnLeaseClosed(ctx, lease)
-	keepers.Deployment.OnLeaseClosed(ctx, order.GroupID())
 	return &sdk.Result{
 		Events: ctx.EventManager().Events(),
 	}, nil
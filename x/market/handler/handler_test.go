@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
+	"github.com/ovrclk/akash/x/market/types"
+	ptypes "github.com/ovrclk/akash/x/provider/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBidPrice(t *testing.T) {
+	ctx, keepers := setupKeepers(t)
+
+	provider := testAddress()
+	keepers.Provider = &testProviderKeeper{provider: ptypes.Provider{Owner: provider}}
+
+	gid := dtypes.GroupID{Owner: testAddress(), DSeq: 1, GSeq: 1}
+	order, err := keepers.Market.CreateOrder(ctx, gid, dtypes.GroupSpec{
+		Name:      "test",
+		Resources: []dtypes.Resource{{Count: 1, Price: sdk.NewInt64Coin("akash", 100)}},
+		MaxPrice:  sdk.NewInt64Coin("akash", 50),
+	})
+	require.NoError(t, err)
+
+	createBid := func(price sdk.Coin) error {
+		_, err := NewHandler(keepers)(ctx, types.MsgCreateBid{
+			Order:    order.ID(),
+			Provider: provider,
+			Price:    price,
+			Deposit:  sdk.NewInt64Coin("akash", 0),
+		})
+		return err
+	}
+
+	assert.Equal(t, types.ErrBidDenomMismatch, createBid(sdk.NewInt64Coin("uakt", 10)))
+	assert.Equal(t, types.ErrInvalidBidPrice, createBid(sdk.NewInt64Coin("akash", 0)))
+	// over the max price, though under the resource prices
+	assert.Equal(t, types.ErrBidOverOrder, createBid(sdk.NewInt64Coin("akash", 51)))
+
+	_, ok := keepers.Market.GetBid(ctx, types.MakeBidID(order.ID(), provider))
+	assert.False(t, ok)
+
+	require.NoError(t, createBid(sdk.NewInt64Coin("akash", 50)))
+	_, ok = keepers.Market.GetBid(ctx, types.MakeBidID(order.ID(), provider))
+	assert.True(t, ok)
+}
+
+type testProviderKeeper struct {
+	provider ptypes.Provider
+}
+
+func (k *testProviderKeeper) Get(ctx sdk.Context, id sdk.Address) (ptypes.Provider, bool) {
+	if !k.provider.Owner.Equals(id) {
+		return ptypes.Provider{}, false
+	}
+	return k.provider, true
+}
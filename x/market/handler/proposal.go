@@ -0,0 +1,22 @@
+package handler
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	"github.com/ovrclk/akash/x/market/keeper"
+	"github.com/ovrclk/akash/x/market/types"
+)
+
+// NewProposalHandler handles market governance proposals.  The app adds it to
+// the gov router under types.RouterKey.
+func NewProposalHandler(k keeper.Keeper) govtypes.Handler {
+	return func(ctx sdk.Context, content govtypes.Content) error {
+		switch c := content.(type) {
+		case types.LeasePriceUpdateProposal:
+			return k.UpdateLeasePrices(ctx, c.Updates)
+		default:
+			return sdkerrors.Wrapf(sdkerrors.ErrUnknownRequest, "unrecognized market proposal content type: %T", c)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ovrclk/akash/sdkutil"
+	"github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeasePriceUpdateProposal(t *testing.T) {
+	ctx, keepers := setupKeepers(t)
+
+	lease := createLease(t, ctx, keepers.Market)
+	price := sdk.NewInt64Coin("uakt", 10000)
+
+	proposal := types.LeasePriceUpdateProposal{
+		Title:       "redenominate",
+		Description: "akash -> uakt",
+		Updates:     []types.LeasePriceUpdate{{LeaseID: lease.ID(), Price: price}},
+	}
+	require.NoError(t, proposal.ValidateBasic())
+
+	// not reachable as a regular message
+	_, err := NewHandler(keepers)(ctx, proposal)
+	require.Error(t, err)
+
+	lease, _ = keepers.Market.GetLease(ctx, lease.ID())
+	assert.Equal(t, "10akash", lease.Price.String())
+
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	require.NoError(t, NewProposalHandler(keepers.Market)(ctx, proposal))
+
+	lease, _ = keepers.Market.GetLease(ctx, lease.ID())
+	assert.Equal(t, price.String(), lease.Price.String())
+
+	events := ctx.EventManager().Events()
+	require.Len(t, events, 1)
+
+	attrs := sdk.StringifyEvent(events.ToABCIEvents()[0]).Attributes
+
+	action, err := sdkutil.GetString(attrs, sdk.AttributeKeyAction)
+	require.NoError(t, err)
+	assert.Equal(t, "lease-price-updated", action)
+
+	value, err := sdkutil.GetString(attrs, "price")
+	require.NoError(t, err)
+	assert.Equal(t, price.String(), value)
+
+	id, err := types.ParseEVLeaseID(attrs)
+	require.NoError(t, err)
+	assert.True(t, lease.ID().Equals(id))
+}
+
+func TestLeasePriceUpdateProposal_invalid(t *testing.T) {
+	ctx, keepers := setupKeepers(t)
+
+	lease := createLease(t, ctx, keepers.Market)
+	keepers.Market.OnLeaseClosed(ctx, lease)
+
+	proposal := types.LeasePriceUpdateProposal{
+		Title:       "reprice",
+		Description: "reprice closed lease",
+		Updates:     []types.LeasePriceUpdate{{LeaseID: lease.ID(), Price: sdk.NewInt64Coin("akash", 5)}},
+	}
+
+	err := NewProposalHandler(keepers.Market)(ctx, proposal)
+	assert.Equal(t, types.ErrLeaseNotActive, err)
+
+	proposal.Updates[0].Price = sdk.NewInt64Coin("akash", 0)
+	assert.Error(t, proposal.ValidateBasic())
+}
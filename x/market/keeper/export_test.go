@@ -0,0 +1,23 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
+	"github.com/ovrclk/akash/x/market/types"
+)
+
+// SetOrderSeq exposes setOrderSeq to keeper_test.
+func (k Keeper) SetOrderSeq(ctx sdk.Context, gid dtypes.GroupID, seq uint32) {
+	k.setOrderSeq(ctx, gid, seq)
+}
+
+// WriteV1Order writes only the primary record of order, as version 1 stores
+// did.
+func (k Keeper) WriteV1Order(ctx sdk.Context, order types.Order) {
+	k.updateOrder(ctx, order)
+}
+
+// WriteV1Bid writes only the primary record of bid, as version 1 stores did.
+func (k Keeper) WriteV1Bid(ctx sdk.Context, bid types.Bid) {
+	k.updateBid(ctx, bid)
+}
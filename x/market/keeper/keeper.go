@@ -1,177 +1,959 @@
 package keeper
 
 import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sort"
+
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/x/params"
 	dtypes "github.com/ovrclk/akash/x/deployment/types"
 	"github.com/ovrclk/akash/x/market/types"
 )
 
 const (
-	// TODO: parameterize somewhere.
-	orderTTL = 5 // blocks
+	// number of blocks of events kept in the market event log.
+	eventLogRetention = 10000
+
+	// MaxPageLimit caps the number of items returned in a single page.
+	MaxPageLimit = 100
 )
 
+// BankKeeper pays providers from their tenants' accounts.
+type BankKeeper interface {
+	SendCoins(ctx sdk.Context, from sdk.AccAddress, to sdk.AccAddress, amt sdk.Coins) error
+}
+
+// SupplyKeeper moves bid deposits in and out of the market module account.
+type SupplyKeeper interface {
+	SendCoinsFromAccountToModule(ctx sdk.Context, from sdk.AccAddress, module string, amt sdk.Coins) error
+	SendCoinsFromModuleToAccount(ctx sdk.Context, module string, to sdk.AccAddress, amt sdk.Coins) error
+}
+
 type Keeper struct {
-	cdc  *codec.Codec
-	skey sdk.StoreKey
+	cdc     *codec.Codec
+	skey    sdk.StoreKey
+	pspace  params.Subspace
+	bkeeper BankKeeper
+	skeeper SupplyKeeper
 }
 
-func NewKeeper(cdc *codec.Codec, skey sdk.StoreKey) Keeper {
-	return Keeper{cdc: cdc, skey: skey}
+func NewKeeper(cdc *codec.Codec, skey sdk.StoreKey, pspace params.Subspace, bkeeper BankKeeper, skeeper SupplyKeeper) Keeper {
+	return Keeper{
+		cdc:     cdc,
+		skey:    skey,
+		pspace:  pspace.WithKeyTable(types.ParamKeyTable()),
+		bkeeper: bkeeper,
+		skeeper: skeeper,
+	}
 }
 
 func (k Keeper) Codec() *codec.Codec {
 	return k.cdc
 }
 
-func (k Keeper) CreateOrder(ctx sdk.Context, gid dtypes.GroupID, spec dtypes.GroupSpec) types.Order {
+func (k Keeper) GetParams(ctx sdk.Context) types.Params {
+	var p types.Params
+	k.pspace.GetParamSet(ctx, &p)
+	return p
+}
+
+func (k Keeper) SetParams(ctx sdk.Context, p types.Params) {
+	k.pspace.SetParamSet(ctx, &p)
+}
+
+// CreateOrder opens an order for the group, matchable once the OrderTTL
+// param has passed.  ErrTooManyOpenOrders is
+// returned if the owner is already at the MaxOpenOrdersPerOwner cap, and
+// ErrOrderExists if the assigned order ID is already taken.  Orders are
+// refused rather than wrapping around when the group's oseq is exhausted
+// (ErrOrderSeqOverflow) or the start height overflows (ErrOrderStartOverflow).
+func (k Keeper) CreateOrder(ctx sdk.Context, gid dtypes.GroupID, spec dtypes.GroupSpec) (types.Order, error) {
 	store := ctx.KVStore(k.skey)
+	params := k.GetParams(ctx)
 
-	oseq := uint32(1)
-	k.WithOrdersForGroup(ctx, gid, func(types.Order) bool {
-		oseq++
-		return false
-	})
+	if max := params.MaxOpenOrdersPerOwner; max > 0 && k.countOpenOrders(ctx, gid.Owner) >= max {
+		return types.Order{}, types.ErrTooManyOpenOrders
+	}
+
+	if params.OrderTTL > math.MaxInt64-ctx.BlockHeight() {
+		return types.Order{}, types.ErrOrderStartOverflow
+	}
+
+	oseq, err := k.nextOrderSeq(ctx, gid)
+	if err != nil {
+		return types.Order{}, err
+	}
 
 	order := types.Order{
-		OrderID: types.MakeOrderID(gid, oseq),
-		Spec:    spec,
-		StartAt: ctx.BlockHeight() + orderTTL, // TODO: check overflow
+		OrderID:   types.MakeOrderID(gid, oseq),
+		Spec:      spec,
+		StartAt:   ctx.BlockHeight() + params.OrderTTL,
+		CreatedAt: ctx.BlockHeight(),
 	}
 
 	key := orderKey(order.ID())
 
-	// XXX TODO: check not overwrite
+	if store.Has(key) {
+		return types.Order{}, types.ErrOrderExists
+	}
+
+	k.setOrderSeq(ctx, gid, oseq)
 	store.Set(key, k.cdc.MustMarshalBinaryBare(order))
+	store.Set(orderCreatedKey(order.CreatedAt, order.ID()), key)
 
 	ctx.Logger().Info("created order", "order", order.ID())
-	ctx.EventManager().EmitEvent(
-		types.EventOrderCreated{ID: order.ID()}.ToSDKEvent(),
-	)
-	return order
+	k.emitEvent(ctx, types.EventOrderCreated{ID: order.ID()}.ToSDKEvent())
+	return order, nil
 }
 
-func (k Keeper) CreateBid(ctx sdk.Context, oid types.OrderID, provider sdk.AccAddress, price sdk.Coin) {
+// nextOrderSeq returns the next oseq for the group.  Sequences are never
+// reused, even after orders are closed, so ErrOrderSeqOverflow is returned
+// once the group has used every oseq.  Groups that predate the stored
+// counter continue from their highest existing oseq.
+func (k Keeper) nextOrderSeq(ctx sdk.Context, gid dtypes.GroupID) (uint32, error) {
+	store := ctx.KVStore(k.skey)
+
+	var last uint32
+	if buf := store.Get(orderSeqKey(gid)); buf != nil {
+		last = binary.BigEndian.Uint32(buf)
+	} else {
+		k.WithOrdersForGroup(ctx, gid, func(order types.Order) bool {
+			if order.OSeq > last {
+				last = order.OSeq
+			}
+			return false
+		})
+	}
+
+	if last == math.MaxUint32 {
+		return 0, types.ErrOrderSeqOverflow
+	}
+	return last + 1, nil
+}
+
+// setOrderSeq records seq as the last oseq assigned within the group.
+func (k Keeper) setOrderSeq(ctx sdk.Context, gid dtypes.GroupID, seq uint32) {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, seq)
+	ctx.KVStore(k.skey).Set(orderSeqKey(gid), buf)
+}
+
+func (k Keeper) countOpenOrders(ctx sdk.Context, owner sdk.AccAddress) uint32 {
+	store := ctx.KVStore(k.skey)
+	iter := sdk.KVStorePrefixIterator(store, ordersForOwnerPrefix(owner))
+	defer iter.Close()
+
+	var count uint32
+	for ; iter.Valid(); iter.Next() {
+		var order types.Order
+		k.cdc.MustUnmarshalBinaryBare(iter.Value(), &order)
+		if order.State == types.OrderOpen {
+			count++
+		}
+	}
+	return count
+}
+
+// CreateBid places the provider's bid on the order, moving deposit from the
+// provider into escrow.  The deposit is refunded when the bid is lost or
+// closed, and held for as long as a matched bid's lease runs.  ErrBidExists
+// is returned if the provider has already bid on it, and ErrBidDepositTooLow
+// if the deposit is under the MinBidDeposit param.
+func (k Keeper) CreateBid(ctx sdk.Context, oid types.OrderID, provider sdk.AccAddress,
+	price sdk.Coin, maxDuration int64, deposit sdk.Coin) error {
 
 	store := ctx.KVStore(k.skey)
 
 	bid := types.Bid{
-		BidID: types.MakeBidID(oid, provider),
-		Price: price,
+		BidID:       types.MakeBidID(oid, provider),
+		Price:       price,
+		CreatedAt:   ctx.BlockHeight(),
+		MaxDuration: maxDuration,
+		Deposit:     deposit,
 	}
 
 	key := bidKey(bid.ID())
 
-	// XXX TODO: check not overwrite
+	if store.Has(key) {
+		return types.ErrBidExists
+	}
+
+	if min := k.GetParams(ctx).MinBidDeposit; min.IsPositive() {
+		if deposit.Denom != min.Denom || deposit.IsLT(min) {
+			return types.ErrBidDepositTooLow
+		}
+	}
+
+	if hasDeposit(bid) {
+		if err := k.skeeper.SendCoinsFromAccountToModule(ctx, provider, types.ModuleName, sdk.NewCoins(deposit)); err != nil {
+			return err
+		}
+	}
+
 	store.Set(key, k.cdc.MustMarshalBinaryBare(bid))
+	k.updateProviderBidIndex(ctx, bid)
 
-	ctx.EventManager().EmitEvent(
-		types.EventBidCreated{ID: bid.ID()}.ToSDKEvent(),
-	)
+	k.emitEvent(ctx, types.EventBidCreated{ID: bid.ID(), Deposit: deposit}.ToSDKEvent())
+	return nil
 }
 
-func (k Keeper) CreateLease(ctx sdk.Context, bid types.Bid) {
+// CreateLease creates the lease for a bid.  ErrLeaseExists is returned if the
+// bid already has a lease.
+func (k Keeper) CreateLease(ctx sdk.Context, bid types.Bid) error {
 	store := ctx.KVStore(k.skey)
 
 	lease := types.Lease{
-		LeaseID: types.LeaseID(bid.ID()),
-		Price:   bid.Price,
+		LeaseID:   types.LeaseID(bid.ID()),
+		Price:     bid.Price,
+		Heartbeat: ctx.BlockHeight(),
+		CreatedAt: ctx.BlockHeight(),
+		SettledAt: ctx.BlockHeight(),
+	}
+	if bid.MaxDuration > 0 {
+		lease.ExpiresAt = ctx.BlockHeight() + bid.MaxDuration
+	}
+	if order, ok := k.GetOrder(ctx, bid.OrderID()); ok {
+		lease.Tags = order.Spec.Tags
 	}
 	key := leaseKey(lease.ID())
 
-	// XXX TODO: check not overwrite
+	if store.Has(key) {
+		return types.ErrLeaseExists
+	}
+
 	store.Set(key, k.cdc.MustMarshalBinaryBare(lease))
 	ctx.Logger().Info("created lease", "lease", lease.ID())
-	ctx.EventManager().EmitEvent(
-		types.EventLeaseCreated{ID: lease.ID()}.ToSDKEvent(),
+	k.emitEvent(ctx, types.EventLeaseCreated{ID: lease.ID()}.ToSDKEvent())
+	return nil
+}
+
+// AwardLease matches the order with the given bid: the lease is created, the
+// bid and order are marked matched and all other open bids for the order are
+// marked lost.  The award runs in a cache context, so nothing is written and
+// no events are emitted unless every transition succeeds.
+func (k Keeper) AwardLease(ctx sdk.Context, oid types.OrderID, bid types.Bid) (types.Lease, error) {
+	cctx, write := ctx.CacheContext()
+
+	lease, err := k.awardLease(cctx, oid, bid)
+	if err != nil {
+		return types.Lease{}, err
+	}
+
+	write()
+	ctx.EventManager().EmitEvents(cctx.EventManager().Events())
+	return lease, nil
+}
+
+func (k Keeper) awardLease(ctx sdk.Context, oid types.OrderID, bid types.Bid) (types.Lease, error) {
+	order, ok := k.GetOrder(ctx, oid)
+	if !ok {
+		return types.Lease{}, types.ErrUnknownOrder
+	}
+	if order.State != types.OrderOpen {
+		return types.Lease{}, types.ErrOrderNotOpen
+	}
+
+	if !bid.ID().OrderID().Equals(oid) {
+		return types.Lease{}, types.ErrUnknownOrderForBid
+	}
+	bid, ok = k.GetBid(ctx, bid.ID())
+	if !ok {
+		return types.Lease{}, types.ErrUnknownBid
+	}
+	if bid.State != types.BidOpen {
+		return types.Lease{}, types.ErrBidNotOpen
+	}
+
+	var losers []types.Bid
+	k.WithBidsForOrder(ctx, oid, func(other types.Bid) bool {
+		if other.State == types.BidOpen && !other.ID().Equals(bid.ID()) {
+			losers = append(losers, other)
+		}
+		return false
+	})
+
+	if err := k.CreateLease(ctx, bid); err != nil {
+		return types.Lease{}, err
+	}
+	if err := k.OnBidMatched(ctx, bid); err != nil {
+		return types.Lease{}, err
+	}
+	for _, other := range losers {
+		if err := k.OnBidLost(ctx, other); err != nil {
+			return types.Lease{}, err
+		}
+	}
+	if err := k.OnOrderMatched(ctx, order); err != nil {
+		return types.Lease{}, err
+	}
+
+	lease, _ := k.GetLease(ctx, bid.ID().LeaseID())
+	return lease, nil
+}
+
+// MatchOrders awards a lease on every open order past its StartAt height
+// that has open bids.  The winner is chosen by SelectBid: the cheapest bid,
+// with ties going to the earliest bid and then the lowest provider address,
+// unless the order weights provider uptime or is a Dutch auction.  The
+// created leases are returned.
+func (k Keeper) MatchOrders(ctx sdk.Context) []types.Lease {
+	var orders []types.Order
+	k.WithOrders(ctx, func(order types.Order) bool {
+		if order.ValidateCanMatch(ctx.BlockHeight()) == nil {
+			orders = append(orders, order)
+		}
+		return false
+	})
+
+	var leases []types.Lease
+	for _, order := range orders {
+		var bids []types.Bid
+		k.WithBidsForOrder(ctx, order.ID(), func(bid types.Bid) bool {
+			if bid.State == types.BidOpen {
+				bids = append(bids, bid)
+			}
+			return false
+		})
+
+		winner, ok := k.SelectBid(ctx, order, bids)
+		if !ok {
+			continue
+		}
+
+		lease, err := k.AwardLease(ctx, order.ID(), winner)
+		if err != nil {
+			ctx.Logger().Error("awarding lease", "order", order.ID(), "err", err)
+			continue
+		}
+		leases = append(leases, lease)
+	}
+	return leases
+}
+
+// OrdersByState returns the orders in the given state.  Orders aren't
+// indexed by state, so every order is scanned: O(n) in the number of orders
+// in the store until a secondary state index exists.  Unknown states return
+// without scanning.
+func (k Keeper) OrdersByState(ctx sdk.Context, state types.OrderState) []types.Order {
+	if state > types.OrderClosed {
+		return nil
+	}
+
+	var orders []types.Order
+	k.WithOrders(ctx, func(order types.Order) bool {
+		if order.State == state {
+			orders = append(orders, order)
+		}
+		return false
+	})
+	return orders
+}
+
+// BidsByState returns the bids in the given state.  Like OrdersByState, this
+// is a scan of every bid in the store.
+func (k Keeper) BidsByState(ctx sdk.Context, state types.BidState) []types.Bid {
+	if state > types.BidClosed {
+		return nil
+	}
+
+	var bids []types.Bid
+	k.WithBids(ctx, func(bid types.Bid) bool {
+		if bid.State == state {
+			bids = append(bids, bid)
+		}
+		return false
+	})
+	return bids
+}
+
+// OrdersWithoutBids returns the open orders created at least minAge blocks
+// ago that have not received any bids.
+func (k Keeper) OrdersWithoutBids(ctx sdk.Context, minAge int64) []types.Order {
+	end := ctx.BlockHeight() - minAge + 1
+	if end <= 0 {
+		return nil
+	}
+
+	store := ctx.KVStore(k.skey)
+	iter := store.Iterator(orderCreatedPrefix, orderCreatedHeightPrefix(end))
+	defer iter.Close()
+
+	var orders []types.Order
+	for ; iter.Valid(); iter.Next() {
+		buf := store.Get(iter.Value())
+		if buf == nil {
+			continue
+		}
+
+		var order types.Order
+		k.cdc.MustUnmarshalBinaryBare(buf, &order)
+		if order.State != types.OrderOpen {
+			continue
+		}
+
+		bids := false
+		k.WithBidsForOrder(ctx, order.ID(), func(types.Bid) bool {
+			bids = true
+			return true
+		})
+		if !bids {
+			orders = append(orders, order)
+		}
+	}
+	return orders
+}
+
+// BestBidForOrder returns the cheapest open bid for the order.
+func (k Keeper) BestBidForOrder(ctx sdk.Context, id types.OrderID) (types.Bid, bool) {
+	var (
+		best  types.Bid
+		found bool
 	)
+	k.WithBidsForOrder(ctx, id, func(bid types.Bid) bool {
+		if bid.State != types.BidOpen {
+			return false
+		}
+		if !found || bid.CheaperThan(best) {
+			best = bid
+			found = true
+		}
+		return false
+	})
+	return best, found
+}
+
+// SelectBid picks the winning bid for the order among open bids.  Orders
+// without an uptime weight are awarded to the cheapest bid.  Otherwise each
+// bid is scored by its price relative to the cheapest bid and its provider's
+// uptime, weighted by the order's UptimeWeight, and the highest score wins;
+// ties go to the cheaper bid.  Dutch auction orders are awarded by
+// selectDutchBid.
+func (k Keeper) SelectBid(ctx sdk.Context, order types.Order, bids []types.Bid) (types.Bid, bool) {
+	if len(bids) == 0 {
+		return types.Bid{}, false
+	}
+
+	if order.Spec.PricingMode == dtypes.PricingDutch {
+		return k.selectDutchBid(ctx, order, bids)
+	}
+
+	cheapest := bids[0]
+	for _, bid := range bids[1:] {
+		if bid.CheaperThan(cheapest) {
+			cheapest = bid
+		}
+	}
+
+	if order.Spec.UptimeWeight == 0 {
+		return cheapest, true
+	}
+
+	weight := sdk.NewDec(int64(order.Spec.UptimeWeight)).QuoInt64(dtypes.MaxUptimeWeight)
+	if weight.GT(sdk.OneDec()) {
+		weight = sdk.OneDec()
+	}
+
+	uptimes := make(map[string]sdk.Dec)
+
+	score := func(bid types.Bid) sdk.Dec {
+		uptime, ok := uptimes[bid.Provider.String()]
+		if !ok {
+			uptime = k.ProviderUptime(ctx, bid.Provider)
+			uptimes[bid.Provider.String()] = uptime
+		}
+		price := bidPriceScore(bid, cheapest)
+		return sdk.OneDec().Sub(weight).Mul(price).Add(weight.Mul(uptime))
+	}
+
+	best := cheapest
+	bestScore := score(cheapest)
+	for _, bid := range bids {
+		bscore := score(bid)
+		if bscore.GT(bestScore) || (bscore.Equal(bestScore) && bid.CheaperThan(best)) {
+			best, bestScore = bid, bscore
+		}
+	}
+	return best, true
 }
 
-func (k Keeper) OnOrderMatched(ctx sdk.Context, order types.Order) {
-	// TODO: assert state transition
+// selectDutchBid picks the earliest bid at or below the order's current
+// Dutch auction ceiling, with ties going to the cheaper bid.  No bid is
+// selected while every bid is above the ceiling.
+func (k Keeper) selectDutchBid(ctx sdk.Context, order types.Order, bids []types.Bid) (types.Bid, bool) {
+	params := k.GetParams(ctx)
+	ceiling := order.DutchCeiling(ctx.BlockHeight(), params.DutchAuctionWindow, params.DutchAuctionFloor)
+
+	var (
+		winner types.Bid
+		found  bool
+	)
+	for _, bid := range bids {
+		if bid.Price.Denom != ceiling.Denom || ceiling.IsLT(bid.Price) {
+			continue
+		}
+		if !found || bid.CreatedAt < winner.CreatedAt ||
+			(bid.CreatedAt == winner.CreatedAt && bid.CheaperThan(winner)) {
+			winner, found = bid, true
+		}
+	}
+	return winner, found
+}
+
+// bidPriceScore rates a bid's price from 0 to 1 relative to the cheapest
+// bid, which rates 1.  Bids in a different denomination rate 0.
+func bidPriceScore(bid, cheapest types.Bid) sdk.Dec {
+	if bid.Price.Denom != cheapest.Price.Denom {
+		return sdk.ZeroDec()
+	}
+	if bid.Price.Amount.IsZero() {
+		return sdk.OneDec()
+	}
+	return sdk.NewDecFromInt(cheapest.Price.Amount).Quo(sdk.NewDecFromInt(bid.Price.Amount))
+}
+
+// ProviderUptime returns the fraction of the provider's leases that were not
+// closed for missing heartbeats.  Providers without leases rate 1.
+func (k Keeper) ProviderUptime(ctx sdk.Context, provider sdk.AccAddress) sdk.Dec {
+	var total, unresponsive int64
+	k.WithBidsForProvider(ctx, provider, func(bid types.Bid) bool {
+		lease, ok := k.GetLease(ctx, bid.ID().LeaseID())
+		if !ok {
+			return false
+		}
+		total++
+		if lease.CloseReason == types.LeaseCloseReasonProviderUnresponsive {
+			unresponsive++
+		}
+		return false
+	})
+
+	if total == 0 {
+		return sdk.OneDec()
+	}
+	return sdk.NewDec(total - unresponsive).QuoInt64(total)
+}
+
+// OnOrderMatched marks the order matched.
+func (k Keeper) OnOrderMatched(ctx sdk.Context, order types.Order) error {
+	if err := assertTransition(order.State, types.OrderMatched); err != nil {
+		return err
+	}
 	order.State = types.OrderMatched
 	k.updateOrder(ctx, order)
+	return nil
 }
 
-func (k Keeper) OnBidMatched(ctx sdk.Context, bid types.Bid) {
-	// TODO: assert state transition
+// OnBidMatched marks the bid matched.  Its deposit stays in escrow until the
+// bid is closed.
+func (k Keeper) OnBidMatched(ctx sdk.Context, bid types.Bid) error {
+	if err := assertTransition(bid.State, types.BidMatched); err != nil {
+		return err
+	}
 	bid.State = types.BidMatched
 	k.updateBid(ctx, bid)
+	k.updateProviderBidIndex(ctx, bid)
+	return nil
 }
 
-func (k Keeper) OnBidLost(ctx sdk.Context, bid types.Bid) {
-	// TODO: assert state transition
+// OnBidLost marks the bid lost and refunds its deposit, recording the refund
+// on the bid-lost event.  Bids already lost or closed are left as they are.
+func (k Keeper) OnBidLost(ctx sdk.Context, bid types.Bid) error {
+	switch bid.State {
+	case types.BidClosed, types.BidLost:
+		return nil
+	}
+	if err := assertTransition(bid.State, types.BidLost); err != nil {
+		return err
+	}
 	bid.State = types.BidLost
 	k.updateBid(ctx, bid)
+	k.updateProviderBidIndex(ctx, bid)
+	k.refundDeposit(ctx, bid)
+
+	ev := types.EventBidLost{ID: bid.ID()}
+	if hasDeposit(bid) {
+		ev.Refund = bid.Deposit
+		ev.Recipient = bid.Provider
+	}
+	k.emitEvent(ctx, ev.ToSDKEvent())
+	return nil
 }
 
-func (k Keeper) OnBidClosed(ctx sdk.Context, bid types.Bid) {
-	// TODO: assert state transition
+// OnBidClosed marks the bid closed and refunds its deposit, recording the
+// refund on the bid-closed event.  Bids already lost or closed are left as
+// they are.
+func (k Keeper) OnBidClosed(ctx sdk.Context, bid types.Bid) error {
 	switch bid.State {
 	case types.BidClosed, types.BidLost:
-		return
+		return nil
+	}
+	if err := assertTransition(bid.State, types.BidClosed); err != nil {
+		return err
 	}
 	bid.State = types.BidClosed
 	k.updateBid(ctx, bid)
-	ctx.EventManager().EmitEvent(
-		types.EventBidClosed{ID: bid.ID()}.ToSDKEvent(),
-	)
+	k.updateProviderBidIndex(ctx, bid)
+	k.refundDeposit(ctx, bid)
+
+	ev := types.EventBidClosed{ID: bid.ID()}
+	if hasDeposit(bid) {
+		ev.Refund = bid.Deposit
+		ev.Recipient = bid.Provider
+	}
+	k.emitEvent(ctx, ev.ToSDKEvent())
+	return nil
+}
+
+// refundDeposit returns the bid's deposit to its provider.  Escrow holds the
+// deposit of every open and matched bid, so failing to refund it is a bug.
+func (k Keeper) refundDeposit(ctx sdk.Context, bid types.Bid) {
+	if !hasDeposit(bid) {
+		return
+	}
+	if err := k.skeeper.SendCoinsFromModuleToAccount(ctx, types.ModuleName, bid.Provider, sdk.NewCoins(bid.Deposit)); err != nil {
+		panic(err)
+	}
+}
+
+func hasDeposit(bid types.Bid) bool {
+	return bid.Deposit.Denom != "" && !bid.Deposit.IsZero()
+}
+
+// OnOrderClosed marks the order closed.  Closed orders are left as they are.
+func (k Keeper) OnOrderClosed(ctx sdk.Context, order types.Order) error {
+	switch order.State {
+	case types.OrderClosed:
+		return nil
+	}
+	if err := assertTransition(order.State, types.OrderClosed); err != nil {
+		return err
+	}
+	order.State = types.OrderClosed
+	k.updateOrder(ctx, order)
+	k.emitEvent(ctx, types.EventOrderClosed{ID: order.ID()}.ToSDKEvent())
+	return nil
+}
+
+// OnInsufficientFunds closes the lease for lack of funds.  Leases already
+// closed are left as they are.
+func (k Keeper) OnInsufficientFunds(ctx sdk.Context, lease types.Lease) error {
+	switch lease.State {
+	case types.LeaseClosed, types.LeaseInsufficientFunds:
+		return nil
+	}
+	if err := assertTransition(lease.State, types.LeaseInsufficientFunds); err != nil {
+		return err
+	}
+	lease.State = types.LeaseInsufficientFunds
+	lease.ClosedAt = ctx.BlockHeight()
+	k.updateLease(ctx, lease)
+	k.emitEvent(ctx, types.EventLeaseClosed{ID: lease.ID()}.ToSDKEvent())
+	return nil
+}
+
+// OnLeaseClosed closes the lease.  Leases already closed are left as they
+// are.
+func (k Keeper) OnLeaseClosed(ctx sdk.Context, lease types.Lease) error {
+	switch lease.State {
+	case types.LeaseClosed, types.LeaseInsufficientFunds:
+		return nil
+	}
+	if err := assertTransition(lease.State, types.LeaseClosed); err != nil {
+		return err
+	}
+	lease.State = types.LeaseClosed
+	lease.ClosedAt = ctx.BlockHeight()
+	k.updateLease(ctx, lease)
+	ctx.Logger().Info("closed lease", "lease", lease.ID())
+	k.emitEvent(ctx, types.EventLeaseClosed{ID: lease.ID()}.ToSDKEvent())
+	return nil
+}
+
+// SettleLease pays the provider of an active lease Price for every block
+// since it was last settled, from the tenant's account.  If the tenant can't
+// cover the amount nothing is paid, the lease is closed with
+// OnInsufficientFunds, its bid is closed and refunded, and
+// ErrLeaseUnderfunded is returned.
+func (k Keeper) SettleLease(ctx sdk.Context, lease types.Lease) error {
+	if lease.State != types.LeaseActive {
+		return types.ErrLeaseNotActive
+	}
+
+	// leases created before settlement was tracked were paid every block.
+	from := lease.SettledAt
+	if from == 0 {
+		from = ctx.BlockHeight() - 1
+	}
+
+	blocks := ctx.BlockHeight() - from
+	if blocks <= 0 {
+		return nil
+	}
+
+	if lease.Price.IsValid() && !lease.Price.IsZero() {
+		amt := sdk.NewCoins(sdk.NewCoin(lease.Price.Denom, lease.Price.Amount.MulRaw(blocks)))
+
+		if err := k.bkeeper.SendCoins(ctx, lease.Owner, lease.Provider, amt); err != nil {
+			if !sdkerrors.ErrInsufficientFunds.Is(err) {
+				return err
+			}
+			if err := k.OnInsufficientFunds(ctx, lease); err != nil {
+				return err
+			}
+			if bid, ok := k.GetBid(ctx, lease.BidID()); ok {
+				if err := k.OnBidClosed(ctx, bid); err != nil {
+					return err
+				}
+			}
+			return types.ErrLeaseUnderfunded
+		}
+	}
+
+	lease.SettledAt = ctx.BlockHeight()
+	k.updateLease(ctx, lease)
+	return nil
+}
+
+// CloseOrder closes a leased order along with its lease and the matched bid,
+// refunding the bid's deposit.
+func (k Keeper) CloseOrder(ctx sdk.Context, id types.OrderID) error {
+	order, ok := k.GetOrder(ctx, id)
+	if !ok {
+		return types.ErrUnknownOrder
+	}
+
+	lease, ok := k.LeaseForOrder(ctx, id)
+	if !ok {
+		return types.ErrNoLeaseForOrder
+	}
+
+	if bid, ok := k.GetBid(ctx, lease.BidID()); ok {
+		if err := k.OnBidClosed(ctx, bid); err != nil {
+			return err
+		}
+	}
+	if err := k.OnOrderClosed(ctx, order); err != nil {
+		return err
+	}
+	return k.OnLeaseClosed(ctx, lease)
+}
+
+// CancelOrder closes an open order that has not been leased, along with any
+// open bids on it.  Authorization is by signature: the order owner signs the
+// cancel message.
+func (k Keeper) CancelOrder(ctx sdk.Context, id types.OrderID) error {
+	order, ok := k.GetOrder(ctx, id)
+	if !ok {
+		return types.ErrUnknownOrder
+	}
+
+	if _, ok := k.LeaseForOrder(ctx, id); ok {
+		return types.ErrOrderLeased
+	}
+
+	if order.State != types.OrderOpen {
+		return types.ErrOrderNotOpen
+	}
+
+	var err error
+	k.WithBidsForOrder(ctx, id, func(bid types.Bid) bool {
+		err = k.OnBidClosed(ctx, bid)
+		return err != nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := k.OnOrderClosed(ctx, order); err != nil {
+		return err
+	}
+
+	ctx.Logger().Info("canceled order", "order", order.ID())
+	k.emitEvent(ctx, types.EventOrderCanceled{ID: order.ID()}.ToSDKEvent())
+	return nil
+}
+
+// WithdrawBid closes the provider's open bid before the order is matched,
+// refunding its deposit.  Matched bids are closed with their lease instead.
+func (k Keeper) WithdrawBid(ctx sdk.Context, id types.BidID, provider sdk.AccAddress) error {
+	bid, ok := k.GetBid(ctx, id)
+	if !ok {
+		return types.ErrUnknownBid
+	}
+
+	if !bid.Provider.Equals(provider) {
+		return types.ErrInvalidBidProvider
+	}
+
+	if bid.State != types.BidOpen {
+		return types.ErrBidNotOpen
+	}
+
+	if err := k.OnBidClosed(ctx, bid); err != nil {
+		return err
+	}
+
+	ctx.Logger().Info("withdrew bid", "bid", bid.ID())
+	return nil
 }
 
-func (k Keeper) OnOrderClosed(ctx sdk.Context, order types.Order) {
-	// TODO: assert state transition
-	switch order.State {
-	case types.OrderClosed:
-		return
+// ProviderCloseLease schedules an active lease to be closed by its provider
+// once the notice window has elapsed.
+func (k Keeper) ProviderCloseLease(ctx sdk.Context, id types.LeaseID, provider sdk.AccAddress) error {
+	lease, ok := k.GetLease(ctx, id)
+	if !ok {
+		return types.ErrUnknownLease
+	}
+
+	if !lease.Provider.Equals(provider) {
+		return types.ErrInvalidLeaseProvider
+	}
+
+	if lease.State != types.LeaseActive {
+		return types.ErrLeaseNotActive
+	}
+
+	if lease.CloseAt != 0 {
+		return types.ErrLeaseCloseScheduled
+	}
+
+	lease.CloseAt = ctx.BlockHeight() + k.GetParams(ctx).LeaseCloseNotice
+	k.updateLease(ctx, lease)
+
+	ctx.Logger().Info("scheduled lease close", "lease", lease.ID(), "close-at", lease.CloseAt)
+	k.emitEvent(ctx, types.EventLeaseProviderClose{ID: lease.ID(), CloseAt: lease.CloseAt}.ToSDKEvent())
+	return nil
+}
+
+// RecordLeaseHeartbeat marks an active lease as still being served by its provider.
+func (k Keeper) RecordLeaseHeartbeat(ctx sdk.Context, id types.LeaseID, provider sdk.AccAddress) error {
+	lease, ok := k.GetLease(ctx, id)
+	if !ok {
+		return types.ErrUnknownLease
+	}
+
+	if !lease.Provider.Equals(provider) {
+		return types.ErrInvalidLeaseProvider
+	}
+
+	if lease.State != types.LeaseActive {
+		return types.ErrLeaseNotActive
+	}
+
+	lease.Heartbeat = ctx.BlockHeight()
+	k.updateLease(ctx, lease)
+	return nil
+}
+
+// CloseUnresponsiveLeases closes active leases whose provider has missed the
+// heartbeat threshold, along with their bids and orders.  The closed leases
+// are returned so callers can notify other modules.
+func (k Keeper) CloseUnresponsiveLeases(ctx sdk.Context) []types.Lease {
+	threshold := k.GetParams(ctx).LeaseHeartbeatThreshold
+
+	var leases []types.Lease
+	k.WithLeases(ctx, func(lease types.Lease) bool {
+		if lease.HeartbeatMissed(ctx.BlockHeight(), threshold) {
+			leases = append(leases, lease)
+		}
+		return false
+	})
+
+	for idx, lease := range leases {
+		if bid, ok := k.GetBid(ctx, lease.BidID()); ok {
+			if err := k.OnBidClosed(ctx, bid); err != nil {
+				ctx.Logger().Error("closing bid", "bid", bid.ID(), "err", err)
+			}
+		}
+
+		lease.CloseReason = types.LeaseCloseReasonProviderUnresponsive
+		if err := k.OnLeaseClosed(ctx, lease); err != nil {
+			ctx.Logger().Error("closing lease", "lease", lease.ID(), "err", err)
+		}
+
+		if order, ok := k.GetOrder(ctx, lease.OrderID()); ok {
+			if err := k.OnOrderClosed(ctx, order); err != nil {
+				ctx.Logger().Error("closing order", "order", order.ID(), "err", err)
+			}
+		}
+
+		ctx.Logger().Info("provider unresponsive", "lease", lease.ID(), "heartbeat", lease.Heartbeat)
+		k.emitEvent(ctx, types.EventLeaseProviderUnresponsive{ID: lease.ID()}.ToSDKEvent())
+
+		leases[idx], _ = k.GetLease(ctx, lease.ID())
 	}
-	order.State = types.OrderClosed
-	k.updateOrder(ctx, order)
-	ctx.EventManager().EmitEvent(
-		types.EventOrderClosed{ID: order.ID()}.ToSDKEvent(),
-	)
+
+	return leases
 }
 
-func (k Keeper) OnInsufficientFunds(ctx sdk.Context, lease types.Lease) {
-	// TODO: assert state transition
-	switch lease.State {
-	case types.LeaseClosed, types.LeaseInsufficientFunds:
-		return
+// UpdateLeasePrices reprices active leases.  Every update is validated before
+// any is applied.  It must only be reached through a governance proposal.
+func (k Keeper) UpdateLeasePrices(ctx sdk.Context, updates []types.LeasePriceUpdate) error {
+	leases := make([]types.Lease, 0, len(updates))
+
+	for _, update := range updates {
+		if !update.Price.IsValid() || update.Price.IsZero() {
+			return types.ErrInvalidLeasePrice
+		}
+
+		lease, ok := k.GetLease(ctx, update.LeaseID)
+		if !ok {
+			return types.ErrUnknownLease
+		}
+
+		if lease.State != types.LeaseActive {
+			return types.ErrLeaseNotActive
+		}
+
+		lease.Price = update.Price
+		leases = append(leases, lease)
 	}
-	lease.State = types.LeaseInsufficientFunds
-	k.updateLease(ctx, lease)
-	ctx.EventManager().EmitEvent(
-		types.EventLeaseClosed{ID: lease.ID()}.ToSDKEvent(),
-	)
-}
 
-func (k Keeper) OnLeaseClosed(ctx sdk.Context, lease types.Lease) {
-	// TODO: assert state transition
-	switch lease.State {
-	case types.LeaseClosed, types.LeaseInsufficientFunds:
-		return
+	for _, lease := range leases {
+		k.updateLease(ctx, lease)
+		ctx.Logger().Info("updated lease price", "lease", lease.ID(), "price", lease.Price)
+		k.emitEvent(ctx, types.EventLeasePriceUpdated{ID: lease.ID(), Price: lease.Price}.ToSDKEvent())
 	}
-	lease.State = types.LeaseClosed
-	k.updateLease(ctx, lease)
-	ctx.Logger().Info("closed lease", "lease", lease.ID())
-	ctx.EventManager().EmitEvent(
-		types.EventLeaseClosed{ID: lease.ID()}.ToSDKEvent(),
-	)
+
+	return nil
 }
 
+// OnGroupClosed closes the group's orders along with their bids and leases,
+// emitting the close event of each.  Errors are logged rather than returned
+// so that one bad record doesn't leave the rest of the group open.
 func (k Keeper) OnGroupClosed(ctx sdk.Context, id dtypes.GroupID) {
+	var orders []types.Order
 	k.WithOrdersForGroup(ctx, id, func(order types.Order) bool {
-		k.OnOrderClosed(ctx, order)
+		orders = append(orders, order)
+		return false
+	})
+
+	for _, order := range orders {
+		var bids []types.Bid
 		k.WithBidsForOrder(ctx, order.ID(), func(bid types.Bid) bool {
-			k.OnBidClosed(ctx, bid)
-			if lease, ok := k.GetLease(ctx, types.LeaseID(bid.ID())); ok {
-				// TODO: emit events
-				k.OnLeaseClosed(ctx, lease)
-			}
+			bids = append(bids, bid)
 			return false
 		})
-		return false
-	})
+
+		for _, bid := range bids {
+			if err := k.OnBidClosed(ctx, bid); err != nil {
+				ctx.Logger().Error("closing bid", "bid", bid.ID(), "err", err)
+			}
+			if lease, ok := k.GetLease(ctx, types.LeaseID(bid.ID())); ok {
+				if err := k.OnLeaseClosed(ctx, lease); err != nil {
+					ctx.Logger().Error("closing lease", "lease", lease.ID(), "err", err)
+				}
+			}
+		}
+
+		if err := k.OnOrderClosed(ctx, order); err != nil {
+			ctx.Logger().Error("closing order", "order", order.ID(), "err", err)
+		}
+	}
 }
 
 func (k Keeper) GetOrder(ctx sdk.Context, id types.OrderID) (types.Order, bool) {
@@ -236,6 +1018,215 @@ func (k Keeper) LeaseForOrder(ctx sdk.Context, oid types.OrderID) (types.Lease,
 	return value, found
 }
 
+// ActiveProviders returns the distinct providers holding active leases along
+// with the number of active leases each provider holds.
+func (k Keeper) ActiveProviders(ctx sdk.Context) []types.ProviderLeaseCount {
+	var values []types.ProviderLeaseCount
+	index := make(map[string]int)
+
+	k.WithLeases(ctx, func(lease types.Lease) bool {
+		if lease.State != types.LeaseActive {
+			return false
+		}
+		key := lease.Provider.String()
+		if idx, ok := index[key]; ok {
+			values[idx].Leases++
+			return false
+		}
+		index[key] = len(values)
+		values = append(values, types.ProviderLeaseCount{
+			Provider: lease.Provider,
+			Leases:   1,
+		})
+		return false
+	})
+
+	sort.Slice(values, func(i, j int) bool {
+		return bytes.Compare(values[i].Provider, values[j].Provider) < 0
+	})
+
+	return values
+}
+
+// EstimateOrderPrice returns the median price of leases whose orders requested
+// the same resources as spec.  It returns false when there is no such lease.
+func (k Keeper) EstimateOrderPrice(ctx sdk.Context, spec dtypes.GroupSpec) (sdk.Coin, bool) {
+	denom := spec.Price().Denom
+
+	var amounts []sdk.Int
+
+	k.WithLeases(ctx, func(lease types.Lease) bool {
+		if lease.Price.Denom != denom {
+			return false
+		}
+		order, ok := k.GetOrder(ctx, lease.OrderID())
+		if !ok || !specResourcesEqual(order.Spec, spec) {
+			return false
+		}
+		amounts = append(amounts, lease.Price.Amount)
+		return false
+	})
+
+	if len(amounts) == 0 {
+		return sdk.Coin{}, false
+	}
+
+	sort.Slice(amounts, func(i, j int) bool {
+		return amounts[i].LT(amounts[j])
+	})
+
+	mid := len(amounts) / 2
+	median := amounts[mid]
+	if len(amounts)%2 == 0 {
+		median = amounts[mid-1].Add(median).QuoRaw(2)
+	}
+
+	return sdk.NewCoin(denom, median), true
+}
+
+// PriceHistory returns the prices of the leases created within the last
+// window blocks whose orders requested resources with the given
+// GroupSpec.ResourcesHash, oldest first.
+func (k Keeper) PriceHistory(ctx sdk.Context, specHash []byte, window int64) []types.PricePoint {
+	from := ctx.BlockHeight() - window
+
+	var points []types.PricePoint
+	k.WithLeases(ctx, func(lease types.Lease) bool {
+		if lease.CreatedAt <= from {
+			return false
+		}
+		order, ok := k.GetOrder(ctx, lease.OrderID())
+		if !ok || !bytes.Equal(order.Spec.ResourcesHash(), specHash) {
+			return false
+		}
+		points = append(points, types.PricePoint{Height: lease.CreatedAt, Price: lease.Price})
+		return false
+	})
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Height < points[j].Height
+	})
+	return points
+}
+
+// ResourceSupplyDemand sums the resources requested by open orders and the
+// resources committed to active leases.
+func (k Keeper) ResourceSupplyDemand(ctx sdk.Context) types.ResourceSupplyDemand {
+	var value types.ResourceSupplyDemand
+
+	k.WithOrders(ctx, func(order types.Order) bool {
+		if order.State == types.OrderOpen {
+			value.Demand.Add(order.Spec)
+		}
+		return false
+	})
+
+	k.WithLeases(ctx, func(lease types.Lease) bool {
+		if lease.State != types.LeaseActive {
+			return false
+		}
+		if order, ok := k.GetOrder(ctx, lease.OrderID()); ok {
+			value.Committed.Add(order.Spec)
+		}
+		return false
+	})
+
+	return value
+}
+
+// ProviderBidStats counts the bids a provider placed between the from and to
+// heights (inclusive), and how many of those were won or lost.  Bids that were
+// matched and later closed still count as won.  Lost and withdrawn bids are
+// dropped from the provider bid index, so every bid is scanned.
+func (k Keeper) ProviderBidStats(ctx sdk.Context, provider sdk.AccAddress, from, to int64) types.ProviderBidStats {
+	stats := types.ProviderBidStats{Provider: provider}
+
+	k.WithBids(ctx, func(bid types.Bid) bool {
+		if !bid.Provider.Equals(provider) || bid.CreatedAt < from || bid.CreatedAt > to {
+			return false
+		}
+		stats.Bids++
+		switch bid.State {
+		case types.BidMatched:
+			stats.Won++
+		case types.BidLost:
+			stats.Lost++
+		case types.BidClosed:
+			if _, ok := k.GetLease(ctx, bid.ID().LeaseID()); ok {
+				stats.Won++
+			}
+		}
+		return false
+	})
+
+	stats.WinRate = sdk.ZeroDec()
+	if decided := stats.Won + stats.Lost; decided > 0 {
+		stats.WinRate = sdk.NewDec(int64(stats.Won)).QuoInt64(int64(decided))
+	}
+
+	return stats
+}
+
+// LeaseDurationStats computes the mean and median duration, in blocks, of
+// closed leases, including those closed for insufficient funds.  Durations
+// are truncated to whole blocks.  A zero Count means there are no closed
+// leases.
+func (k Keeper) LeaseDurationStats(ctx sdk.Context) types.LeaseDurationStats {
+	var durations []int64
+
+	k.WithLeases(ctx, func(lease types.Lease) bool {
+		switch lease.State {
+		case types.LeaseClosed, types.LeaseInsufficientFunds:
+		default:
+			return false
+		}
+		if lease.ClosedAt < lease.CreatedAt {
+			return false
+		}
+		durations = append(durations, lease.ClosedAt-lease.CreatedAt)
+		return false
+	})
+
+	if len(durations) == 0 {
+		return types.LeaseDurationStats{}
+	}
+
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+
+	var total int64
+	for _, d := range durations {
+		total += d
+	}
+
+	mid := len(durations) / 2
+	median := durations[mid]
+	if len(durations)%2 == 0 {
+		median = (durations[mid-1] + median) / 2
+	}
+
+	return types.LeaseDurationStats{
+		Count:  uint32(len(durations)),
+		Mean:   total / int64(len(durations)),
+		Median: median,
+	}
+}
+
+// TotalEscrowed returns the bid deposits held in escrow, those of open and
+// matched bids.  Leases are paid by their tenant every block and hold no
+// balance of their own.
+func (k Keeper) TotalEscrowed(ctx sdk.Context) sdk.Coins {
+	total := sdk.NewCoins()
+	k.WithBids(ctx, func(bid types.Bid) bool {
+		if hasDeposit(bid) && (bid.State == types.BidOpen || bid.State == types.BidMatched) {
+			total = total.Add(bid.Deposit)
+		}
+		return false
+	})
+	return total
+}
+
 func (k Keeper) WithOrders(ctx sdk.Context, fn func(types.Order) bool) {
 	store := ctx.KVStore(k.skey)
 	iter := sdk.KVStorePrefixIterator(store, orderPrefix)
@@ -270,24 +1261,252 @@ func (k Keeper) WithLeases(ctx sdk.Context, fn func(types.Lease) bool) {
 	}
 }
 
-func (k Keeper) WithOrdersForGroup(ctx sdk.Context, id dtypes.GroupID, fn func(types.Order) bool) {
-	// TODO: do it correctly with prefix search
-	k.WithOrders(ctx, func(item types.Order) bool {
-		if item.GroupID().Equals(id) {
-			return fn(item)
+// WithLeasesForDeployment iterates the leases of a single deployment generation.
+func (k Keeper) WithLeasesForDeployment(ctx sdk.Context, id dtypes.DeploymentID, fn func(types.Lease) bool) {
+	store := ctx.KVStore(k.skey)
+	iter := sdk.KVStorePrefixIterator(store, leasesForDeploymentPrefix(id))
+	for ; iter.Valid(); iter.Next() {
+		var val types.Lease
+		k.cdc.MustUnmarshalBinaryBare(iter.Value(), &val)
+		if stop := fn(val); stop {
+			break
+		}
+	}
+}
+
+// WithBidsForProvider iterates the provider's open and matched bids, and
+// closed bids that won a lease, in order ID order, through the provider bid
+// index.
+func (k Keeper) WithBidsForProvider(ctx sdk.Context, provider sdk.AccAddress, fn func(types.Bid) bool) {
+	store := ctx.KVStore(k.skey)
+	iter := sdk.KVStorePrefixIterator(store, providerBidsPrefix(provider))
+	for ; iter.Valid(); iter.Next() {
+		var val types.Bid
+		k.cdc.MustUnmarshalBinaryBare(store.Get(iter.Value()), &val)
+		if stop := fn(val); stop {
+			break
+		}
+	}
+}
+
+// ImportOrder writes an order read from genesis, along with its creation
+// height index entry.
+func (k Keeper) ImportOrder(ctx sdk.Context, order types.Order) {
+	key := orderKey(order.ID())
+	k.updateOrder(ctx, order)
+	ctx.KVStore(k.skey).Set(orderCreatedKey(order.CreatedAt, order.ID()), key)
+}
+
+// ImportBid writes a bid read from genesis.  Only the primary record is
+// written; RebuildProviderBidIndex must be called once all bids and leases
+// are imported.
+func (k Keeper) ImportBid(ctx sdk.Context, bid types.Bid) {
+	k.updateBid(ctx, bid)
+}
+
+// ImportLease writes a lease read from genesis.
+func (k Keeper) ImportLease(ctx sdk.Context, lease types.Lease) {
+	k.updateLease(ctx, lease)
+}
+
+// ImportOrderSeq records seq as the last oseq assigned within the group.
+func (k Keeper) ImportOrderSeq(ctx sdk.Context, gid dtypes.GroupID, seq uint32) {
+	k.setOrderSeq(ctx, gid, seq)
+}
+
+// WithOrderSeqs iterates the last oseq assigned within each group.
+func (k Keeper) WithOrderSeqs(ctx sdk.Context, fn func(dtypes.GroupID, uint32) bool) {
+	store := ctx.KVStore(k.skey)
+	iter := sdk.KVStorePrefixIterator(store, orderSeqPrefix)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		gid := parseOrderSeqKey(iter.Key())
+		if stop := fn(gid, binary.BigEndian.Uint32(iter.Value())); stop {
+			break
 		}
+	}
+}
+
+// RebuildProviderBidIndex discards the provider bid index and reconstructs
+// it from the primary bid records.
+func (k Keeper) RebuildProviderBidIndex(ctx sdk.Context) {
+	store := ctx.KVStore(k.skey)
+
+	var stale [][]byte
+	iter := sdk.KVStorePrefixIterator(store, providerBidPrefix)
+	for ; iter.Valid(); iter.Next() {
+		stale = append(stale, iter.Key())
+	}
+	iter.Close()
+	for _, key := range stale {
+		store.Delete(key)
+	}
+
+	k.WithBids(ctx, func(bid types.Bid) bool {
+		k.updateProviderBidIndex(ctx, bid)
 		return false
 	})
 }
 
+// updateProviderBidIndex adds bid to the provider bid index, or removes it.
+// The index holds the bids that are still live, open or matched, and closed
+// bids that won a lease, which ProviderUptime rates the provider on.  Lost
+// bids and bids closed without a lease are removed so that the index doesn't
+// grow with every bid placed.
+func (k Keeper) updateProviderBidIndex(ctx sdk.Context, bid types.Bid) {
+	store := ctx.KVStore(k.skey)
+	key := providerBidKey(bid.ID())
+
+	indexed := false
+	switch bid.State {
+	case types.BidOpen, types.BidMatched:
+		indexed = true
+	case types.BidClosed:
+		_, indexed = k.GetLease(ctx, bid.ID().LeaseID())
+	}
+
+	if indexed {
+		store.Set(key, bidKey(bid.ID()))
+	} else {
+		store.Delete(key)
+	}
+}
+
+// WithOrdersForGroup iterates the orders of a single group.
+func (k Keeper) WithOrdersForGroup(ctx sdk.Context, id dtypes.GroupID, fn func(types.Order) bool) {
+	store := ctx.KVStore(k.skey)
+	iter := sdk.KVStorePrefixIterator(store, ordersForGroupPrefix(id))
+	for ; iter.Valid(); iter.Next() {
+		var val types.Order
+		k.cdc.MustUnmarshalBinaryBare(iter.Value(), &val)
+		if stop := fn(val); stop {
+			break
+		}
+	}
+}
+
+// WithBidsForOrder iterates the bids placed on a single order.
 func (k Keeper) WithBidsForOrder(ctx sdk.Context, id types.OrderID, fn func(types.Bid) bool) {
-	// TODO: do it correctly with prefix search
-	k.WithBids(ctx, func(item types.Bid) bool {
-		if item.OrderID().Equals(id) {
-			return fn(item)
+	store := ctx.KVStore(k.skey)
+	iter := sdk.KVStorePrefixIterator(store, bidsForOrderPrefix(id))
+	for ; iter.Valid(); iter.Next() {
+		var val types.Bid
+		k.cdc.MustUnmarshalBinaryBare(iter.Value(), &val)
+		if stop := fn(val); stop {
+			break
+		}
+	}
+}
+
+// BidsForOrderPage returns one page of the bids for an order.  Pages start at
+// 1 and limit is capped at MaxPageLimit.  The returned next page is zero when
+// there are no further bids.
+func (k Keeper) BidsForOrderPage(ctx sdk.Context, id types.OrderID, page, limit uint32) ([]types.Bid, uint32) {
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 || limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	var (
+		bids  []types.Bid
+		next  uint32
+		skip  = uint64(page-1) * uint64(limit)
+		count uint64
+	)
+
+	k.WithBidsForOrder(ctx, id, func(bid types.Bid) bool {
+		count++
+		if count <= skip {
+			return false
 		}
+		if len(bids) == int(limit) {
+			next = page + 1
+			return true
+		}
+		bids = append(bids, bid)
 		return false
 	})
+
+	return bids, next
+}
+
+// MarketEvents returns the logged market events between the given heights
+// (inclusive), in the order they were emitted.
+func (k Keeper) MarketEvents(ctx sdk.Context, from, to int64) []types.MarketEvent {
+	var events []types.MarketEvent
+	if from < 0 {
+		from = 0
+	}
+	if to < from {
+		return events
+	}
+
+	store := ctx.KVStore(k.skey)
+	iter := store.Iterator(eventLogHeightPrefix(from), eventLogHeightPrefix(to+1))
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		var ev types.MarketEvent
+		k.cdc.MustUnmarshalBinaryBare(iter.Value(), &ev)
+		events = append(events, ev)
+	}
+	return events
+}
+
+// PruneEventLog deletes market event log entries older than
+// eventLogRetention blocks.  It is called once per block from EndBlock.
+func (k Keeper) PruneEventLog(ctx sdk.Context) {
+	cutoff := ctx.BlockHeight() - eventLogRetention
+	if cutoff <= 0 {
+		return
+	}
+
+	store := ctx.KVStore(k.skey)
+	iter := store.Iterator(eventLogPrefix, eventLogHeightPrefix(cutoff))
+	var stale [][]byte
+	for ; iter.Valid(); iter.Next() {
+		stale = append(stale, iter.Key())
+	}
+	iter.Close()
+
+	for _, key := range stale {
+		store.Delete(key)
+	}
+}
+
+// emitEvent emits ev and records it in the market event log.
+func (k Keeper) emitEvent(ctx sdk.Context, ev sdk.Event) {
+	ctx.EventManager().EmitEvent(ev)
+
+	store := ctx.KVStore(k.skey)
+	height := ctx.BlockHeight()
+	seq := k.nextEventSeq(ctx)
+
+	entry := types.MarketEvent{Height: height, Event: sdk.StringifyEvent(ev.ToABCIEvents()[0])}
+	store.Set(eventLogKey(height, seq), k.cdc.MustMarshalBinaryBare(entry))
+}
+
+// nextEventSeq returns the sequence of the next event logged at the current
+// height.  The counter stores the height it was last used at, so it restarts
+// from zero on each new block.
+func (k Keeper) nextEventSeq(ctx sdk.Context) uint32 {
+	store := ctx.KVStore(k.skey)
+	height := ctx.BlockHeight()
+
+	var seq uint32
+	if buf := store.Get(eventSeqKey); len(buf) == 12 && int64(binary.BigEndian.Uint64(buf)) == height {
+		seq = binary.BigEndian.Uint32(buf[8:])
+	}
+
+	buf := make([]byte, 12)
+	binary.BigEndian.PutUint64(buf, uint64(height))
+	binary.BigEndian.PutUint32(buf[8:], seq+1)
+	store.Set(eventSeqKey, buf)
+
+	return seq
 }
 
 func (k Keeper) updateOrder(ctx sdk.Context, order types.Order) {
@@ -307,3 +1526,16 @@ func (k Keeper) updateLease(ctx sdk.Context, lease types.Lease) {
 	key := leaseKey(lease.ID())
 	store.Set(key, k.cdc.MustMarshalBinaryBare(lease))
 }
+
+func specResourcesEqual(a, b dtypes.GroupSpec) bool {
+	if len(a.Resources) != len(b.Resources) {
+		return false
+	}
+	for idx := range a.Resources {
+		if !a.Resources[idx].Unit.Equals(b.Resources[idx].Unit) ||
+			a.Resources[idx].Count != b.Resources[idx].Count {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,1882 @@
+package keeper_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/x/params"
+	"github.com/cosmos/cosmos-sdk/x/supply"
+	"github.com/ovrclk/akash/sdkutil"
+	"github.com/ovrclk/akash/types"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
+	"github.com/ovrclk/akash/x/market/keeper"
+	mtypes "github.com/ovrclk/akash/x/market/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmkv "github.com/tendermint/tendermint/libs/kv"
+	"github.com/tendermint/tendermint/libs/log"
+	dbm "github.com/tendermint/tm-db"
+)
+
+func TestKeeper_ActiveProviders(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	p1 := testAddress()
+	p2 := testAddress()
+	p3 := testAddress()
+
+	createLease(t, ctx, k, createOrder(t, ctx, k, 1), p1)
+	createLease(t, ctx, k, createOrder(t, ctx, k, 2), p1)
+	createLease(t, ctx, k, createOrder(t, ctx, k, 3), p2)
+
+	k.OnLeaseClosed(ctx, createLease(t, ctx, k, createOrder(t, ctx, k, 4), p2))
+	k.OnLeaseClosed(ctx, createLease(t, ctx, k, createOrder(t, ctx, k, 5), p3))
+
+	providers := k.ActiveProviders(ctx)
+	require.Len(t, providers, 2)
+
+	counts := make(map[string]uint32)
+	for _, p := range providers {
+		counts[p.Provider.String()] = p.Leases
+	}
+
+	assert.Equal(t, uint32(2), counts[p1.String()])
+	assert.Equal(t, uint32(1), counts[p2.String()])
+	assert.NotContains(t, counts, p3.String())
+}
+
+func TestKeeper_EstimateOrderPrice(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	spec := testGroupSpec()
+
+	_, ok := k.EstimateOrderPrice(ctx, spec)
+	assert.False(t, ok)
+
+	for idx, price := range []int64{30, 10, 20, 40} {
+		order := createOrder(t, ctx, k, uint64(idx+1))
+		bid := createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", price))
+		require.NoError(t, k.CreateLease(ctx, bid))
+	}
+
+	// different resources; ignored.
+	other := testGroupSpec()
+	other.Resources[0].Count = 3
+	order, err := k.CreateOrder(ctx, dtypes.GroupID{Owner: testAddress(), DSeq: 10, GSeq: 1}, other)
+	require.NoError(t, err)
+	require.NoError(t, k.CreateLease(ctx, createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 1000))))
+
+	price, ok := k.EstimateOrderPrice(ctx, spec)
+	require.True(t, ok)
+	assert.Equal(t, "25akash", price.String())
+
+	price, ok = k.EstimateOrderPrice(ctx, other)
+	require.True(t, ok)
+	assert.Equal(t, "1000akash", price.String())
+}
+
+func TestKeeper_PriceHistory(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	spec := testGroupSpec()
+	hash := spec.ResourcesHash()
+
+	// same resources under another name and price hash the same
+	renamed := testGroupSpec()
+	renamed.Name = "renamed"
+	renamed.Resources[0].Price = sdk.NewInt64Coin("akash", 1)
+	assert.Equal(t, hash, renamed.ResourcesHash())
+
+	other := testGroupSpec()
+	other.Resources[0].Count = 3
+	assert.NotEqual(t, hash, other.ResourcesHash())
+
+	lease := func(height int64, dseq uint64, spec dtypes.GroupSpec, price int64) {
+		lctx := ctx.WithBlockHeight(height)
+		order, err := k.CreateOrder(lctx, dtypes.GroupID{Owner: testAddress(), DSeq: dseq, GSeq: 1}, spec)
+		require.NoError(t, err)
+		bid := createBid(t, lctx, k, order, testAddress(), sdk.NewInt64Coin("akash", price))
+		require.NoError(t, k.CreateLease(lctx, bid))
+	}
+
+	lease(30, 1, spec, 20)
+	lease(10, 2, spec, 30)
+	lease(20, 3, renamed, 10)
+	lease(25, 4, other, 1000)
+
+	ctx = ctx.WithBlockHeight(30)
+
+	points := k.PriceHistory(ctx, hash, 30)
+	assert.Equal(t, []mtypes.PricePoint{
+		{Height: 10, Price: sdk.NewInt64Coin("akash", 30)},
+		{Height: 20, Price: sdk.NewInt64Coin("akash", 10)},
+		{Height: 30, Price: sdk.NewInt64Coin("akash", 20)},
+	}, points)
+
+	// leases at heights 21 through 30
+	points = k.PriceHistory(ctx, hash, 10)
+	assert.Equal(t, []mtypes.PricePoint{
+		{Height: 30, Price: sdk.NewInt64Coin("akash", 20)},
+	}, points)
+
+	assert.Empty(t, k.PriceHistory(ctx, []byte("unknown"), 30))
+}
+
+func TestKeeper_ProviderCloseLease(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	params := k.GetParams(ctx)
+	params.LeaseCloseNotice = 10
+	k.SetParams(ctx, params)
+
+	provider := testAddress()
+	lease := createLease(t, ctx, k, createOrder(t, ctx, k, 1), provider)
+
+	err := k.ProviderCloseLease(ctx, lease.ID(), testAddress())
+	assert.Equal(t, mtypes.ErrInvalidLeaseProvider, err)
+
+	require.NoError(t, k.ProviderCloseLease(ctx, lease.ID(), provider))
+
+	err = k.ProviderCloseLease(ctx, lease.ID(), provider)
+	assert.Equal(t, mtypes.ErrLeaseCloseScheduled, err)
+
+	lease, ok := k.GetLease(ctx, lease.ID())
+	require.True(t, ok)
+	assert.Equal(t, mtypes.LeaseActive, lease.State)
+	assert.Equal(t, ctx.BlockHeight()+10, lease.CloseAt)
+
+	assert.False(t, lease.CloseDue(ctx.BlockHeight()+9))
+	assert.True(t, lease.CloseDue(ctx.BlockHeight()+10))
+}
+
+func TestKeeper_CloseUnresponsiveLeases(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	params := k.GetParams(ctx)
+	params.LeaseHeartbeatThreshold = 10
+	k.SetParams(ctx, params)
+
+	stale := createLease(t, ctx, k, createOrder(t, ctx, k, 1), testAddress())
+	healthy := createLease(t, ctx, k, createOrder(t, ctx, k, 2), testAddress())
+
+	err := k.RecordLeaseHeartbeat(ctx, healthy.ID(), testAddress())
+	assert.Equal(t, mtypes.ErrInvalidLeaseProvider, err)
+
+	require.NoError(t, k.RecordLeaseHeartbeat(ctx.WithBlockHeight(ctx.BlockHeight()+5), healthy.ID(), healthy.Provider))
+
+	assert.Empty(t, k.CloseUnresponsiveLeases(ctx.WithBlockHeight(ctx.BlockHeight()+9)))
+
+	closed := k.CloseUnresponsiveLeases(ctx.WithBlockHeight(ctx.BlockHeight() + 10))
+	require.Len(t, closed, 1)
+	assert.True(t, stale.ID().Equals(closed[0].ID()))
+
+	stale, ok := k.GetLease(ctx, stale.ID())
+	require.True(t, ok)
+	assert.Equal(t, mtypes.LeaseClosed, stale.State)
+	assert.Equal(t, mtypes.LeaseCloseReasonProviderUnresponsive, stale.CloseReason)
+
+	bid, _ := k.GetBid(ctx, stale.BidID())
+	assert.Equal(t, mtypes.BidClosed, bid.State)
+
+	order, _ := k.GetOrder(ctx, stale.OrderID())
+	assert.Equal(t, mtypes.OrderClosed, order.State)
+
+	healthy, ok = k.GetLease(ctx, healthy.ID())
+	require.True(t, ok)
+	assert.Equal(t, mtypes.LeaseActive, healthy.State)
+
+	err = k.RecordLeaseHeartbeat(ctx, stale.ID(), stale.Provider)
+	assert.Equal(t, mtypes.ErrLeaseNotActive, err)
+}
+
+func TestKeeper_CloseUnresponsiveLeasesWithoutHeartbeat(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	params := k.GetParams(ctx)
+	params.LeaseHeartbeatThreshold = 10
+	k.SetParams(ctx, params)
+
+	// leases written before heartbeats were recorded
+	created := createLease(t, ctx, k, createOrder(t, ctx, k, 1), testAddress())
+	created.Heartbeat = 0
+	k.ImportLease(ctx, created)
+
+	unknown := createLease(t, ctx, k, createOrder(t, ctx, k, 2), testAddress())
+	unknown.Heartbeat = 0
+	unknown.CreatedAt = 0
+	k.ImportLease(ctx, unknown)
+
+	assert.Empty(t, k.CloseUnresponsiveLeases(ctx.WithBlockHeight(ctx.BlockHeight()+9)))
+
+	// counted from creation
+	closed := k.CloseUnresponsiveLeases(ctx.WithBlockHeight(ctx.BlockHeight() + 10))
+	require.Len(t, closed, 1)
+	assert.Equal(t, created.ID(), closed[0].ID())
+
+	unknown, _ = k.GetLease(ctx, unknown.ID())
+	assert.Equal(t, mtypes.LeaseActive, unknown.State)
+	assert.Empty(t, k.CloseUnresponsiveLeases(ctx.WithBlockHeight(ctx.BlockHeight()+1000)))
+}
+
+func TestKeeper_ResourceSupplyDemand(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	// open orders: 2 x testGroupSpec
+	createOrder(t, ctx, k, 1)
+	createOrder(t, ctx, k, 2)
+
+	// active leases: 3 x testGroupSpec
+	for dseq := uint64(3); dseq <= 5; dseq++ {
+		order := createOrder(t, ctx, k, dseq)
+		createLease(t, ctx, k, order, testAddress())
+		k.OnOrderMatched(ctx, order)
+	}
+
+	// closed lease; ignored
+	order := createOrder(t, ctx, k, 6)
+	k.OnOrderMatched(ctx, order)
+	k.OnLeaseClosed(ctx, createLease(t, ctx, k, order, testAddress()))
+
+	value := k.ResourceSupplyDemand(ctx)
+
+	assert.Equal(t, mtypes.ResourceTotals{
+		CPU:     2 * 100,
+		Memory:  2 * 128 * 1024 * 1024,
+		Storage: 2 * 512 * 1024 * 1024,
+	}, value.Demand)
+
+	assert.Equal(t, mtypes.ResourceTotals{
+		CPU:     3 * 100,
+		Memory:  3 * 128 * 1024 * 1024,
+		Storage: 3 * 512 * 1024 * 1024,
+	}, value.Committed)
+}
+
+func TestKeeper_ProviderBidStats(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	provider := testAddress()
+
+	// won
+	for dseq := uint64(1); dseq <= 3; dseq++ {
+		bid := createBid(t, ctx, k, createOrder(t, ctx, k, dseq), provider, sdk.NewInt64Coin("akash", 10))
+		require.NoError(t, k.CreateLease(ctx, bid))
+		k.OnBidMatched(ctx, bid)
+	}
+
+	// won, then closed
+	bid := createBid(t, ctx, k, createOrder(t, ctx, k, 4), provider, sdk.NewInt64Coin("akash", 10))
+	require.NoError(t, k.CreateLease(ctx, bid))
+	k.OnBidMatched(ctx, bid)
+	bid, _ = k.GetBid(ctx, bid.ID())
+	k.OnBidClosed(ctx, bid)
+
+	// lost
+	k.OnBidLost(ctx, createBid(t, ctx, k, createOrder(t, ctx, k, 5), provider, sdk.NewInt64Coin("akash", 10)))
+
+	// open
+	createBid(t, ctx, k, createOrder(t, ctx, k, 6), provider, sdk.NewInt64Coin("akash", 10))
+
+	// outside height range
+	later := ctx.WithBlockHeight(ctx.BlockHeight() + 100)
+	k.OnBidLost(later, createBid(t, later, k, createOrder(t, later, k, 7), provider, sdk.NewInt64Coin("akash", 10)))
+
+	// other provider
+	k.OnBidLost(ctx, createBid(t, ctx, k, createOrder(t, ctx, k, 8), testAddress(), sdk.NewInt64Coin("akash", 10)))
+
+	stats := k.ProviderBidStats(ctx, provider, ctx.BlockHeight(), ctx.BlockHeight()+10)
+	assert.Equal(t, uint32(6), stats.Bids)
+	assert.Equal(t, uint32(4), stats.Won)
+	assert.Equal(t, uint32(1), stats.Lost)
+	assert.Equal(t, "0.800000000000000000", stats.WinRate.String())
+
+	stats = k.ProviderBidStats(ctx, testAddress(), 0, ctx.BlockHeight()+100)
+	assert.Equal(t, uint32(0), stats.Bids)
+	assert.True(t, stats.WinRate.IsZero())
+}
+
+func TestKeeper_BidsForOrderPage(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	order := createOrder(t, ctx, k, 1)
+	for i := 0; i < 50; i++ {
+		createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 10))
+	}
+
+	// bids on another order; never returned
+	other := createOrder(t, ctx, k, 2)
+	createBid(t, ctx, k, other, testAddress(), sdk.NewInt64Coin("akash", 10))
+
+	seen := make(map[string]bool)
+	page := uint32(1)
+	pages := 0
+
+	for page != 0 {
+		bids, next := k.BidsForOrderPage(ctx, order.ID(), page, 20)
+		pages++
+		for _, bid := range bids {
+			require.True(t, bid.OrderID().Equals(order.ID()))
+			require.False(t, seen[bid.Provider.String()])
+			seen[bid.Provider.String()] = true
+		}
+		if next != 0 {
+			assert.Len(t, bids, 20)
+		}
+		page = next
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Len(t, seen, 50)
+
+	// limit is capped
+	bids, next := k.BidsForOrderPage(ctx, order.ID(), 1, keeper.MaxPageLimit+1)
+	assert.Len(t, bids, 50)
+	assert.Equal(t, uint32(0), next)
+
+	// past the end
+	bids, next = k.BidsForOrderPage(ctx, order.ID(), 4, 20)
+	assert.Empty(t, bids)
+	assert.Equal(t, uint32(0), next)
+}
+
+func TestKeeper_Paginated(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	const count = 250
+	for dseq := uint64(1); dseq <= count; dseq++ {
+		createLease(t, ctx, k, createOrder(t, ctx, k, dseq), testAddress())
+	}
+
+	type walk func(mtypes.PageRequest, func(string) bool) mtypes.PageResponse
+
+	walks := map[string]walk{
+		"orders": func(req mtypes.PageRequest, fn func(string) bool) mtypes.PageResponse {
+			return k.WithOrdersPaginated(ctx, req, func(obj mtypes.Order) bool {
+				return fn(obj.ID().Owner.String())
+			})
+		},
+		"bids": func(req mtypes.PageRequest, fn func(string) bool) mtypes.PageResponse {
+			return k.WithBidsPaginated(ctx, req, func(obj mtypes.Bid) bool {
+				return fn(obj.ID().Owner.String())
+			})
+		},
+		"leases": func(req mtypes.PageRequest, fn func(string) bool) mtypes.PageResponse {
+			return k.WithLeasesPaginated(ctx, req, func(obj mtypes.Lease) bool {
+				return fn(obj.ID().Owner.String())
+			})
+		},
+	}
+
+	for name, walk := range walks {
+		t.Run(name, func(t *testing.T) {
+			seen := make(map[string]bool)
+			visit := func(owner string) bool {
+				require.False(t, seen[owner])
+				seen[owner] = true
+				return false
+			}
+
+			req := mtypes.PageRequest{Limit: 60, CountTotal: true}
+			pages := 0
+			for {
+				res := walk(req, visit)
+				pages++
+				assert.Equal(t, uint64(count), res.Total)
+				if res.NextKey == nil {
+					break
+				}
+				assert.Len(t, seen, pages*60)
+				req.Key = res.NextKey
+			}
+			assert.Equal(t, 5, pages)
+			assert.Len(t, seen, count)
+
+			// limit is capped and total is only counted on request
+			n := 0
+			res := walk(mtypes.PageRequest{Limit: keeper.MaxPageLimit + 1}, func(string) bool {
+				n++
+				return false
+			})
+			assert.Equal(t, keeper.MaxPageLimit, n)
+			assert.NotNil(t, res.NextKey)
+			assert.Zero(t, res.Total)
+
+			// stopping early resumes after the last item seen
+			var first []string
+			res = walk(mtypes.PageRequest{}, func(owner string) bool {
+				first = append(first, owner)
+				return len(first) == 5
+			})
+			require.Len(t, first, 5)
+
+			var second []string
+			walk(mtypes.PageRequest{Key: res.NextKey, Limit: 1}, func(owner string) bool {
+				second = append(second, owner)
+				return false
+			})
+			require.Len(t, second, 1)
+			assert.NotContains(t, first, second[0])
+		})
+	}
+}
+
+func TestKeeper_WithBidsForOrder(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	var orders []mtypes.Order
+	for dseq := uint64(1); dseq <= 10; dseq++ {
+		order := createOrder(t, ctx, k, dseq)
+		for i := 0; i < 3; i++ {
+			createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 10))
+		}
+		orders = append(orders, order)
+	}
+	order := orders[4]
+
+	// trace store access while iterating a single order's bids
+	trace := new(bytes.Buffer)
+	ctx.MultiStore().SetTracer(trace)
+
+	var found []mtypes.BidID
+	k.WithBidsForOrder(ctx, order.ID(), func(bid mtypes.Bid) bool {
+		found = append(found, bid.ID())
+		return false
+	})
+
+	ctx.MultiStore().SetTracer(nil)
+
+	require.Len(t, found, 3)
+	for _, id := range found {
+		assert.True(t, id.OrderID().Equals(order.ID()))
+	}
+
+	// every value read belongs to the order
+	values := 0
+	scanner := bufio.NewScanner(trace)
+	for scanner.Scan() {
+		var op struct {
+			Operation string `json:"operation"`
+			Value     string `json:"value"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &op))
+		if op.Operation != "iterValue" {
+			continue
+		}
+		buf, err := base64.StdEncoding.DecodeString(op.Value)
+		require.NoError(t, err)
+
+		var bid mtypes.Bid
+		require.NoError(t, k.Codec().UnmarshalBinaryBare(buf, &bid))
+		assert.True(t, bid.OrderID().Equals(order.ID()))
+		values++
+	}
+	assert.NotZero(t, values)
+}
+
+func TestKeeper_LeaseDurationStats(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	stats := k.LeaseDurationStats(ctx)
+	assert.Equal(t, uint32(0), stats.Count)
+
+	for idx, duration := range []int64{10, 40, 20, 30} {
+		lease := createLease(t, ctx, k, createOrder(t, ctx, k, uint64(idx+1)), testAddress())
+		k.OnLeaseClosed(ctx.WithBlockHeight(ctx.BlockHeight()+duration), lease)
+	}
+
+	// still active; ignored
+	createLease(t, ctx, k, createOrder(t, ctx, k, 10), testAddress())
+
+	stats = k.LeaseDurationStats(ctx)
+	assert.Equal(t, uint32(4), stats.Count)
+	assert.Equal(t, int64(25), stats.Mean)
+	assert.Equal(t, int64(25), stats.Median)
+
+	lease := createLease(t, ctx, k, createOrder(t, ctx, k, 11), testAddress())
+	k.OnLeaseClosed(ctx.WithBlockHeight(ctx.BlockHeight()+100), lease)
+
+	stats = k.LeaseDurationStats(ctx)
+	assert.Equal(t, uint32(5), stats.Count)
+	assert.Equal(t, int64(40), stats.Mean)
+	assert.Equal(t, int64(30), stats.Median)
+
+	// closed for insufficient funds
+	lease = createLease(t, ctx, k, createOrder(t, ctx, k, 12), testAddress())
+	require.NoError(t, k.OnInsufficientFunds(ctx.WithBlockHeight(ctx.BlockHeight()+50), lease))
+
+	stats = k.LeaseDurationStats(ctx)
+	assert.Equal(t, uint32(6), stats.Count)
+	assert.Equal(t, int64(41), stats.Mean)
+	assert.Equal(t, int64(35), stats.Median)
+}
+
+func TestKeeper_CancelOrder(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	order := createOrder(t, ctx, k, 1)
+	bid := createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 10))
+
+	require.NoError(t, k.CancelOrder(ctx, order.ID()))
+
+	order, _ = k.GetOrder(ctx, order.ID())
+	assert.Equal(t, mtypes.OrderClosed, order.State)
+
+	bid, _ = k.GetBid(ctx, bid.ID())
+	assert.Equal(t, mtypes.BidClosed, bid.State)
+
+	assert.Equal(t, mtypes.ErrOrderNotOpen, k.CancelOrder(ctx, order.ID()))
+
+	// leased
+	leased := createOrder(t, ctx, k, 2)
+	bid = createBid(t, ctx, k, leased, testAddress(), sdk.NewInt64Coin("akash", 10))
+	require.NoError(t, k.CreateLease(ctx, bid))
+	k.OnBidMatched(ctx, bid)
+	k.OnOrderMatched(ctx, leased)
+
+	assert.Equal(t, mtypes.ErrOrderLeased, k.CancelOrder(ctx, leased.ID()))
+
+	leased, _ = k.GetOrder(ctx, leased.ID())
+	assert.Equal(t, mtypes.OrderMatched, leased.State)
+
+	unknown := mtypes.MakeOrderID(dtypes.GroupID{Owner: testAddress(), DSeq: 1, GSeq: 1}, 1)
+	assert.Equal(t, mtypes.ErrUnknownOrder, k.CancelOrder(ctx, unknown))
+}
+
+func TestKeeper_AwardLease(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	order := createOrder(t, ctx, k, 1)
+	winner := createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 10))
+	loser1 := createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 20))
+	loser2 := createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 30))
+
+	// unrelated order is untouched
+	other := createOrder(t, ctx, k, 2)
+	otherBid := createBid(t, ctx, k, other, testAddress(), sdk.NewInt64Coin("akash", 10))
+
+	lease, err := k.AwardLease(ctx, order.ID(), winner)
+	require.NoError(t, err)
+	assert.Equal(t, winner.ID().LeaseID(), lease.ID())
+	assert.Equal(t, winner.Price, lease.Price)
+	assert.Equal(t, mtypes.LeaseActive, lease.State)
+
+	stored, ok := k.GetLease(ctx, lease.ID())
+	require.True(t, ok)
+	assert.Equal(t, lease, stored)
+
+	order, _ = k.GetOrder(ctx, order.ID())
+	assert.Equal(t, mtypes.OrderMatched, order.State)
+
+	winner, _ = k.GetBid(ctx, winner.ID())
+	assert.Equal(t, mtypes.BidMatched, winner.State)
+
+	for _, id := range []mtypes.BidID{loser1.ID(), loser2.ID()} {
+		bid, _ := k.GetBid(ctx, id)
+		assert.Equal(t, mtypes.BidLost, bid.State)
+	}
+
+	other, _ = k.GetOrder(ctx, other.ID())
+	assert.Equal(t, mtypes.OrderOpen, other.State)
+	otherBid, _ = k.GetBid(ctx, otherBid.ID())
+	assert.Equal(t, mtypes.BidOpen, otherBid.State)
+
+	assert.Contains(t, eventActions(k.MarketEvents(ctx, 0, ctx.BlockHeight())), "lease-created")
+
+	// order already matched
+	_, err = k.AwardLease(ctx, order.ID(), loser1)
+	assert.Equal(t, mtypes.ErrOrderNotOpen, err)
+
+	// bid for another order
+	_, err = k.AwardLease(ctx, other.ID(), loser1)
+	assert.Equal(t, mtypes.ErrUnknownOrderForBid, err)
+	other, _ = k.GetOrder(ctx, other.ID())
+	assert.Equal(t, mtypes.OrderOpen, other.State)
+}
+
+func TestKeeper_AwardLeaseWritesNothingOnFailure(t *testing.T) {
+	ctx, k, bank := setupKeeperWithBank(t)
+
+	deposit := sdk.NewInt64Coin("akash", 30)
+
+	order := createOrder(t, ctx, k, 1)
+	winner := createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 10))
+	loser := testAddress()
+	bank.balances[loser.String()] = sdk.NewCoins(deposit)
+	require.NoError(t, k.CreateBid(ctx, order.ID(), loser, sdk.NewInt64Coin("akash", 20), 0, deposit))
+
+	// the losing bid's refund fails after the lease has been written
+	bank.balances[mtypes.EscrowAddress.String()] = sdk.NewCoins()
+
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	assert.Panics(t, func() { _, _ = k.AwardLease(ctx, order.ID(), winner) })
+
+	_, ok := k.GetLease(ctx, winner.ID().LeaseID())
+	assert.False(t, ok)
+	order, _ = k.GetOrder(ctx, order.ID())
+	assert.Equal(t, mtypes.OrderOpen, order.State)
+	winner, _ = k.GetBid(ctx, winner.ID())
+	assert.Equal(t, mtypes.BidOpen, winner.State)
+	assert.Empty(t, ctx.EventManager().Events())
+}
+
+func TestKeeper_BidDeposit(t *testing.T) {
+	ctx, k, bank := setupKeeperWithBank(t)
+
+	deposit := sdk.NewInt64Coin("akash", 30)
+	funds := sdk.NewCoins(sdk.NewInt64Coin("akash", 100))
+
+	order := createOrder(t, ctx, k, 1)
+	winner, loser := testAddress(), testAddress()
+	bank.balances[winner.String()] = funds
+	bank.balances[loser.String()] = funds
+
+	require.NoError(t, k.CreateBid(ctx, order.ID(), winner, sdk.NewInt64Coin("akash", 10), 0, deposit))
+	require.NoError(t, k.CreateBid(ctx, order.ID(), loser, sdk.NewInt64Coin("akash", 20), 0, deposit))
+
+	bid, ok := k.GetBid(ctx, mtypes.MakeBidID(order.ID(), winner))
+	require.True(t, ok)
+	assert.Equal(t, deposit, bid.Deposit)
+
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("akash", 70)), bank.balances[winner.String()])
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("akash", 60)), bank.balances[mtypes.EscrowAddress.String()])
+
+	// provider can't cover the deposit
+	poor := testAddress()
+	err := k.CreateBid(ctx, order.ID(), poor, sdk.NewInt64Coin("akash", 10), 0, deposit)
+	assert.True(t, sdkerrors.ErrInsufficientFunds.Is(err))
+	_, ok = k.GetBid(ctx, mtypes.MakeBidID(order.ID(), poor))
+	assert.False(t, ok)
+
+	// lost bids are refunded, the matched bid's deposit is held
+	_, err = k.AwardLease(ctx, order.ID(), bid)
+	require.NoError(t, err)
+
+	assert.Equal(t, funds, bank.balances[loser.String()])
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("akash", 70)), bank.balances[winner.String()])
+	assert.Equal(t, sdk.NewCoins(deposit), bank.balances[mtypes.EscrowAddress.String()])
+
+	// closed bids are refunded
+	bid, _ = k.GetBid(ctx, bid.ID())
+	k.OnBidClosed(ctx, bid)
+	assert.Equal(t, funds, bank.balances[winner.String()])
+	assert.True(t, bank.balances[mtypes.EscrowAddress.String()].IsZero())
+
+	// closing again doesn't refund twice
+	bid, _ = k.GetBid(ctx, bid.ID())
+	k.OnBidClosed(ctx, bid)
+	assert.Equal(t, funds, bank.balances[winner.String()])
+
+	// open bids are refunded when their order is canceled
+	order = createOrder(t, ctx, k, 2)
+	require.NoError(t, k.CreateBid(ctx, order.ID(), loser, sdk.NewInt64Coin("akash", 10), 0, deposit))
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("akash", 70)), bank.balances[loser.String()])
+
+	require.NoError(t, k.CancelOrder(ctx, order.ID()))
+	assert.Equal(t, funds, bank.balances[loser.String()])
+	assert.True(t, bank.balances[mtypes.EscrowAddress.String()].IsZero())
+}
+
+func TestKeeper_MinBidDeposit(t *testing.T) {
+	ctx, k, bank := setupKeeperWithBank(t)
+
+	params := k.GetParams(ctx)
+	params.MinBidDeposit = sdk.NewInt64Coin("akash", 20)
+	k.SetParams(ctx, params)
+
+	order := createOrder(t, ctx, k, 1)
+	provider := testAddress()
+	bank.balances[provider.String()] = sdk.NewCoins(sdk.NewInt64Coin("akash", 100), sdk.NewInt64Coin("stake", 100))
+
+	for _, deposit := range []sdk.Coin{
+		sdk.NewInt64Coin("akash", 0),
+		sdk.NewInt64Coin("akash", 19),
+		sdk.NewInt64Coin("stake", 50),
+	} {
+		err := k.CreateBid(ctx, order.ID(), provider, sdk.NewInt64Coin("akash", 10), 0, deposit)
+		assert.Equal(t, mtypes.ErrBidDepositTooLow, err, deposit.String())
+	}
+	_, ok := k.GetBid(ctx, mtypes.MakeBidID(order.ID(), provider))
+	assert.False(t, ok)
+	assert.Empty(t, bank.balances[mtypes.EscrowAddress.String()])
+
+	require.NoError(t, k.CreateBid(ctx, order.ID(), provider, sdk.NewInt64Coin("akash", 10), 0, sdk.NewInt64Coin("akash", 20)))
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("akash", 20)), bank.balances[mtypes.EscrowAddress.String()])
+}
+
+func TestKeeper_TotalEscrowed(t *testing.T) {
+	ctx, k, bank := setupKeeperWithBank(t)
+
+	assert.True(t, k.TotalEscrowed(ctx).IsZero())
+
+	deposit := sdk.NewInt64Coin("akash", 30)
+	funds := sdk.NewCoins(sdk.NewInt64Coin("akash", 100))
+
+	order := createOrder(t, ctx, k, 1)
+	winner, loser, closed := testAddress(), testAddress(), testAddress()
+	for _, provider := range []sdk.AccAddress{winner, loser, closed} {
+		bank.balances[provider.String()] = funds
+		require.NoError(t, k.CreateBid(ctx, order.ID(), provider, sdk.NewInt64Coin("akash", 10), 0, deposit))
+	}
+	createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 10))
+
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("akash", 90)), k.TotalEscrowed(ctx))
+	assert.Equal(t, bank.balances[mtypes.EscrowAddress.String()], k.TotalEscrowed(ctx))
+
+	require.NoError(t, k.WithdrawBid(ctx, mtypes.MakeBidID(order.ID(), closed), closed))
+	bid, _ := k.GetBid(ctx, mtypes.MakeBidID(order.ID(), winner))
+	_, err := k.AwardLease(ctx, order.ID(), bid)
+	require.NoError(t, err)
+
+	// only the matched bid's deposit is still held
+	assert.Equal(t, sdk.NewCoins(deposit), k.TotalEscrowed(ctx))
+	assert.Equal(t, bank.balances[mtypes.EscrowAddress.String()], k.TotalEscrowed(ctx))
+}
+
+func TestKeeper_BidClosedRefundEvent(t *testing.T) {
+	ctx, k, bank := setupKeeperWithBank(t)
+
+	deposit := sdk.NewInt64Coin("akash", 30)
+	provider := testAddress()
+	bank.balances[provider.String()] = sdk.NewCoins(sdk.NewInt64Coin("akash", 100))
+
+	order := createOrder(t, ctx, k, 1)
+	require.NoError(t, k.CreateBid(ctx, order.ID(), provider, sdk.NewInt64Coin("akash", 10), 0, deposit))
+	bid, _ := k.GetBid(ctx, mtypes.MakeBidID(order.ID(), provider))
+
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	require.NoError(t, k.OnBidClosed(ctx, bid))
+
+	events := ctx.EventManager().Events()
+	require.Len(t, events, 1)
+
+	sev := sdk.StringifyEvent(events.ToABCIEvents()[0])
+	ev, err := mtypes.ParseEvent(sdkutil.Event{
+		Type:       sev.Type,
+		Module:     mtypes.ModuleName,
+		Action:     "bid-closed",
+		Attributes: sev.Attributes,
+	})
+	require.NoError(t, err)
+	closed, ok := ev.(mtypes.EventBidClosed)
+	require.True(t, ok)
+	assert.Equal(t, deposit, closed.Refund)
+	assert.Equal(t, provider, closed.Recipient)
+
+	// no deposit, no refund attributes
+	free := createBid(t, ctx, k, createOrder(t, ctx, k, 2), testAddress(), sdk.NewInt64Coin("akash", 10))
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	require.NoError(t, k.OnBidClosed(ctx, free))
+	assert.Equal(t, sdk.Events{mtypes.EventBidClosed{ID: free.ID()}.ToSDKEvent()}, ctx.EventManager().Events())
+}
+
+func TestKeeper_BidLostRefundEvent(t *testing.T) {
+	ctx, k, bank := setupKeeperWithBank(t)
+
+	deposit := sdk.NewInt64Coin("akash", 30)
+	funds := sdk.NewCoins(sdk.NewInt64Coin("akash", 100))
+
+	order := createOrder(t, ctx, k, 1)
+	winner, loser := testAddress(), testAddress()
+	for _, provider := range []sdk.AccAddress{winner, loser} {
+		bank.balances[provider.String()] = funds
+	}
+	require.NoError(t, k.CreateBid(ctx, order.ID(), winner, sdk.NewInt64Coin("akash", 10), 0, deposit))
+	require.NoError(t, k.CreateBid(ctx, order.ID(), loser, sdk.NewInt64Coin("akash", 20), 0, deposit))
+	bid, _ := k.GetBid(ctx, mtypes.MakeBidID(order.ID(), winner))
+
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	_, err := k.AwardLease(ctx, order.ID(), bid)
+	require.NoError(t, err)
+	assert.Equal(t, funds, bank.balances[loser.String()])
+
+	var lost []mtypes.EventBidLost
+	for _, aev := range ctx.EventManager().Events().ToABCIEvents() {
+		sev := sdk.StringifyEvent(aev)
+		ev, err := mtypes.ParseEvent(sdkutil.Event{
+			Type:       sev.Type,
+			Module:     mtypes.ModuleName,
+			Action:     eventAction(sev.Attributes),
+			Attributes: sev.Attributes,
+		})
+		if err != nil {
+			continue
+		}
+		if ev, ok := ev.(mtypes.EventBidLost); ok {
+			lost = append(lost, ev)
+		}
+	}
+	assert.Equal(t, []mtypes.EventBidLost{{
+		ID:        mtypes.MakeBidID(order.ID(), loser),
+		Refund:    deposit,
+		Recipient: loser,
+	}}, lost)
+}
+
+func eventAction(attrs []sdk.Attribute) string {
+	for _, attr := range attrs {
+		if attr.Key == sdk.AttributeKeyAction {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func TestKeeper_WithdrawBid(t *testing.T) {
+	ctx, k, bank := setupKeeperWithBank(t)
+
+	deposit := sdk.NewInt64Coin("akash", 30)
+	funds := sdk.NewCoins(sdk.NewInt64Coin("akash", 100))
+
+	order := createOrder(t, ctx, k, 1)
+	provider, winner := testAddress(), testAddress()
+	bank.balances[provider.String()] = funds
+	bank.balances[winner.String()] = funds
+
+	require.NoError(t, k.CreateBid(ctx, order.ID(), provider, sdk.NewInt64Coin("akash", 20), 0, deposit))
+	require.NoError(t, k.CreateBid(ctx, order.ID(), winner, sdk.NewInt64Coin("akash", 10), 0, deposit))
+	id := mtypes.MakeBidID(order.ID(), provider)
+
+	// only the bidding provider can withdraw
+	err := k.WithdrawBid(ctx, id, testAddress())
+	assert.Equal(t, mtypes.ErrInvalidBidProvider, err)
+
+	require.NoError(t, k.WithdrawBid(ctx, id, provider))
+
+	bid, ok := k.GetBid(ctx, id)
+	require.True(t, ok)
+	assert.Equal(t, mtypes.BidClosed, bid.State)
+	assert.Equal(t, funds, bank.balances[provider.String()])
+
+	err = k.WithdrawBid(ctx, id, provider)
+	assert.Equal(t, mtypes.ErrBidNotOpen, err)
+
+	// matched bids can't be withdrawn
+	bid, _ = k.GetBid(ctx, mtypes.MakeBidID(order.ID(), winner))
+	_, err = k.AwardLease(ctx, order.ID(), bid)
+	require.NoError(t, err)
+
+	err = k.WithdrawBid(ctx, bid.ID(), winner)
+	assert.Equal(t, mtypes.ErrBidNotOpen, err)
+
+	bid, _ = k.GetBid(ctx, bid.ID())
+	assert.Equal(t, mtypes.BidMatched, bid.State)
+	assert.Equal(t, sdk.NewCoins(deposit), bank.balances[mtypes.EscrowAddress.String()])
+
+	assert.Equal(t, mtypes.ErrUnknownBid, k.WithdrawBid(ctx, mtypes.MakeBidID(order.ID(), testAddress()), provider))
+}
+
+func TestKeeper_OnGroupClosed(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	leased := createOrder(t, ctx, k, 1)
+	gid := leased.GroupID()
+
+	winner := createBid(t, ctx, k, leased, testAddress(), sdk.NewInt64Coin("akash", 10))
+	createBid(t, ctx, k, leased, testAddress(), sdk.NewInt64Coin("akash", 20))
+	lease, err := k.AwardLease(ctx, leased.ID(), winner)
+	require.NoError(t, err)
+
+	// a second, still open order in the same group
+	open, err := k.CreateOrder(ctx, gid, testGroupSpec())
+	require.NoError(t, err)
+	bid := createBid(t, ctx, k, open, testAddress(), sdk.NewInt64Coin("akash", 10))
+
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	k.OnGroupClosed(ctx, gid)
+
+	// lost bids were already closed out when the lease was awarded
+	expected := sdk.Events{
+		mtypes.EventBidClosed{ID: winner.ID()}.ToSDKEvent(),
+		mtypes.EventLeaseClosed{ID: lease.ID()}.ToSDKEvent(),
+		mtypes.EventOrderClosed{ID: leased.ID()}.ToSDKEvent(),
+		mtypes.EventBidClosed{ID: bid.ID()}.ToSDKEvent(),
+		mtypes.EventOrderClosed{ID: open.ID()}.ToSDKEvent(),
+	}
+	assert.ElementsMatch(t, expected, ctx.EventManager().Events())
+
+	lease, _ = k.GetLease(ctx, lease.ID())
+	assert.Equal(t, mtypes.LeaseClosed, lease.State)
+	for _, id := range []mtypes.OrderID{leased.ID(), open.ID()} {
+		order, _ := k.GetOrder(ctx, id)
+		assert.Equal(t, mtypes.OrderClosed, order.State)
+	}
+
+	// closing again emits nothing
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	k.OnGroupClosed(ctx, gid)
+	assert.Empty(t, ctx.EventManager().Events())
+}
+
+func TestKeeper_IllegalTransitions(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	isIllegal := func(err error) bool {
+		return mtypes.ErrInvalidTransition.Is(err)
+	}
+
+	order := createOrder(t, ctx, k, 1)
+	lease := createLease(t, ctx, k, order, testAddress())
+	bid, _ := k.GetBid(ctx, lease.ID().BidID())
+	require.NoError(t, k.OnBidMatched(ctx, bid))
+	require.NoError(t, k.OnOrderMatched(ctx, order))
+
+	order, _ = k.GetOrder(ctx, order.ID())
+	bid, _ = k.GetBid(ctx, bid.ID())
+
+	// matching twice
+	assert.True(t, isIllegal(k.OnOrderMatched(ctx, order)))
+	assert.True(t, isIllegal(k.OnBidMatched(ctx, bid)))
+
+	// a matched bid can't lose
+	assert.True(t, isIllegal(k.OnBidLost(ctx, bid)))
+	stored, _ := k.GetBid(ctx, bid.ID())
+	assert.Equal(t, mtypes.BidMatched, stored.State)
+
+	// lost and closed bids can't be matched
+	lost := createBid(t, ctx, k, createOrder(t, ctx, k, 2), testAddress(), sdk.NewInt64Coin("akash", 10))
+	require.NoError(t, k.OnBidLost(ctx, lost))
+	lost, _ = k.GetBid(ctx, lost.ID())
+	assert.True(t, isIllegal(k.OnBidMatched(ctx, lost)))
+
+	require.NoError(t, k.OnBidClosed(ctx, bid))
+	bid, _ = k.GetBid(ctx, bid.ID())
+	assert.True(t, isIllegal(k.OnBidMatched(ctx, bid)))
+
+	// closed orders can't be matched
+	require.NoError(t, k.OnOrderClosed(ctx, order))
+	order, _ = k.GetOrder(ctx, order.ID())
+	assert.True(t, isIllegal(k.OnOrderMatched(ctx, order)))
+	order, _ = k.GetOrder(ctx, order.ID())
+	assert.Equal(t, mtypes.OrderClosed, order.State)
+
+	// closing again is a no-op
+	assert.NoError(t, k.OnOrderClosed(ctx, order))
+	assert.NoError(t, k.OnBidClosed(ctx, bid))
+	assert.NoError(t, k.OnBidLost(ctx, lost))
+
+	require.NoError(t, k.OnLeaseClosed(ctx, lease))
+	lease, _ = k.GetLease(ctx, lease.ID())
+	assert.NoError(t, k.OnLeaseClosed(ctx, lease))
+	assert.NoError(t, k.OnInsufficientFunds(ctx, lease))
+
+	// unknown states can't move anywhere
+	order.State = mtypes.OrderClosed + 1
+	assert.True(t, isIllegal(k.OnOrderClosed(ctx, order)))
+	bid.State = mtypes.BidClosed + 1
+	assert.True(t, isIllegal(k.OnBidClosed(ctx, bid)))
+	assert.True(t, isIllegal(k.OnBidLost(ctx, bid)))
+	lease.State = mtypes.LeaseClosed + 1
+	assert.True(t, isIllegal(k.OnLeaseClosed(ctx, lease)))
+	assert.True(t, isIllegal(k.OnInsufficientFunds(ctx, lease)))
+}
+
+func TestKeeper_SettleLease(t *testing.T) {
+	ctx, k, bank := setupKeeperWithBank(t)
+
+	lease := createLease(t, ctx, k, createOrder(t, ctx, k, 1), testAddress())
+	assert.Equal(t, ctx.BlockHeight(), lease.SettledAt)
+
+	bank.balances[lease.Owner.String()] = sdk.NewCoins(sdk.NewInt64Coin("akash", 45))
+	balance := func(addr sdk.AccAddress) int64 {
+		return bank.balances[addr.String()].AmountOf("akash").Int64()
+	}
+	settle := func(height int64) error {
+		hctx := ctx.WithBlockHeight(height)
+		lease, _ := k.GetLease(hctx, lease.ID())
+		return k.SettleLease(hctx, lease)
+	}
+
+	// nothing owed in the block the lease was created
+	require.NoError(t, settle(ctx.BlockHeight()))
+	assert.Equal(t, int64(45), balance(lease.Owner))
+
+	// two blocks at 10 each
+	require.NoError(t, settle(ctx.BlockHeight()+2))
+	assert.Equal(t, int64(25), balance(lease.Owner))
+	assert.Equal(t, int64(20), balance(lease.Provider))
+
+	lease, _ = k.GetLease(ctx, lease.ID())
+	assert.Equal(t, ctx.BlockHeight()+2, lease.SettledAt)
+
+	// settled blocks aren't paid twice
+	require.NoError(t, settle(ctx.BlockHeight()+2))
+	require.NoError(t, settle(ctx.BlockHeight()+3))
+	assert.Equal(t, int64(15), balance(lease.Owner))
+	assert.Equal(t, int64(30), balance(lease.Provider))
+
+	// two more blocks cost 20; the tenant has 15
+	err := settle(ctx.BlockHeight() + 5)
+	assert.Equal(t, mtypes.ErrLeaseUnderfunded, err)
+	assert.Equal(t, int64(15), balance(lease.Owner))
+	assert.Equal(t, int64(30), balance(lease.Provider))
+
+	lease, _ = k.GetLease(ctx, lease.ID())
+	assert.Equal(t, mtypes.LeaseInsufficientFunds, lease.State)
+	assert.Equal(t, ctx.BlockHeight()+3, lease.SettledAt)
+
+	assert.Equal(t, mtypes.ErrLeaseNotActive, settle(ctx.BlockHeight()+6))
+}
+
+func TestKeeper_SettleLeaseRefundsDeposit(t *testing.T) {
+	ctx, k, bank := setupKeeperWithBank(t)
+
+	funds := sdk.NewCoins(sdk.NewInt64Coin("akash", 100))
+	lease := createDepositLease(t, ctx, k, bank, funds)
+
+	err := k.SettleLease(ctx.WithBlockHeight(ctx.BlockHeight()+1), lease)
+	assert.Equal(t, mtypes.ErrLeaseUnderfunded, err)
+
+	bid, _ := k.GetBid(ctx, lease.BidID())
+	assert.Equal(t, mtypes.BidClosed, bid.State)
+	assert.Equal(t, funds, bank.balances[lease.Provider.String()])
+	assert.True(t, bank.balances[mtypes.EscrowAddress.String()].IsZero())
+}
+
+func TestKeeper_CloseOrder(t *testing.T) {
+	ctx, k, bank := setupKeeperWithBank(t)
+
+	funds := sdk.NewCoins(sdk.NewInt64Coin("akash", 100))
+	lease := createDepositLease(t, ctx, k, bank, funds)
+
+	require.NoError(t, k.CloseOrder(ctx, lease.OrderID()))
+
+	order, _ := k.GetOrder(ctx, lease.OrderID())
+	assert.Equal(t, mtypes.OrderClosed, order.State)
+	lease, _ = k.GetLease(ctx, lease.ID())
+	assert.Equal(t, mtypes.LeaseClosed, lease.State)
+	bid, _ := k.GetBid(ctx, lease.BidID())
+	assert.Equal(t, mtypes.BidClosed, bid.State)
+
+	assert.Equal(t, funds, bank.balances[lease.Provider.String()])
+	assert.True(t, bank.balances[mtypes.EscrowAddress.String()].IsZero())
+
+	assert.Equal(t, mtypes.ErrNoLeaseForOrder, k.CloseOrder(ctx, createOrder(t, ctx, k, 2).ID()))
+}
+
+func TestKeeper_CreateDuplicates(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	order := createOrder(t, ctx, k, 1)
+	provider := testAddress()
+	bid := createBid(t, ctx, k, order, provider, sdk.NewInt64Coin("akash", 10))
+
+	// a second bid from the same provider is rejected and the first is kept
+	err := k.CreateBid(ctx, order.ID(), provider, sdk.NewInt64Coin("akash", 5), 0, sdk.NewInt64Coin("akash", 0))
+	assert.Equal(t, mtypes.ErrBidExists, err)
+
+	stored, ok := k.GetBid(ctx, bid.ID())
+	require.True(t, ok)
+	assert.Equal(t, bid, stored)
+
+	// other providers may still bid
+	createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 5))
+
+	require.NoError(t, k.CreateLease(ctx, bid))
+	assert.Equal(t, mtypes.ErrLeaseExists, k.CreateLease(ctx, bid))
+}
+
+func TestKeeper_MatchOrders(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	order := createOrder(t, ctx, k, 1)
+	unbid := createOrder(t, ctx, k, 2)
+
+	// equal cheapest bids; the lower provider address wins
+	low, high := testAddress(), testAddress()
+	if bytes.Compare(low, high) > 0 {
+		low, high = high, low
+	}
+	createBid(t, ctx, k, order, high, sdk.NewInt64Coin("akash", 10))
+	winner := createBid(t, ctx, k, order, low, sdk.NewInt64Coin("akash", 10))
+	loser := createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 20))
+
+	// too early
+	assert.Empty(t, k.MatchOrders(ctx.WithBlockHeight(order.StartAt-1)))
+
+	mctx := ctx.WithBlockHeight(order.StartAt)
+	leases := k.MatchOrders(mctx)
+	require.Len(t, leases, 1)
+	assert.Equal(t, winner.ID().LeaseID(), leases[0].ID())
+	assert.Contains(t, eventActions(k.MarketEvents(mctx, 0, mctx.BlockHeight())), "lease-created")
+
+	bid, _ := k.GetBid(ctx, winner.ID())
+	assert.Equal(t, mtypes.BidMatched, bid.State)
+	for _, id := range []mtypes.BidID{mtypes.MakeBidID(order.ID(), high), loser.ID()} {
+		bid, _ = k.GetBid(ctx, id)
+		assert.Equal(t, mtypes.BidLost, bid.State)
+	}
+
+	order, _ = k.GetOrder(ctx, order.ID())
+	assert.Equal(t, mtypes.OrderMatched, order.State)
+	unbid, _ = k.GetOrder(ctx, unbid.ID())
+	assert.Equal(t, mtypes.OrderOpen, unbid.State)
+
+	// matched orders aren't matched again
+	assert.Empty(t, k.MatchOrders(mctx))
+}
+
+func TestKeeper_BestBidForOrder(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	order := createOrder(t, ctx, k, 1)
+
+	_, ok := k.BestBidForOrder(ctx, order.ID())
+	assert.False(t, ok)
+
+	createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 30))
+	cheapest := createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 10))
+	createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 20))
+
+	// same price, placed later
+	createBid(t, ctx.WithBlockHeight(ctx.BlockHeight()+1), k, order, testAddress(), sdk.NewInt64Coin("akash", 10))
+
+	// cheaper but no longer open
+	closed := createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 5))
+	k.OnBidClosed(ctx, closed)
+
+	// other denominations don't panic
+	createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("uakt", 1))
+
+	best, ok := k.BestBidForOrder(ctx, order.ID())
+	require.True(t, ok)
+	assert.Equal(t, cheapest.ID(), best.ID())
+
+	// bids for other orders are ignored
+	other := createOrder(t, ctx, k, 2)
+	createBid(t, ctx, k, other, testAddress(), sdk.NewInt64Coin("akash", 1))
+	best, ok = k.BestBidForOrder(ctx, order.ID())
+	require.True(t, ok)
+	assert.Equal(t, cheapest.ID(), best.ID())
+}
+
+func TestKeeper_SelectBid(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	params := k.GetParams(ctx)
+	params.LeaseHeartbeatThreshold = 10
+	k.SetParams(ctx, params)
+
+	unreliable := testAddress()
+	reliable := testAddress()
+
+	// unreliable misses heartbeats on one of its two leases
+	createLease(t, ctx, k, createOrder(t, ctx, k, 1), unreliable)
+	kept := createLease(t, ctx, k, createOrder(t, ctx, k, 2), unreliable)
+	other := createLease(t, ctx, k, createOrder(t, ctx, k, 3), reliable)
+
+	require.NoError(t, k.RecordLeaseHeartbeat(ctx.WithBlockHeight(ctx.BlockHeight()+5), kept.ID(), unreliable))
+	require.NoError(t, k.RecordLeaseHeartbeat(ctx.WithBlockHeight(ctx.BlockHeight()+5), other.ID(), reliable))
+	require.Len(t, k.CloseUnresponsiveLeases(ctx.WithBlockHeight(ctx.BlockHeight()+10)), 1)
+
+	assert.True(t, sdk.NewDecWithPrec(5, 1).Equal(k.ProviderUptime(ctx, unreliable)))
+	assert.True(t, sdk.OneDec().Equal(k.ProviderUptime(ctx, reliable)))
+	assert.True(t, sdk.OneDec().Equal(k.ProviderUptime(ctx, testAddress())))
+
+	spec := testGroupSpec()
+	spec.UptimeWeight = 50
+	order, err := k.CreateOrder(ctx, dtypes.GroupID{Owner: testAddress(), DSeq: 4, GSeq: 1}, spec)
+	require.NoError(t, err)
+
+	cheaper := createBid(t, ctx, k, order, unreliable, sdk.NewInt64Coin("akash", 95))
+	dearer := createBid(t, ctx, k, order, reliable, sdk.NewInt64Coin("akash", 100))
+	bids := []mtypes.Bid{cheaper, dearer}
+
+	// weighting favors the higher-uptime provider despite its price
+	winner, ok := k.SelectBid(ctx, order, bids)
+	require.True(t, ok)
+	assert.Equal(t, dearer.ID(), winner.ID())
+
+	// price only
+	order.Spec.UptimeWeight = 0
+	winner, ok = k.SelectBid(ctx, order, bids)
+	require.True(t, ok)
+	assert.Equal(t, cheaper.ID(), winner.ID())
+
+	_, ok = k.SelectBid(ctx, order, nil)
+	assert.False(t, ok)
+}
+
+func TestKeeper_DutchAuction(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	params := k.GetParams(ctx)
+	params.DutchAuctionWindow = 10
+	params.DutchAuctionFloor = 50
+	k.SetParams(ctx, params)
+
+	spec := testGroupSpec()
+	spec.PricingMode = dtypes.PricingDutch
+
+	order, err := k.CreateOrder(ctx, dtypes.GroupID{Owner: testAddress(), DSeq: 1, GSeq: 1}, spec)
+	require.NoError(t, err)
+	early, err := k.CreateOrder(ctx, dtypes.GroupID{Owner: testAddress(), DSeq: 2, GSeq: 1}, spec)
+	require.NoError(t, err)
+
+	at := func(blocks int64) sdk.Context {
+		return ctx.WithBlockHeight(order.StartAt + blocks)
+	}
+
+	// the ceiling falls from the 100akash price limit to the 50% floor
+	for blocks, expected := range map[int64]int64{-1: 100, 0: 100, 2: 90, 5: 75, 10: 50, 20: 50} {
+		ceiling := order.DutchCeiling(order.StartAt+blocks, params.DutchAuctionWindow, params.DutchAuctionFloor)
+		assert.Equal(t, sdk.NewInt64Coin("akash", expected).String(), ceiling.String(), "%v blocks", blocks)
+	}
+
+	// the first bid under the ceiling wins, not the cheapest
+	first := createBid(t, ctx, k, early, testAddress(), sdk.NewInt64Coin("akash", 100))
+	createBid(t, ctx.WithBlockHeight(ctx.BlockHeight()+1), k, early, testAddress(), sdk.NewInt64Coin("akash", 60))
+
+	leases := k.MatchOrders(at(0))
+	require.Len(t, leases, 1)
+	assert.Equal(t, first.ID().LeaseID(), leases[0].ID())
+
+	// bids above the ceiling aren't accepted
+	createBid(t, at(2), k, order, testAddress(), sdk.NewInt64Coin("akash", 92))
+	assert.Empty(t, k.MatchOrders(at(2)))
+
+	// nor once the ceiling has fallen below them
+	createBid(t, at(3), k, order, testAddress(), sdk.NewInt64Coin("akash", 90))
+	assert.Empty(t, k.MatchOrders(at(3)))
+
+	winner := createBid(t, at(20), k, order, testAddress(), sdk.NewInt64Coin("akash", 50))
+	leases = k.MatchOrders(at(20))
+	require.Len(t, leases, 1)
+	assert.Equal(t, winner.ID().LeaseID(), leases[0].ID())
+}
+
+func TestKeeper_LeaseTags(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	spec := testGroupSpec()
+	spec.Tags = []tmkv.Pair{
+		{Key: []byte("project"), Value: []byte("web")},
+		{Key: []byte("env"), Value: []byte("prod")},
+	}
+
+	tagged, err := k.CreateOrder(ctx, dtypes.GroupID{Owner: testAddress(), DSeq: 1, GSeq: 1}, spec)
+	require.NoError(t, err)
+	untagged := createOrder(t, ctx, k, 2)
+
+	// tags don't participate in attribute matching
+	assert.True(t, tagged.MatchAttributes(nil))
+
+	lease := createLease(t, ctx, k, tagged, testAddress())
+	createLease(t, ctx, k, untagged, testAddress())
+
+	assert.Equal(t, spec.Tags, lease.Tags)
+	assert.True(t, lease.HasTag("project", "web"))
+	assert.True(t, lease.HasTag("env", "prod"))
+	assert.False(t, lease.HasTag("env", "staging"))
+	assert.False(t, lease.HasTag("web", "project"))
+
+	var found []mtypes.LeaseID
+	k.WithLeases(ctx, func(obj mtypes.Lease) bool {
+		if obj.HasTag("env", "prod") {
+			found = append(found, obj.ID())
+		}
+		return false
+	})
+	assert.Equal(t, []mtypes.LeaseID{lease.ID()}, found)
+}
+
+func TestKeeper_MarketEvents(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	order := createOrder(t, ctx, k, 1)
+	bid := createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 10))
+
+	leaseCtx := ctx.WithBlockHeight(ctx.BlockHeight() + 1)
+	require.NoError(t, k.CreateLease(leaseCtx, bid))
+	lease, ok := k.GetLease(ctx, bid.ID().LeaseID())
+	require.True(t, ok)
+
+	closeCtx := ctx.WithBlockHeight(ctx.BlockHeight() + 2)
+	k.OnLeaseClosed(closeCtx, lease)
+
+	events := k.MarketEvents(ctx, ctx.BlockHeight(), closeCtx.BlockHeight())
+	assert.Equal(t, []string{"order-created", "bid-created", "lease-created", "lease-closed"}, eventActions(events))
+	assert.Equal(t, ctx.BlockHeight(), events[0].Height)
+	assert.Equal(t, closeCtx.BlockHeight(), events[3].Height)
+
+	events = k.MarketEvents(ctx, leaseCtx.BlockHeight(), leaseCtx.BlockHeight())
+	assert.Equal(t, []string{"lease-created"}, eventActions(events))
+
+	assert.Empty(t, k.MarketEvents(ctx, closeCtx.BlockHeight(), ctx.BlockHeight()))
+
+	// old entries are pruned at the end of a later block
+	later := ctx.WithBlockHeight(ctx.BlockHeight() + 1000000)
+	createOrder(t, later, k, 2)
+	createOrder(t, later, k, 3)
+	assert.Len(t, k.MarketEvents(ctx, 0, later.BlockHeight()), 6)
+
+	k.PruneEventLog(later)
+	assert.Empty(t, k.MarketEvents(ctx, ctx.BlockHeight(), closeCtx.BlockHeight()))
+	assert.Len(t, k.MarketEvents(ctx, 0, later.BlockHeight()), 2)
+
+	// sequences restart with each block
+	next := later.WithBlockHeight(later.BlockHeight() + 1)
+	createOrder(t, next, k, 4)
+	assert.Equal(t, []string{"order-created"}, eventActions(k.MarketEvents(ctx, next.BlockHeight(), next.BlockHeight())))
+	assert.Len(t, k.MarketEvents(ctx, later.BlockHeight(), later.BlockHeight()), 2)
+}
+
+func TestKeeper_MaxOpenOrdersPerOwner(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	params := k.GetParams(ctx)
+	params.MaxOpenOrdersPerOwner = 2
+	k.SetParams(ctx, params)
+
+	owner := testAddress()
+
+	first, err := k.CreateOrder(ctx, dtypes.GroupID{Owner: owner, DSeq: 1, GSeq: 1}, testGroupSpec())
+	require.NoError(t, err)
+	_, err = k.CreateOrder(ctx, dtypes.GroupID{Owner: owner, DSeq: 2, GSeq: 1}, testGroupSpec())
+	require.NoError(t, err)
+
+	_, err = k.CreateOrder(ctx, dtypes.GroupID{Owner: owner, DSeq: 3, GSeq: 1}, testGroupSpec())
+	assert.Equal(t, mtypes.ErrTooManyOpenOrders, err)
+
+	// other owners are unaffected
+	createOrder(t, ctx, k, 3)
+
+	// closing an order frees a slot
+	k.OnOrderClosed(ctx, first)
+	_, err = k.CreateOrder(ctx, dtypes.GroupID{Owner: owner, DSeq: 3, GSeq: 1}, testGroupSpec())
+	assert.NoError(t, err)
+}
+
+func TestKeeper_OrderTTL(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	order := createOrder(t, ctx, k, 1)
+	assert.Equal(t, ctx.BlockHeight()+mtypes.DefaultOrderTTL, order.StartAt)
+
+	params := k.GetParams(ctx)
+	params.OrderTTL = 20
+	k.SetParams(ctx, params)
+
+	order = createOrder(t, ctx, k, 2)
+	assert.Equal(t, ctx.BlockHeight()+20, order.StartAt)
+	assert.Error(t, order.ValidateCanMatch(ctx.BlockHeight()+19))
+	assert.NoError(t, order.ValidateCanMatch(ctx.BlockHeight()+20))
+}
+
+func TestKeeper_ProviderBidIndex(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	provider := testAddress()
+	providerBids := func() []mtypes.BidID {
+		var ids []mtypes.BidID
+		k.WithBidsForProvider(ctx, provider, func(bid mtypes.Bid) bool {
+			ids = append(ids, bid.ID())
+			return false
+		})
+		return ids
+	}
+
+	open := createBid(t, ctx, k, createOrder(t, ctx, k, 1), provider, sdk.NewInt64Coin("akash", 10))
+	lost := createBid(t, ctx, k, createOrder(t, ctx, k, 2), provider, sdk.NewInt64Coin("akash", 10))
+	withdrawn := createBid(t, ctx, k, createOrder(t, ctx, k, 3), provider, sdk.NewInt64Coin("akash", 10))
+	lease := createLease(t, ctx, k, createOrder(t, ctx, k, 4), provider)
+	createBid(t, ctx, k, createOrder(t, ctx, k, 5), testAddress(), sdk.NewInt64Coin("akash", 10))
+
+	assert.ElementsMatch(t, []mtypes.BidID{open.ID(), lost.ID(), withdrawn.ID(), lease.ID().BidID()}, providerBids())
+
+	leased, _ := k.GetBid(ctx, lease.ID().BidID())
+	require.NoError(t, k.OnBidMatched(ctx, leased))
+	require.NoError(t, k.OnBidLost(ctx, lost))
+	require.NoError(t, k.WithdrawBid(ctx, withdrawn.ID(), provider))
+	assert.ElementsMatch(t, []mtypes.BidID{open.ID(), leased.ID()}, providerBids())
+
+	// closed bids that won a lease stay indexed
+	leased, _ = k.GetBid(ctx, leased.ID())
+	require.NoError(t, k.OnBidClosed(ctx, leased))
+	assert.ElementsMatch(t, []mtypes.BidID{open.ID(), leased.ID()}, providerBids())
+}
+
+func TestKeeper_RebuildProviderBidIndex(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	provider := testAddress()
+	order := createOrder(t, ctx, k, 1)
+	open := createBid(t, ctx, k, order, provider, sdk.NewInt64Coin("akash", 10))
+	closed := createBid(t, ctx, k, createOrder(t, ctx, k, 2), provider, sdk.NewInt64Coin("akash", 10))
+	k.OnBidClosed(ctx, closed)
+	lease := createLease(t, ctx, k, createOrder(t, ctx, k, 3), provider)
+	leased, _ := k.GetBid(ctx, lease.ID().BidID())
+	k.OnBidMatched(ctx, leased)
+	createBid(t, ctx, k, order, testAddress(), sdk.NewInt64Coin("akash", 10))
+
+	var bids []mtypes.Bid
+	k.WithBids(ctx, func(bid mtypes.Bid) bool {
+		bids = append(bids, bid)
+		return false
+	})
+	require.Len(t, bids, 4)
+
+	providerBids := func(ctx sdk.Context, k keeper.Keeper) []mtypes.BidID {
+		var ids []mtypes.BidID
+		k.WithBidsForProvider(ctx, provider, func(bid mtypes.Bid) bool {
+			ids = append(ids, bid.ID())
+			return false
+		})
+		return ids
+	}
+
+	// import into a fresh store
+	ictx, ik := setupKeeper(t)
+	for _, bid := range bids {
+		ik.ImportBid(ictx, bid)
+	}
+	assert.Empty(t, providerBids(ictx, ik))
+
+	ik.RebuildProviderBidIndex(ictx)
+	assert.ElementsMatch(t, []mtypes.BidID{open.ID(), leased.ID()}, providerBids(ictx, ik))
+
+	// rebuilding is idempotent
+	ik.RebuildProviderBidIndex(ictx)
+	assert.ElementsMatch(t, []mtypes.BidID{open.ID(), leased.ID()}, providerBids(ictx, ik))
+}
+
+func TestKeeper_OrdersByState(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	open := createOrder(t, ctx, k, 1)
+	matched := createOrder(t, ctx, k, 2)
+	closed := createOrder(t, ctx, k, 3)
+
+	lease := createLease(t, ctx, k, matched, testAddress())
+	bid, _ := k.GetBid(ctx, lease.ID().BidID())
+	k.OnBidMatched(ctx, bid)
+	k.OnOrderMatched(ctx, matched)
+
+	lost := createBid(t, ctx, k, closed, testAddress(), sdk.NewInt64Coin("akash", 10))
+	k.OnBidLost(ctx, lost)
+	k.OnOrderClosed(ctx, closed)
+
+	ids := func(orders []mtypes.Order) []mtypes.OrderID {
+		var ids []mtypes.OrderID
+		for _, order := range orders {
+			ids = append(ids, order.ID())
+		}
+		return ids
+	}
+
+	assert.Equal(t, []mtypes.OrderID{open.ID()}, ids(k.OrdersByState(ctx, mtypes.OrderOpen)))
+	assert.Equal(t, []mtypes.OrderID{matched.ID()}, ids(k.OrdersByState(ctx, mtypes.OrderMatched)))
+	assert.Equal(t, []mtypes.OrderID{closed.ID()}, ids(k.OrdersByState(ctx, mtypes.OrderClosed)))
+	assert.Empty(t, k.OrdersByState(ctx, mtypes.OrderClosed+1))
+
+	bids := k.BidsByState(ctx, mtypes.BidMatched)
+	require.Len(t, bids, 1)
+	assert.Equal(t, bid.ID(), bids[0].ID())
+
+	bids = k.BidsByState(ctx, mtypes.BidLost)
+	require.Len(t, bids, 1)
+	assert.Equal(t, lost.ID(), bids[0].ID())
+
+	assert.Empty(t, k.BidsByState(ctx, mtypes.BidOpen))
+	assert.Empty(t, k.BidsByState(ctx, mtypes.BidClosed+1))
+}
+
+func TestKeeper_OrdersWithoutBids(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	old := createOrder(t, ctx, k, 1)
+	bid := createOrder(t, ctx, k, 2)
+	createBid(t, ctx, k, bid, testAddress(), sdk.NewInt64Coin("akash", 10))
+	closed := createOrder(t, ctx, k, 3)
+	k.OnOrderClosed(ctx, closed)
+
+	ctx = ctx.WithBlockHeight(5)
+	middle := createOrder(t, ctx, k, 4)
+
+	ctx = ctx.WithBlockHeight(9)
+	createOrder(t, ctx, k, 5)
+
+	ctx = ctx.WithBlockHeight(10)
+
+	orderIDs := func(orders []mtypes.Order) []mtypes.OrderID {
+		var ids []mtypes.OrderID
+		for _, order := range orders {
+			ids = append(ids, order.ID())
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []mtypes.OrderID{old.ID()}, orderIDs(k.OrdersWithoutBids(ctx, 6)))
+	assert.ElementsMatch(t, []mtypes.OrderID{old.ID(), middle.ID()}, orderIDs(k.OrdersWithoutBids(ctx, 5)))
+	assert.Len(t, k.OrdersWithoutBids(ctx, 0), 3)
+	assert.Empty(t, k.OrdersWithoutBids(ctx, 10))
+
+	assert.Equal(t, int64(5), middle.CreatedAt)
+}
+
+func TestKeeper_OrderSequence(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	gid := dtypes.GroupID{Owner: testAddress(), DSeq: 1, GSeq: 1}
+	other := dtypes.GroupID{Owner: gid.Owner, DSeq: 1, GSeq: 2}
+
+	for expected := uint32(1); expected <= 3; expected++ {
+		order, err := k.CreateOrder(ctx, gid, testGroupSpec())
+		require.NoError(t, err)
+		assert.Equal(t, expected, order.OSeq)
+		k.OnOrderClosed(ctx, order)
+	}
+
+	// sequences are per group
+	order, err := k.CreateOrder(ctx, other, testGroupSpec())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), order.OSeq)
+
+	order, err = k.CreateOrder(ctx, gid, testGroupSpec())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(4), order.OSeq)
+}
+
+func TestKeeper_OrderOverflow(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	// a group that has used every oseq
+	gid := dtypes.GroupID{Owner: testAddress(), DSeq: 1, GSeq: 1}
+	k.SetOrderSeq(ctx, gid, math.MaxUint32)
+
+	_, err := k.CreateOrder(ctx, gid, testGroupSpec())
+	assert.Equal(t, mtypes.ErrOrderSeqOverflow, err)
+	assert.Empty(t, k.OrdersByState(ctx, mtypes.OrderOpen))
+
+	k.SetOrderSeq(ctx, gid, math.MaxUint32-1)
+	order, err := k.CreateOrder(ctx, gid, testGroupSpec())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(math.MaxUint32), order.OSeq)
+
+	_, err = k.CreateOrder(ctx, gid, testGroupSpec())
+	assert.Equal(t, mtypes.ErrOrderSeqOverflow, err)
+
+	// start height past the end of int64
+	params := k.GetParams(ctx)
+	params.OrderTTL = math.MaxInt64
+	k.SetParams(ctx, params)
+
+	other := dtypes.GroupID{Owner: gid.Owner, DSeq: 1, GSeq: 2}
+	_, err = k.CreateOrder(ctx, other, testGroupSpec())
+	assert.Equal(t, mtypes.ErrOrderStartOverflow, err)
+
+	// the failed order didn't use up a sequence
+	params.OrderTTL = 0
+	k.SetParams(ctx, params)
+	order, err = k.CreateOrder(ctx, other, testGroupSpec())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), order.OSeq)
+}
+
+func TestKeeper_Migrate(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	// stores written before versioning
+	assert.Equal(t, uint64(1), k.GetStoreVersion(ctx))
+
+	order := createOrder(t, ctx, k, 1)
+
+	require.NoError(t, k.Migrate(ctx, 1))
+	assert.Equal(t, keeper.CurrentStoreVersion, k.GetStoreVersion(ctx))
+
+	_, ok := k.GetOrder(ctx, order.ID())
+	assert.True(t, ok)
+
+	// already current
+	require.NoError(t, k.Migrate(ctx, keeper.CurrentStoreVersion))
+	assert.Equal(t, keeper.CurrentStoreVersion, k.GetStoreVersion(ctx))
+
+	assert.Equal(t, mtypes.ErrUnknownStoreVersion, k.Migrate(ctx, 0))
+	assert.Equal(t, mtypes.ErrUnknownStoreVersion, k.Migrate(ctx, keeper.CurrentStoreVersion+1))
+}
+
+func TestKeeper_MigrateV1ToV2(t *testing.T) {
+	ctx, k := setupKeeper(t)
+	ctx = ctx.WithBlockHeight(10)
+
+	gid := dtypes.GroupID{Owner: testAddress(), DSeq: 1, GSeq: 1}
+	provider := testAddress()
+
+	// a version 1 store: primary records only
+	var orders []mtypes.Order
+	for oseq := uint32(1); oseq <= 3; oseq++ {
+		order := mtypes.Order{
+			OrderID:   mtypes.MakeOrderID(gid, oseq),
+			State:     mtypes.OrderOpen,
+			Spec:      testGroupSpec(),
+			CreatedAt: int64(oseq),
+		}
+		k.WriteV1Order(ctx, order)
+		orders = append(orders, order)
+	}
+
+	bid := mtypes.Bid{
+		BidID:     mtypes.MakeBidID(orders[0].ID(), provider),
+		State:     mtypes.BidOpen,
+		Price:     sdk.NewInt64Coin("stake", 1),
+		Deposit:   sdk.NewInt64Coin("stake", 0),
+		CreatedAt: 1,
+	}
+	k.WriteV1Bid(ctx, bid)
+
+	lease := mtypes.Lease{
+		LeaseID: bid.ID().LeaseID(),
+		State:   mtypes.LeaseActive,
+		Price:   bid.Price,
+	}
+	k.ImportLease(ctx, lease)
+
+	assert.Empty(t, k.OrdersWithoutBids(ctx, 0))
+
+	require.NoError(t, k.Migrate(ctx, 1))
+
+	lease, _ = k.GetLease(ctx, lease.ID())
+	assert.Equal(t, ctx.BlockHeight(), lease.Heartbeat)
+	assert.Equal(t, ctx.BlockHeight(), lease.CreatedAt)
+	assert.Equal(t, ctx.BlockHeight(), lease.SettledAt)
+
+	// heartbeats are counted from the upgrade
+	threshold := k.GetParams(ctx).LeaseHeartbeatThreshold
+	require.True(t, threshold > 0)
+	assert.Empty(t, k.CloseUnresponsiveLeases(ctx))
+	assert.Empty(t, k.CloseUnresponsiveLeases(ctx.WithBlockHeight(ctx.BlockHeight()+threshold-1)))
+	lease, _ = k.GetLease(ctx, lease.ID())
+	assert.Equal(t, mtypes.LeaseActive, lease.State)
+
+	var unbid []mtypes.OrderID
+	for _, order := range k.OrdersWithoutBids(ctx, 0) {
+		unbid = append(unbid, order.ID())
+	}
+	assert.Equal(t, []mtypes.OrderID{orders[1].ID(), orders[2].ID()}, unbid)
+
+	var bids []mtypes.BidID
+	k.WithBidsForProvider(ctx, provider, func(bid mtypes.Bid) bool {
+		bids = append(bids, bid.ID())
+		return false
+	})
+	assert.Equal(t, []mtypes.BidID{bid.ID()}, bids)
+
+	var seqs []uint32
+	k.WithOrderSeqs(ctx, func(id dtypes.GroupID, seq uint32) bool {
+		assert.Equal(t, gid, id)
+		seqs = append(seqs, seq)
+		return false
+	})
+	assert.Equal(t, []uint32{3}, seqs)
+
+	order, err := k.CreateOrder(ctx, gid, testGroupSpec())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(4), order.OSeq)
+}
+
+func TestKeeper_WithOrdersForGroup(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	owner := testAddress()
+	gid := dtypes.GroupID{Owner: owner, DSeq: 1, GSeq: 2}
+
+	var expected []mtypes.OrderID
+	for i := 0; i < 3; i++ {
+		order, err := k.CreateOrder(ctx, gid, testGroupSpec())
+		require.NoError(t, err)
+		expected = append(expected, order.ID())
+	}
+
+	// neighbouring groups of the same owner and another owner's group
+	for _, other := range []dtypes.GroupID{
+		{Owner: owner, DSeq: 1, GSeq: 1},
+		{Owner: owner, DSeq: 1, GSeq: 3},
+		{Owner: owner, DSeq: 2, GSeq: 2},
+		{Owner: testAddress(), DSeq: 1, GSeq: 2},
+	} {
+		_, err := k.CreateOrder(ctx, other, testGroupSpec())
+		require.NoError(t, err)
+	}
+
+	var found []mtypes.OrderID
+	k.WithOrdersForGroup(ctx, gid, func(order mtypes.Order) bool {
+		found = append(found, order.ID())
+		return false
+	})
+	assert.Equal(t, expected, found)
+}
+
+func TestKeeper_WithLeasesForDeployment(t *testing.T) {
+	ctx, k := setupKeeper(t)
+
+	owner := testAddress()
+
+	var expected []mtypes.LeaseID
+	for _, dseq := range []uint64{1, 2} {
+		for gseq := uint32(1); gseq <= 2; gseq++ {
+			order, err := k.CreateOrder(ctx, dtypes.GroupID{Owner: owner, DSeq: dseq, GSeq: gseq}, testGroupSpec())
+			require.NoError(t, err)
+			lease := createLease(t, ctx, k, order, testAddress())
+			if dseq == 2 {
+				expected = append(expected, lease.ID())
+			}
+		}
+	}
+
+	// other owner, same dseq
+	createLease(t, ctx, k, createOrder(t, ctx, k, 2), testAddress())
+
+	var found []mtypes.LeaseID
+	k.WithLeasesForDeployment(ctx, dtypes.DeploymentID{Owner: owner, DSeq: 2}, func(lease mtypes.Lease) bool {
+		found = append(found, lease.ID())
+		return false
+	})
+
+	require.Len(t, found, len(expected))
+	for idx := range expected {
+		assert.True(t, expected[idx].Equals(found[idx]))
+	}
+}
+
+func setupKeeper(t *testing.T) (sdk.Context, keeper.Keeper) {
+	t.Helper()
+	ctx, k, _ := setupKeeperWithBank(t)
+	return ctx, k
+}
+
+func setupKeeperWithBank(t *testing.T) (sdk.Context, keeper.Keeper, *testBankKeeper) {
+	t.Helper()
+
+	key := sdk.NewKVStoreKey(mtypes.StoreKey)
+	pkey := sdk.NewKVStoreKey(params.StoreKey)
+	tkey := sdk.NewTransientStoreKey(params.TStoreKey)
+
+	db := dbm.NewMemDB()
+	ms := store.NewCommitMultiStore(db)
+	ms.MountStoreWithDB(key, sdk.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(pkey, sdk.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(tkey, sdk.StoreTypeTransient, db)
+	require.NoError(t, ms.LoadLatestVersion())
+
+	ctx := sdk.NewContext(ms, abci.Header{Height: 1}, false, log.NewNopLogger())
+
+	cdc := codec.New()
+	mtypes.RegisterCodec(cdc)
+
+	pkeeper := params.NewKeeper(cdc, pkey, tkey)
+
+	bank := &testBankKeeper{balances: make(map[string]sdk.Coins)}
+
+	k := keeper.NewKeeper(cdc, key, pkeeper.Subspace(mtypes.DefaultParamspace), bank, bank)
+	k.SetParams(ctx, mtypes.DefaultParams())
+
+	return ctx, k, bank
+}
+
+type testBankKeeper struct {
+	balances map[string]sdk.Coins
+}
+
+func (b *testBankKeeper) SendCoins(_ sdk.Context, from sdk.AccAddress, to sdk.AccAddress, amt sdk.Coins) error {
+	balance, negative := b.balances[from.String()].SafeSub(amt)
+	if negative {
+		return sdkerrors.ErrInsufficientFunds
+	}
+	b.balances[from.String()] = balance
+	b.balances[to.String()] = b.balances[to.String()].Add(amt...)
+	return nil
+}
+
+func (b *testBankKeeper) SendCoinsFromAccountToModule(ctx sdk.Context, from sdk.AccAddress, module string, amt sdk.Coins) error {
+	return b.SendCoins(ctx, from, supply.NewModuleAddress(module), amt)
+}
+
+func (b *testBankKeeper) SendCoinsFromModuleToAccount(ctx sdk.Context, module string, to sdk.AccAddress, amt sdk.Coins) error {
+	return b.SendCoins(ctx, supply.NewModuleAddress(module), to, amt)
+}
+
+func testAddress() sdk.AccAddress {
+	return sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+}
+
+func testGroupSpec() dtypes.GroupSpec {
+	return dtypes.GroupSpec{
+		Name: "test",
+		Resources: []dtypes.Resource{
+			{
+				Unit: types.Unit{
+					CPU:     100,
+					Memory:  128 * 1024 * 1024,
+					Storage: 512 * 1024 * 1024,
+				},
+				Count: 1,
+				Price: sdk.NewInt64Coin("akash", 100),
+			},
+		},
+	}
+}
+
+func eventActions(events []mtypes.MarketEvent) []string {
+	var actions []string
+	for _, ev := range events {
+		for _, attr := range ev.Event.Attributes {
+			if attr.Key == sdk.AttributeKeyAction {
+				actions = append(actions, attr.Value)
+			}
+		}
+	}
+	return actions
+}
+
+// createDepositLease awards a lease on a new order to a provider holding
+// funds, whose bid escrows a deposit of 30.
+func createDepositLease(t *testing.T, ctx sdk.Context, k keeper.Keeper, bank *testBankKeeper, funds sdk.Coins) mtypes.Lease {
+	t.Helper()
+
+	order := createOrder(t, ctx, k, 1)
+	provider := testAddress()
+	bank.balances[provider.String()] = funds
+
+	deposit := sdk.NewInt64Coin("akash", 30)
+	require.NoError(t, k.CreateBid(ctx, order.ID(), provider, sdk.NewInt64Coin("akash", 10), 0, deposit))
+	bid, _ := k.GetBid(ctx, mtypes.MakeBidID(order.ID(), provider))
+
+	lease, err := k.AwardLease(ctx, order.ID(), bid)
+	require.NoError(t, err)
+	assert.Equal(t, funds.Sub(sdk.NewCoins(deposit)), bank.balances[provider.String()])
+	return lease
+}
+
+func createOrder(t *testing.T, ctx sdk.Context, k keeper.Keeper, dseq uint64) mtypes.Order {
+	t.Helper()
+	gid := dtypes.GroupID{Owner: testAddress(), DSeq: dseq, GSeq: 1}
+	order, err := k.CreateOrder(ctx, gid, testGroupSpec())
+	require.NoError(t, err)
+	return order
+}
+
+func createBid(t *testing.T, ctx sdk.Context, k keeper.Keeper, order mtypes.Order, provider sdk.AccAddress, price sdk.Coin) mtypes.Bid {
+	t.Helper()
+	require.NoError(t, k.CreateBid(ctx, order.ID(), provider, price, 0, sdk.NewInt64Coin("akash", 0)))
+	bid, ok := k.GetBid(ctx, mtypes.MakeBidID(order.ID(), provider))
+	require.True(t, ok)
+	return bid
+}
+
+func createLease(t *testing.T, ctx sdk.Context, k keeper.Keeper, order mtypes.Order, provider sdk.AccAddress) mtypes.Lease {
+	t.Helper()
+	bid := createBid(t, ctx, k, order, provider, sdk.NewInt64Coin("akash", 10))
+	require.NoError(t, k.CreateLease(ctx, bid))
+	lease, ok := k.GetLease(ctx, bid.ID().LeaseID())
+	require.True(t, ok)
+	return lease
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/binary"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
 	"github.com/ovrclk/akash/x/market/types"
 )
 
@@ -11,6 +13,17 @@ var (
 	orderPrefix = []byte{0x01, 0x00}
 	bidPrefix   = []byte{0x02, 0x00}
 	leasePrefix = []byte{0x03, 0x00}
+
+	providerBidPrefix = []byte{0x04, 0x00}
+	eventLogPrefix    = []byte{0x05, 0x00}
+
+	orderCreatedPrefix = []byte{0x06, 0x00}
+	orderSeqPrefix     = []byte{0x07, 0x00}
+
+	storeVersionKey = []byte{0x08, 0x00}
+
+	// height and next sequence of the market event log.
+	eventSeqKey = []byte{0x09, 0x00}
 )
 
 func orderKey(id types.OrderID) []byte {
@@ -22,6 +35,41 @@ func orderKey(id types.OrderID) []byte {
 	return buf.Bytes()
 }
 
+func ordersForOwnerPrefix(owner sdk.AccAddress) []byte {
+	buf := bytes.NewBuffer(orderPrefix)
+	buf.Write(owner.Bytes())
+	return buf.Bytes()
+}
+
+// ordersForGroupPrefix prefixes the keys of all orders of a group.
+func ordersForGroupPrefix(id dtypes.GroupID) []byte {
+	buf := bytes.NewBuffer(orderPrefix)
+	buf.Write(id.Owner.Bytes())
+	binary.Write(buf, binary.BigEndian, id.DSeq)
+	binary.Write(buf, binary.BigEndian, id.GSeq)
+	return buf.Bytes()
+}
+
+// orderSeqKey stores the last oseq assigned within a group.
+func orderSeqKey(id dtypes.GroupID) []byte {
+	buf := bytes.NewBuffer(orderSeqPrefix)
+	buf.Write(id.Owner.Bytes())
+	binary.Write(buf, binary.BigEndian, id.DSeq)
+	binary.Write(buf, binary.BigEndian, id.GSeq)
+	return buf.Bytes()
+}
+
+// parseOrderSeqKey returns the group of an orderSeqKey.
+func parseOrderSeqKey(key []byte) dtypes.GroupID {
+	key = key[len(orderSeqPrefix):]
+	n := len(key) - 12
+	return dtypes.GroupID{
+		Owner: sdk.AccAddress(append([]byte(nil), key[:n]...)),
+		DSeq:  binary.BigEndian.Uint64(key[n : n+8]),
+		GSeq:  binary.BigEndian.Uint32(key[n+8:]),
+	}
+}
+
 func bidKey(id types.BidID) []byte {
 	buf := bytes.NewBuffer(bidPrefix)
 	buf.Write(id.Owner.Bytes())
@@ -41,3 +89,63 @@ func leaseKey(id types.LeaseID) []byte {
 	buf.Write(id.Provider.Bytes())
 	return buf.Bytes()
 }
+
+func bidsForOrderPrefix(id types.OrderID) []byte {
+	buf := bytes.NewBuffer(bidPrefix)
+	buf.Write(id.Owner.Bytes())
+	binary.Write(buf, binary.BigEndian, id.DSeq)
+	binary.Write(buf, binary.BigEndian, id.GSeq)
+	binary.Write(buf, binary.BigEndian, id.OSeq)
+	return buf.Bytes()
+}
+
+func leasesForDeploymentPrefix(id dtypes.DeploymentID) []byte {
+	buf := bytes.NewBuffer(leasePrefix)
+	buf.Write(id.Owner.Bytes())
+	binary.Write(buf, binary.BigEndian, id.DSeq)
+	return buf.Bytes()
+}
+
+// providerBidKey indexes bids by provider; the stored value is the bid key.
+// Entries are added and removed as the bid changes state (see
+// updateProviderBidIndex).
+func providerBidKey(id types.BidID) []byte {
+	buf := bytes.NewBuffer(providerBidsPrefix(id.Provider))
+	buf.Write(id.Owner.Bytes())
+	binary.Write(buf, binary.BigEndian, id.DSeq)
+	binary.Write(buf, binary.BigEndian, id.GSeq)
+	binary.Write(buf, binary.BigEndian, id.OSeq)
+	return buf.Bytes()
+}
+
+func providerBidsPrefix(provider sdk.AccAddress) []byte {
+	buf := bytes.NewBuffer(providerBidPrefix)
+	buf.Write(provider.Bytes())
+	return buf.Bytes()
+}
+
+func eventLogKey(height int64, seq uint32) []byte {
+	buf := bytes.NewBuffer(eventLogHeightPrefix(height))
+	binary.Write(buf, binary.BigEndian, seq)
+	return buf.Bytes()
+}
+
+func eventLogHeightPrefix(height int64) []byte {
+	buf := bytes.NewBuffer(eventLogPrefix)
+	binary.Write(buf, binary.BigEndian, height)
+	return buf.Bytes()
+}
+
+// orderCreatedKey indexes orders by creation height; the stored value is the
+// order key.
+func orderCreatedKey(height int64, id types.OrderID) []byte {
+	buf := bytes.NewBuffer(orderCreatedHeightPrefix(height))
+	buf.Write(orderKey(id))
+	return buf.Bytes()
+}
+
+func orderCreatedHeightPrefix(height int64) []byte {
+	buf := bytes.NewBuffer(orderCreatedPrefix)
+	binary.Write(buf, binary.BigEndian, height)
+	return buf.Bytes()
+}
@@ -0,0 +1,123 @@
+package keeper
+
+import (
+	"encoding/binary"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/upgrade"
+	"github.com/ovrclk/akash/x/market/types"
+)
+
+// migration upgrades the store by one version.
+type migration func(k Keeper, ctx sdk.Context) error
+
+// migrations[i] upgrades the store from version i+1 to i+2.  New layouts are
+// introduced by appending a migration.
+var migrations = []migration{
+	migrateV1ToV2,
+}
+
+// CurrentStoreVersion is the layout version of the market store written by
+// this keeper.
+var CurrentStoreVersion = uint64(len(migrations) + 1)
+
+// GetStoreVersion returns the layout version of the market store.  Stores
+// written before versioning was introduced are version 1.
+func (k Keeper) GetStoreVersion(ctx sdk.Context) uint64 {
+	buf := ctx.KVStore(k.skey).Get(storeVersionKey)
+	if buf == nil {
+		return 1
+	}
+	return binary.BigEndian.Uint64(buf)
+}
+
+// SetStoreVersion records the layout version of the market store.
+func (k Keeper) SetStoreVersion(ctx sdk.Context, version uint64) {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, version)
+	ctx.KVStore(k.skey).Set(storeVersionKey, buf)
+}
+
+// Migrate upgrades the store from fromVersion to CurrentStoreVersion, running
+// each migration in order and recording the version reached after each.
+func (k Keeper) Migrate(ctx sdk.Context, fromVersion uint64) error {
+	if fromVersion < 1 || fromVersion > CurrentStoreVersion {
+		return types.ErrUnknownStoreVersion
+	}
+
+	for version := fromVersion; version < CurrentStoreVersion; version++ {
+		if err := migrations[version-1](k, ctx); err != nil {
+			return err
+		}
+		k.SetStoreVersion(ctx, version+1)
+		ctx.Logger().Info("migrated market store", "version", version+1)
+	}
+	return nil
+}
+
+// UpgradeHandler migrates the store from its recorded version when a
+// software upgrade plan is applied.
+func (k Keeper) UpgradeHandler() upgrade.UpgradeHandler {
+	return func(ctx sdk.Context, _ upgrade.Plan) {
+		if err := k.Migrate(ctx, k.GetStoreVersion(ctx)); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// migrateV1ToV2 introduces store versioning and backfills what version 1
+// stores were written without: the order creation height index, the
+// per-group order sequence counters, the provider bid index, and the
+// heartbeat, creation and settlement heights of active leases.  Leases are
+// given the current height, so heartbeats and payments are counted from the
+// upgrade.  Params added since version 1, such as MinBidDeposit, are set to
+// their defaults.
+func migrateV1ToV2(k Keeper, ctx sdk.Context) error {
+	store := ctx.KVStore(k.skey)
+
+	var orders []types.Order
+	k.WithOrders(ctx, func(order types.Order) bool {
+		orders = append(orders, order)
+		return false
+	})
+
+	for _, order := range orders {
+		store.Set(orderCreatedKey(order.CreatedAt, order.ID()), orderKey(order.ID()))
+
+		gid := order.GroupID()
+		if buf := store.Get(orderSeqKey(gid)); buf == nil || binary.BigEndian.Uint32(buf) < order.OSeq {
+			k.setOrderSeq(ctx, gid, order.OSeq)
+		}
+	}
+
+	var leases []types.Lease
+	k.WithLeases(ctx, func(lease types.Lease) bool {
+		if lease.State == types.LeaseActive {
+			leases = append(leases, lease)
+		}
+		return false
+	})
+
+	for _, lease := range leases {
+		if lease.Heartbeat == 0 {
+			lease.Heartbeat = ctx.BlockHeight()
+		}
+		if lease.CreatedAt == 0 {
+			lease.CreatedAt = ctx.BlockHeight()
+		}
+		if lease.SettledAt == 0 {
+			lease.SettledAt = ctx.BlockHeight()
+		}
+		k.updateLease(ctx, lease)
+	}
+
+	defaults := types.DefaultParams()
+	for _, pair := range defaults.ParamSetPairs() {
+		if !k.pspace.Has(ctx, pair.Key) {
+			k.pspace.Set(ctx, pair.Key, pair.Value)
+		}
+	}
+
+	k.RebuildProviderBidIndex(ctx)
+	return nil
+}
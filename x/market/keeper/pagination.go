@@ -0,0 +1,82 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ovrclk/akash/x/market/types"
+)
+
+// WithOrdersPaginated calls fn with one page of orders, resuming from
+// req.Key.  Iteration also stops early if fn returns true, in which case the
+// next page starts after the last order fn was called with.
+func (k Keeper) WithOrdersPaginated(ctx sdk.Context, req types.PageRequest, fn func(types.Order) bool) types.PageResponse {
+	return k.paginate(ctx, orderPrefix, req, func(value []byte) bool {
+		var val types.Order
+		k.cdc.MustUnmarshalBinaryBare(value, &val)
+		return fn(val)
+	})
+}
+
+// WithBidsPaginated is WithOrdersPaginated for bids.
+func (k Keeper) WithBidsPaginated(ctx sdk.Context, req types.PageRequest, fn func(types.Bid) bool) types.PageResponse {
+	return k.paginate(ctx, bidPrefix, req, func(value []byte) bool {
+		var val types.Bid
+		k.cdc.MustUnmarshalBinaryBare(value, &val)
+		return fn(val)
+	})
+}
+
+// WithLeasesPaginated is WithOrdersPaginated for leases.
+func (k Keeper) WithLeasesPaginated(ctx sdk.Context, req types.PageRequest, fn func(types.Lease) bool) types.PageResponse {
+	return k.paginate(ctx, leasePrefix, req, func(value []byte) bool {
+		var val types.Lease
+		k.cdc.MustUnmarshalBinaryBare(value, &val)
+		return fn(val)
+	})
+}
+
+// paginate iterates up to req.Limit (capped at MaxPageLimit) values under
+// prefix, starting at req.Key, which is relative to prefix.  Counting the
+// total walks every key under prefix without decoding values.
+func (k Keeper) paginate(ctx sdk.Context, prefix []byte, req types.PageRequest, fn func([]byte) bool) types.PageResponse {
+	limit := req.Limit
+	if limit == 0 || limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	store := ctx.KVStore(k.skey)
+	start := append(append([]byte{}, prefix...), req.Key...)
+	iter := store.Iterator(start, sdk.PrefixEndBytes(prefix))
+	defer iter.Close()
+
+	var (
+		res     types.PageResponse
+		count   uint64
+		stopped bool
+	)
+
+	for ; iter.Valid(); iter.Next() {
+		if count == limit || stopped {
+			res.NextKey = append([]byte{}, iter.Key()[len(prefix):]...)
+			break
+		}
+		count++
+		stopped = fn(iter.Value())
+	}
+
+	if req.CountTotal {
+		res.Total = countKeys(store, prefix)
+	}
+
+	return res
+}
+
+func countKeys(store sdk.KVStore, prefix []byte) uint64 {
+	iter := sdk.KVStorePrefixIterator(store, prefix)
+	defer iter.Close()
+
+	var count uint64
+	for ; iter.Valid(); iter.Next() {
+		count++
+	}
+	return count
+}
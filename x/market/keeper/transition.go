@@ -0,0 +1,57 @@
+package keeper
+
+import (
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/ovrclk/akash/x/market/types"
+)
+
+// valid state transitions.  Terminal states have no entries.
+var (
+	orderTransitions = map[types.OrderState][]types.OrderState{
+		types.OrderOpen:    {types.OrderMatched, types.OrderClosed},
+		types.OrderMatched: {types.OrderClosed},
+	}
+
+	bidTransitions = map[types.BidState][]types.BidState{
+		types.BidOpen:    {types.BidMatched, types.BidLost, types.BidClosed},
+		types.BidMatched: {types.BidClosed},
+	}
+
+	leaseTransitions = map[types.LeaseState][]types.LeaseState{
+		types.LeaseActive: {types.LeaseClosed, types.LeaseInsufficientFunds},
+	}
+)
+
+// assertTransition returns ErrInvalidTransition unless moving from current
+// to next is allowed.  Both states must be of the same kind: order, bid or
+// lease.
+func assertTransition(current, next interface{}) error {
+	if !validTransition(current, next) {
+		return sdkerrors.Wrapf(types.ErrInvalidTransition, "%T %v to %v", current, current, next)
+	}
+	return nil
+}
+
+func validTransition(current, next interface{}) bool {
+	switch current := current.(type) {
+	case types.OrderState:
+		for _, state := range orderTransitions[current] {
+			if state == next {
+				return true
+			}
+		}
+	case types.BidState:
+		for _, state := range bidTransitions[current] {
+			if state == next {
+				return true
+			}
+		}
+	case types.LeaseState:
+		for _, state := range leaseTransitions[current] {
+			if state == next {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -4,14 +4,31 @@ import (
 	"fmt"
 
 	"github.com/cosmos/cosmos-sdk/client/context"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
 	"github.com/ovrclk/akash/x/market/types"
 )
 
 type Client interface {
 	Orders() (Orders, error)
+	Order(id types.OrderID) (Order, error)
 	Bids() (Bids, error)
 	Bid(id types.BidID) (Bid, error)
 	Leases() (Leases, error)
+	Lease(id types.LeaseID) (Lease, error)
+	ActiveProviders() (ActiveProviders, error)
+	DeploymentLeases(id dtypes.DeploymentID) (Leases, error)
+	ResourceSupplyDemand() (ResourceSupplyDemand, error)
+	ProviderBidStats(provider sdk.AccAddress, from, to int64) (ProviderBidStats, error)
+	OrderBids(id types.OrderID, page, limit uint32) (OrderBids, error)
+	LeaseDurations() (LeaseDurationStats, error)
+	MarketEvents(from, to int64) (MarketEvents, error)
+	OrderWithBestBid(id types.OrderID) (OrderWithBestBid, error)
+	LeasesByTag(key, value string) (Leases, error)
+	OrdersWithoutBids(minAge int64) (Orders, error)
+	PriceHistory(specHash []byte, window int64) (PriceHistory, error)
+	OrdersByState(state types.OrderState) (Orders, error)
+	TotalEscrowed() (sdk.Coins, error)
 }
 
 func NewClient(ctx context.CLIContext, key string) Client {
@@ -32,6 +49,15 @@ func (c *client) Orders() (Orders, error) {
 	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
 }
 
+func (c *client) Order(id types.OrderID) (Order, error) {
+	var obj Order
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, OrderPath(id)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
 func (c *client) Bids() (Bids, error) {
 	var obj Bids
 	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, BidsPath()), nil)
@@ -42,7 +68,12 @@ func (c *client) Bids() (Bids, error) {
 }
 
 func (c *client) Bid(id types.BidID) (Bid, error) {
-	return Bid{}, fmt.Errorf("TODO: not implemented")
+	var obj Bid
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, BidPath(id)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
 }
 
 func (c *client) Leases() (Leases, error) {
@@ -53,3 +84,129 @@ func (c *client) Leases() (Leases, error) {
 	}
 	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
 }
+
+func (c *client) Lease(id types.LeaseID) (Lease, error) {
+	var obj Lease
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, LeasePath(id)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) ActiveProviders() (ActiveProviders, error) {
+	var obj ActiveProviders
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, ActiveProvidersPath()), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) DeploymentLeases(id dtypes.DeploymentID) (Leases, error) {
+	var obj Leases
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, DeploymentLeasesPath(id)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) ResourceSupplyDemand() (ResourceSupplyDemand, error) {
+	var obj ResourceSupplyDemand
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, ResourceSupplyDemandPath()), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) ProviderBidStats(provider sdk.AccAddress, from, to int64) (ProviderBidStats, error) {
+	var obj ProviderBidStats
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, ProviderBidStatsPath(provider, from, to)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) OrderBids(id types.OrderID, page, limit uint32) (OrderBids, error) {
+	var obj OrderBids
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, OrderBidsPath(id, page, limit)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) LeaseDurations() (LeaseDurationStats, error) {
+	var obj LeaseDurationStats
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, LeaseDurationsPath()), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) TotalEscrowed() (sdk.Coins, error) {
+	var obj sdk.Coins
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, TotalEscrowedPath()), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) MarketEvents(from, to int64) (MarketEvents, error) {
+	var obj MarketEvents
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, MarketEventsPath(from, to)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) OrderWithBestBid(id types.OrderID) (OrderWithBestBid, error) {
+	var obj OrderWithBestBid
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, OrderBestBidPath(id)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) LeasesByTag(key, value string) (Leases, error) {
+	var obj Leases
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, LeasesByTagPath(key, value)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) OrdersWithoutBids(minAge int64) (Orders, error) {
+	var obj Orders
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, OrdersWithoutBidsPath(minAge)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) PriceHistory(specHash []byte, window int64) (PriceHistory, error) {
+	var obj PriceHistory
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, PriceHistoryPath(specHash, window)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
+
+func (c *client) OrdersByState(state types.OrderState) (Orders, error) {
+	var obj Orders
+	buf, _, err := c.ctx.QueryWithData(fmt.Sprintf("custom/%s/%s", c.key, OrdersByStatePath(state)), nil)
+	if err != nil {
+		return obj, err
+	}
+	return obj, c.ctx.Codec.UnmarshalJSON(buf, &obj)
+}
@@ -1,8 +1,14 @@
 package query
 
 import (
+	"encoding/hex"
 	"fmt"
+	"net/url"
+	"strconv"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	dquery "github.com/ovrclk/akash/x/deployment/query"
+	dtypes "github.com/ovrclk/akash/x/deployment/types"
 	"github.com/ovrclk/akash/x/market/types"
 )
 
@@ -13,6 +19,20 @@ const (
 	bidPath    = "bid"
 	leasesPath = "leases"
 	leasePath  = "lease"
+
+	activeProvidersPath      = "active-providers"
+	deploymentLeasesPath     = "deployment-leases"
+	resourceSupplyDemandPath = "resource-supply-demand"
+	providerBidStatsPath     = "provider-bid-stats"
+	orderBidsPath            = "order-bids"
+	leaseDurationsPath       = "lease-durations"
+	marketEventsPath         = "events"
+	orderBestBidPath         = "order-best-bid"
+	leasesByTagPath          = "leases-by-tag"
+	ordersWithoutBidsPath    = "orders-without-bids"
+	priceHistoryPath         = "price-history"
+	ordersByStatePath        = "orders-by-state"
+	totalEscrowedPath        = "total-escrowed"
 )
 
 func OrdersPath() string {
@@ -39,6 +59,232 @@ func LeasePath(id types.LeaseID) string {
 	return fmt.Sprintf("%s/%s/%s", leasePath, orderParts(id.OrderID()), id.Provider)
 }
 
+func ActiveProvidersPath() string {
+	return activeProvidersPath
+}
+
+func DeploymentLeasesPath(id dtypes.DeploymentID) string {
+	return fmt.Sprintf("%s/%s/%v", deploymentLeasesPath, id.Owner, id.DSeq)
+}
+
+func ResourceSupplyDemandPath() string {
+	return resourceSupplyDemandPath
+}
+
+func ProviderBidStatsPath(provider sdk.AccAddress, from, to int64) string {
+	return fmt.Sprintf("%s/%s/%v/%v", providerBidStatsPath, provider, from, to)
+}
+
+func LeaseDurationsPath() string {
+	return leaseDurationsPath
+}
+
+func TotalEscrowedPath() string {
+	return totalEscrowedPath
+}
+
+func MarketEventsPath(from, to int64) string {
+	return fmt.Sprintf("%s/%v/%v", marketEventsPath, from, to)
+}
+
+func OrderBestBidPath(id types.OrderID) string {
+	return fmt.Sprintf("%s/%s", orderBestBidPath, orderParts(id))
+}
+
+func LeasesByTagPath(key, value string) string {
+	return fmt.Sprintf("%s/%s/%s", leasesByTagPath, url.PathEscape(key), url.PathEscape(value))
+}
+
+func OrdersWithoutBidsPath(minAge int64) string {
+	return fmt.Sprintf("%s/%v", ordersWithoutBidsPath, minAge)
+}
+
+func OrdersByStatePath(state types.OrderState) string {
+	return fmt.Sprintf("%s/%v", ordersByStatePath, state)
+}
+
+func PriceHistoryPath(specHash []byte, window int64) string {
+	return fmt.Sprintf("%s/%s/%v", priceHistoryPath, hex.EncodeToString(specHash), window)
+}
+
+func OrderBidsPath(id types.OrderID, page, limit uint32) string {
+	return fmt.Sprintf("%s/%s/%v/%v", orderBidsPath, orderParts(id), page, limit)
+}
+
+func ParseOrderPath(parts []string) (types.OrderID, error) {
+	if len(parts) < 4 {
+		return types.OrderID{}, fmt.Errorf("invalid path")
+	}
+
+	gid, err := dquery.ParseGroupPath(parts[0:3])
+	if err != nil {
+		return types.OrderID{}, err
+	}
+
+	oseq, err := strconv.ParseUint(parts[3], 10, 32)
+	if err != nil {
+		return types.OrderID{}, err
+	}
+
+	return types.MakeOrderID(gid, uint32(oseq)), nil
+}
+
+func parseBidPath(parts []string) (types.BidID, error) {
+	if len(parts) < 5 {
+		return types.BidID{}, fmt.Errorf("invalid path")
+	}
+
+	oid, err := ParseOrderPath(parts[0:4])
+	if err != nil {
+		return types.BidID{}, err
+	}
+
+	provider, err := sdk.AccAddressFromBech32(parts[4])
+	if err != nil {
+		return types.BidID{}, err
+	}
+
+	return types.MakeBidID(oid, provider), nil
+}
+
+func parseLeasePath(parts []string) (types.LeaseID, error) {
+	id, err := parseBidPath(parts)
+	if err != nil {
+		return types.LeaseID{}, err
+	}
+	return id.LeaseID(), nil
+}
+
+func parseOrderBidsPath(parts []string) (types.OrderID, uint32, uint32, error) {
+	if len(parts) < 6 {
+		return types.OrderID{}, 0, 0, fmt.Errorf("invalid path")
+	}
+
+	id, err := ParseOrderPath(parts[0:4])
+	if err != nil {
+		return types.OrderID{}, 0, 0, err
+	}
+
+	page, err := strconv.ParseUint(parts[4], 10, 32)
+	if err != nil {
+		return types.OrderID{}, 0, 0, err
+	}
+
+	limit, err := strconv.ParseUint(parts[5], 10, 32)
+	if err != nil {
+		return types.OrderID{}, 0, 0, err
+	}
+
+	return id, uint32(page), uint32(limit), nil
+}
+
+func parseProviderBidStatsPath(parts []string) (sdk.AccAddress, int64, int64, error) {
+	if len(parts) < 3 {
+		return nil, 0, 0, fmt.Errorf("invalid path")
+	}
+
+	provider, err := sdk.AccAddressFromBech32(parts[0])
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	from, to, err := parseHeightRangePath(parts[1:3])
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return provider, from, to, nil
+}
+
+func parseHeightRangePath(parts []string) (int64, int64, error) {
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("invalid path")
+	}
+
+	from, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	to, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return from, to, nil
+}
+
+func parseLeasesByTagPath(parts []string) (string, string, error) {
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("invalid path")
+	}
+
+	key, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return "", "", err
+	}
+
+	value, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return "", "", err
+	}
+
+	return key, value, nil
+}
+
+func parseOrdersWithoutBidsPath(parts []string) (int64, error) {
+	if len(parts) < 1 {
+		return 0, fmt.Errorf("invalid path")
+	}
+
+	minAge, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if minAge < 0 {
+		return 0, fmt.Errorf("invalid age: %v", minAge)
+	}
+
+	return minAge, nil
+}
+
+func parsePriceHistoryPath(parts []string) ([]byte, int64, error) {
+	if len(parts) < 2 {
+		return nil, 0, fmt.Errorf("invalid path")
+	}
+
+	specHash, err := hex.DecodeString(parts[0])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	window, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, 0, err
+	}
+	if window < 0 {
+		return nil, 0, fmt.Errorf("invalid window: %v", window)
+	}
+
+	return specHash, window, nil
+}
+
+func parseOrdersByStatePath(parts []string) (types.OrderState, error) {
+	if len(parts) < 1 {
+		return 0, fmt.Errorf("invalid path")
+	}
+
+	state, err := strconv.ParseUint(parts[0], 10, 8)
+	if err != nil {
+		return 0, err
+	}
+	if types.OrderState(state) > types.OrderClosed {
+		return 0, fmt.Errorf("invalid order state: %v", state)
+	}
+
+	return types.OrderState(state), nil
+}
+
 func orderParts(id types.OrderID) string {
 	return fmt.Sprintf("%s/%v/%v/%v", id.Owner, id.DSeq, id.GSeq, id.OSeq)
 }
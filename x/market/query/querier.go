@@ -4,6 +4,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
 	"github.com/ovrclk/akash/sdkutil"
+	dquery "github.com/ovrclk/akash/x/deployment/query"
 	"github.com/ovrclk/akash/x/market/keeper"
 	"github.com/ovrclk/akash/x/market/types"
 	abci "github.com/tendermint/tendermint/abci/types"
@@ -14,10 +15,42 @@ func NewQuerier(keeper keeper.Keeper) sdk.Querier {
 		switch path[0] {
 		case ordersPath:
 			return queryOrders(ctx, path[1:], req, keeper)
+		case orderPath:
+			return queryOrder(ctx, path[1:], req, keeper)
 		case bidsPath:
 			return queryBids(ctx, path[1:], req, keeper)
+		case bidPath:
+			return queryBid(ctx, path[1:], req, keeper)
 		case leasesPath:
 			return queryLeases(ctx, path[1:], req, keeper)
+		case leasePath:
+			return queryLease(ctx, path[1:], req, keeper)
+		case activeProvidersPath:
+			return queryActiveProviders(ctx, path[1:], req, keeper)
+		case deploymentLeasesPath:
+			return queryDeploymentLeases(ctx, path[1:], req, keeper)
+		case resourceSupplyDemandPath:
+			return queryResourceSupplyDemand(ctx, path[1:], req, keeper)
+		case providerBidStatsPath:
+			return queryProviderBidStats(ctx, path[1:], req, keeper)
+		case orderBidsPath:
+			return queryOrderBids(ctx, path[1:], req, keeper)
+		case leaseDurationsPath:
+			return queryLeaseDurations(ctx, path[1:], req, keeper)
+		case marketEventsPath:
+			return queryMarketEvents(ctx, path[1:], req, keeper)
+		case orderBestBidPath:
+			return queryOrderWithBestBid(ctx, path[1:], req, keeper)
+		case leasesByTagPath:
+			return queryLeasesByTag(ctx, path[1:], req, keeper)
+		case ordersWithoutBidsPath:
+			return queryOrdersWithoutBids(ctx, path[1:], req, keeper)
+		case priceHistoryPath:
+			return queryPriceHistory(ctx, path[1:], req, keeper)
+		case ordersByStatePath:
+			return queryOrdersByState(ctx, path[1:], req, keeper)
+		case totalEscrowedPath:
+			return queryTotalEscrowed(ctx, path[1:], req, keeper)
 		}
 		return []byte{}, sdkerrors.ErrUnknownRequest
 	}
@@ -32,6 +65,19 @@ func queryOrders(ctx sdk.Context, path []string, req abci.RequestQuery, keeper k
 	return sdkutil.RenderQueryResponse(keeper.Codec(), values)
 }
 
+func queryOrder(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	id, err := ParseOrderPath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+
+	order, ok := keeper.GetOrder(ctx, id)
+	if !ok {
+		return nil, types.ErrUnknownOrder
+	}
+	return sdkutil.RenderQueryResponse(keeper.Codec(), Order(order))
+}
+
 func queryBids(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
 	var values Bids
 	keeper.WithBids(ctx, func(obj types.Bid) bool {
@@ -41,6 +87,19 @@ func queryBids(ctx sdk.Context, path []string, req abci.RequestQuery, keeper kee
 	return sdkutil.RenderQueryResponse(keeper.Codec(), values)
 }
 
+func queryBid(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	id, err := parseBidPath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+
+	bid, ok := keeper.GetBid(ctx, id)
+	if !ok {
+		return nil, types.ErrUnknownBid
+	}
+	return sdkutil.RenderQueryResponse(keeper.Codec(), Bid(bid))
+}
+
 func queryLeases(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
 	var values Leases
 	keeper.WithLeases(ctx, func(obj types.Lease) bool {
@@ -49,3 +108,153 @@ func queryLeases(ctx sdk.Context, path []string, req abci.RequestQuery, keeper k
 	})
 	return sdkutil.RenderQueryResponse(keeper.Codec(), values)
 }
+
+func queryLease(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	id, err := parseLeasePath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+
+	lease, ok := keeper.GetLease(ctx, id)
+	if !ok {
+		return nil, types.ErrUnknownLease
+	}
+	return sdkutil.RenderQueryResponse(keeper.Codec(), Lease(lease))
+}
+
+func queryActiveProviders(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	values := ActiveProviders(keeper.ActiveProviders(ctx))
+	return sdkutil.RenderQueryResponse(keeper.Codec(), values)
+}
+
+func queryDeploymentLeases(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	id, err := dquery.ParseDeploymentPath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+
+	var values Leases
+	keeper.WithLeasesForDeployment(ctx, id, func(obj types.Lease) bool {
+		values = append(values, Lease(obj))
+		return false
+	})
+	return sdkutil.RenderQueryResponse(keeper.Codec(), values)
+}
+
+func queryResourceSupplyDemand(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	value := ResourceSupplyDemand(keeper.ResourceSupplyDemand(ctx))
+	return sdkutil.RenderQueryResponse(keeper.Codec(), value)
+}
+
+func queryProviderBidStats(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	provider, from, to, err := parseProviderBidStatsPath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+	value := ProviderBidStats(keeper.ProviderBidStats(ctx, provider, from, to))
+	return sdkutil.RenderQueryResponse(keeper.Codec(), value)
+}
+
+func queryOrderBids(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	id, page, limit, err := parseOrderBidsPath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+
+	bids, next := keeper.BidsForOrderPage(ctx, id, page, limit)
+
+	value := OrderBids{NextPage: next}
+	for _, bid := range bids {
+		value.Bids = append(value.Bids, Bid(bid))
+	}
+	return sdkutil.RenderQueryResponse(keeper.Codec(), value)
+}
+
+func queryLeaseDurations(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	value := LeaseDurationStats(keeper.LeaseDurationStats(ctx))
+	return sdkutil.RenderQueryResponse(keeper.Codec(), value)
+}
+
+func queryTotalEscrowed(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	return sdkutil.RenderQueryResponse(keeper.Codec(), keeper.TotalEscrowed(ctx))
+}
+
+func queryMarketEvents(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	from, to, err := parseHeightRangePath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+	value := MarketEvents(keeper.MarketEvents(ctx, from, to))
+	return sdkutil.RenderQueryResponse(keeper.Codec(), value)
+}
+
+func queryOrderWithBestBid(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	id, err := ParseOrderPath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+
+	order, ok := keeper.GetOrder(ctx, id)
+	if !ok {
+		return nil, types.ErrUnknownOrder
+	}
+
+	value := OrderWithBestBid{Order: Order(order)}
+	if bid, ok := keeper.BestBidForOrder(ctx, id); ok {
+		best := Bid(bid)
+		value.BestBid = &best
+	}
+	return sdkutil.RenderQueryResponse(keeper.Codec(), value)
+}
+
+func queryLeasesByTag(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	key, value, err := parseLeasesByTagPath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+
+	var values Leases
+	keeper.WithLeases(ctx, func(obj types.Lease) bool {
+		if obj.HasTag(key, value) {
+			values = append(values, Lease(obj))
+		}
+		return false
+	})
+	return sdkutil.RenderQueryResponse(keeper.Codec(), values)
+}
+
+func queryOrdersWithoutBids(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	minAge, err := parseOrdersWithoutBidsPath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+
+	var values Orders
+	for _, obj := range keeper.OrdersWithoutBids(ctx, minAge) {
+		values = append(values, Order(obj))
+	}
+	return sdkutil.RenderQueryResponse(keeper.Codec(), values)
+}
+
+func queryPriceHistory(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	specHash, window, err := parsePriceHistoryPath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+
+	value := PriceHistory(keeper.PriceHistory(ctx, specHash, window))
+	return sdkutil.RenderQueryResponse(keeper.Codec(), value)
+}
+
+func queryOrdersByState(ctx sdk.Context, path []string, req abci.RequestQuery, keeper keeper.Keeper) ([]byte, error) {
+	state, err := parseOrdersByStatePath(path)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	}
+
+	var values Orders
+	for _, obj := range keeper.OrdersByState(ctx, state) {
+		values = append(values, Order(obj))
+	}
+	return sdkutil.RenderQueryResponse(keeper.Codec(), values)
+}
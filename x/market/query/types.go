@@ -11,8 +11,33 @@ type (
 
 	Lease  types.Lease
 	Leases []Lease
+
+	ActiveProviders []types.ProviderLeaseCount
+
+	ResourceSupplyDemand types.ResourceSupplyDemand
+
+	ProviderBidStats types.ProviderBidStats
+
+	LeaseDurationStats types.LeaseDurationStats
+
+	MarketEvents []types.MarketEvent
+
+	PriceHistory []types.PricePoint
 )
 
+// OrderBids is a page of bids for a single order.  NextPage is zero when
+// there are no more bids.
+type OrderBids struct {
+	Bids     Bids   `json:"bids"`
+	NextPage uint32 `json:"next-page"`
+}
+
+// OrderWithBestBid is an order with its cheapest open bid, if any.
+type OrderWithBestBid struct {
+	Order   Order `json:"order"`
+	BestBid *Bid  `json:"best-bid,omitempty"`
+}
+
 func (obj Order) String() string {
 	return "TODO see deployment/query/types.go"
 }
@@ -33,3 +58,31 @@ func (obj Lease) String() string {
 func (obj Leases) String() string {
 	return "TODO see deployment/query/types.go"
 }
+
+func (obj ActiveProviders) String() string {
+	return "TODO see deployment/query/types.go"
+}
+
+func (obj ResourceSupplyDemand) String() string {
+	return "TODO see deployment/query/types.go"
+}
+
+func (obj ProviderBidStats) String() string {
+	return "TODO see deployment/query/types.go"
+}
+
+func (obj LeaseDurationStats) String() string {
+	return "TODO see deployment/query/types.go"
+}
+
+func (obj MarketEvents) String() string {
+	return "TODO see deployment/query/types.go"
+}
+
+func (obj OrderWithBestBid) String() string {
+	return "TODO see deployment/query/types.go"
+}
+
+func (obj OrderBids) String() string {
+	return "TODO see deployment/query/types.go"
+}
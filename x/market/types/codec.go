@@ -2,18 +2,32 @@ package types
 
 import (
 	"github.com/cosmos/cosmos-sdk/codec"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
 )
 
 var cdc = codec.New()
 
 func init() {
 	RegisterCodec(cdc)
+
+	// MsgSubmitProposal sign bytes are produced by the gov module codec.
+	govtypes.RegisterProposalType(ProposalTypeLeasePriceUpdate)
+	registerProposals(govtypes.ModuleCdc)
 }
 
 func RegisterCodec(cdc *codec.Codec) {
 	cdc.RegisterConcrete(MsgCloseOrder{}, ModuleName+"/msg-close-order", nil)
+	cdc.RegisterConcrete(MsgCancelOrder{}, ModuleName+"/msg-cancel-order", nil)
 	cdc.RegisterConcrete(MsgCreateBid{}, ModuleName+"/msg-create-bid", nil)
 	cdc.RegisterConcrete(MsgCloseBid{}, ModuleName+"/msg-close-bid", nil)
+	cdc.RegisterConcrete(MsgWithdrawBid{}, ModuleName+"/msg-withdraw-bid", nil)
+	cdc.RegisterConcrete(MsgProviderCloseLease{}, ModuleName+"/msg-provider-close-lease", nil)
+	cdc.RegisterConcrete(MsgLeaseHeartbeat{}, ModuleName+"/msg-lease-heartbeat", nil)
+	registerProposals(cdc)
+}
+
+func registerProposals(cdc *codec.Codec) {
+	cdc.RegisterConcrete(LeasePriceUpdateProposal{}, ModuleName+"/lease-price-update-proposal", nil)
 }
 
 func MustMarshalJSON(o interface{}) []byte {
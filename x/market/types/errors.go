@@ -5,17 +5,39 @@ import (
 )
 
 var (
-	ErrInvalidOrder       = sdkerrors.Register(ModuleName, 1, "invalid: order id")
-	ErrEmptyProvider      = sdkerrors.Register(ModuleName, 2, "empty provider")
-	ErrSameAccount        = sdkerrors.Register(ModuleName, 3, "owner and provider are the same account")
-	ErrInternal           = sdkerrors.Register(ModuleName, 4, "internal error")
-	ErrBidOverOrder       = sdkerrors.Register(ModuleName, 5, "bid price above max order price")
-	ErrAtributeMismatch   = sdkerrors.Register(ModuleName, 6, "atribute mismatch")
-	ErrUnknownBid         = sdkerrors.Register(ModuleName, 7, "unknown bid")
-	ErrUnknownLeaseForBid = sdkerrors.Register(ModuleName, 8, "unknown lease for bid")
-	ErrUnknownOrderForBid = sdkerrors.Register(ModuleName, 9, "unknown order for bid")
-	ErrLeaseNotActive     = sdkerrors.Register(ModuleName, 10, "lease not active")
-	ErrBidNotMatched      = sdkerrors.Register(ModuleName, 11, "bid not matched")
-	ErrUnknownOrder       = sdkerrors.Register(ModuleName, 12, "unknown order")
-	ErrNoLeaseForOrder    = sdkerrors.Register(ModuleName, 13, "no lease for order")
+	ErrInvalidOrder          = sdkerrors.Register(ModuleName, 1, "invalid: order id")
+	ErrEmptyProvider         = sdkerrors.Register(ModuleName, 2, "empty provider")
+	ErrSameAccount           = sdkerrors.Register(ModuleName, 3, "owner and provider are the same account")
+	ErrInternal              = sdkerrors.Register(ModuleName, 4, "internal error")
+	ErrBidOverOrder          = sdkerrors.Register(ModuleName, 5, "bid price above max order price")
+	ErrAtributeMismatch      = sdkerrors.Register(ModuleName, 6, "atribute mismatch")
+	ErrUnknownBid            = sdkerrors.Register(ModuleName, 7, "unknown bid")
+	ErrUnknownLeaseForBid    = sdkerrors.Register(ModuleName, 8, "unknown lease for bid")
+	ErrUnknownOrderForBid    = sdkerrors.Register(ModuleName, 9, "unknown order for bid")
+	ErrLeaseNotActive        = sdkerrors.Register(ModuleName, 10, "lease not active")
+	ErrBidNotMatched         = sdkerrors.Register(ModuleName, 11, "bid not matched")
+	ErrUnknownOrder          = sdkerrors.Register(ModuleName, 12, "unknown order")
+	ErrNoLeaseForOrder       = sdkerrors.Register(ModuleName, 13, "no lease for order")
+	ErrUnknownLease          = sdkerrors.Register(ModuleName, 14, "unknown lease")
+	ErrInvalidLeaseProvider  = sdkerrors.Register(ModuleName, 15, "invalid lease provider")
+	ErrLeaseCloseScheduled   = sdkerrors.Register(ModuleName, 16, "lease close already scheduled")
+	ErrInvalidLeasePrice     = sdkerrors.Register(ModuleName, 17, "invalid lease price")
+	ErrOrderNotOpen          = sdkerrors.Register(ModuleName, 18, "order not open")
+	ErrOrderLeased           = sdkerrors.Register(ModuleName, 19, "order has a lease")
+	ErrBidNotOpen            = sdkerrors.Register(ModuleName, 20, "bid not open")
+	ErrLeaseDurationExceeded = sdkerrors.Register(ModuleName, 21, "order duration exceeds bid maximum")
+	ErrTooManyOpenOrders     = sdkerrors.Register(ModuleName, 22, "owner has too many open orders")
+	ErrUnknownStoreVersion   = sdkerrors.Register(ModuleName, 23, "unknown store version")
+	ErrOrderExists           = sdkerrors.Register(ModuleName, 24, "order already exists")
+	ErrBidExists             = sdkerrors.Register(ModuleName, 25, "bid already exists")
+	ErrLeaseExists           = sdkerrors.Register(ModuleName, 26, "lease already exists")
+	ErrInvalidDeposit        = sdkerrors.Register(ModuleName, 27, "invalid bid deposit")
+	ErrInvalidTransition     = sdkerrors.Register(ModuleName, 28, "invalid state transition")
+	ErrLeaseUnderfunded      = sdkerrors.Register(ModuleName, 29, "insufficient funds for lease")
+	ErrInvalidBidProvider    = sdkerrors.Register(ModuleName, 30, "invalid bid provider")
+	ErrInvalidBidPrice       = sdkerrors.Register(ModuleName, 31, "bid price not positive")
+	ErrBidDenomMismatch      = sdkerrors.Register(ModuleName, 32, "bid price denomination not accepted by order")
+	ErrOrderSeqOverflow      = sdkerrors.Register(ModuleName, 33, "order sequence exhausted for group")
+	ErrOrderStartOverflow    = sdkerrors.Register(ModuleName, 34, "order start height overflows")
+	ErrBidDepositTooLow      = sdkerrors.Register(ModuleName, 35, "bid deposit below minimum")
 )
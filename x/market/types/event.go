@@ -11,13 +11,25 @@ import (
 const (
 	evActionOrderCreated = "order-created"
 	evActionOrderClosed  = "order-closed"
+	evActionOrderCancel  = "order-canceled"
 	evActionBidCreated   = "bid-created"
 	evActionBidClosed    = "bid-closed"
+	evActionBidLost      = "bid-lost"
 	evActionLeaseCreated = "lease-created"
 	evActionLeaseClosed  = "lease-closed"
 
+	evActionLeaseProviderClose        = "lease-provider-close"
+	evActionLeaseProviderUnresponsive = "lease-provider-unresponsive"
+	evActionLeasePriceUpdated         = "lease-price-updated"
+
 	evOSeqKey     = "oseq"
 	evProviderKey = "provider"
+	evCloseAtKey  = "close-at"
+	evPriceKey    = "price"
+	evDepositKey  = "deposit"
+
+	evRefundKey          = "refund"
+	evRefundRecipientKey = "refund-recipient"
 )
 
 type EventOrderCreated struct {
@@ -46,8 +58,23 @@ func (e EventOrderClosed) ToSDKEvent() sdk.Event {
 	)
 }
 
+// EventOrderCanceled is emitted when an owner cancels an order before it is leased.
+type EventOrderCanceled struct {
+	ID OrderID
+}
+
+func (e EventOrderCanceled) ToSDKEvent() sdk.Event {
+	return sdk.NewEvent(sdk.EventTypeMessage,
+		append([]sdk.Attribute{
+			sdk.NewAttribute(sdk.AttributeKeyModule, ModuleName),
+			sdk.NewAttribute(sdk.AttributeKeyAction, evActionOrderCancel),
+		}, OrderIDEVAttributes(e.ID)...)...,
+	)
+}
+
 type EventBidCreated struct {
-	ID BidID
+	ID      BidID
+	Deposit sdk.Coin
 }
 
 func (e EventBidCreated) ToSDKEvent() sdk.Event {
@@ -55,21 +82,69 @@ func (e EventBidCreated) ToSDKEvent() sdk.Event {
 		append([]sdk.Attribute{
 			sdk.NewAttribute(sdk.AttributeKeyModule, ModuleName),
 			sdk.NewAttribute(sdk.AttributeKeyAction, evActionBidCreated),
+			sdk.NewAttribute(evDepositKey, e.Deposit.String()),
 		}, BidIDEVAttributes(e.ID)...)...,
 	)
 }
 
+// EventBidClosed is emitted when a bid is closed.  Refund and Recipient are
+// set when the bid's deposit was refunded, and empty otherwise.
 type EventBidClosed struct {
-	ID BidID
+	ID        BidID
+	Refund    sdk.Coin
+	Recipient sdk.AccAddress
 }
 
 func (e EventBidClosed) ToSDKEvent() sdk.Event {
-	return sdk.NewEvent(sdk.EventTypeMessage,
-		append([]sdk.Attribute{
-			sdk.NewAttribute(sdk.AttributeKeyModule, ModuleName),
-			sdk.NewAttribute(sdk.AttributeKeyAction, evActionBidClosed),
-		}, BidIDEVAttributes(e.ID)...)...,
-	)
+	attrs := []sdk.Attribute{
+		sdk.NewAttribute(sdk.AttributeKeyModule, ModuleName),
+		sdk.NewAttribute(sdk.AttributeKeyAction, evActionBidClosed),
+	}
+	attrs = append(attrs, refundEVAttributes(e.Refund, e.Recipient)...)
+	return sdk.NewEvent(sdk.EventTypeMessage, append(attrs, BidIDEVAttributes(e.ID)...)...)
+}
+
+// EventBidLost is emitted when another bid wins the bid's order.  Refund and
+// Recipient are set when the bid's deposit was refunded, and empty otherwise.
+type EventBidLost struct {
+	ID        BidID
+	Refund    sdk.Coin
+	Recipient sdk.AccAddress
+}
+
+func (e EventBidLost) ToSDKEvent() sdk.Event {
+	attrs := []sdk.Attribute{
+		sdk.NewAttribute(sdk.AttributeKeyModule, ModuleName),
+		sdk.NewAttribute(sdk.AttributeKeyAction, evActionBidLost),
+	}
+	attrs = append(attrs, refundEVAttributes(e.Refund, e.Recipient)...)
+	return sdk.NewEvent(sdk.EventTypeMessage, append(attrs, BidIDEVAttributes(e.ID)...)...)
+}
+
+func refundEVAttributes(refund sdk.Coin, recipient sdk.AccAddress) []sdk.Attribute {
+	if recipient.Empty() {
+		return nil
+	}
+	return []sdk.Attribute{
+		sdk.NewAttribute(evRefundKey, refund.String()),
+		sdk.NewAttribute(evRefundRecipientKey, recipient.String()),
+	}
+}
+
+func parseRefundEVAttributes(attrs []sdk.Attribute) (sdk.Coin, sdk.AccAddress, error) {
+	value, err := sdkutil.GetString(attrs, evRefundKey)
+	if err != nil {
+		return sdk.Coin{}, nil, nil
+	}
+	refund, err := sdk.ParseCoin(value)
+	if err != nil {
+		return sdk.Coin{}, nil, err
+	}
+	recipient, err := sdkutil.GetAccAddress(attrs, evRefundRecipientKey)
+	if err != nil {
+		return sdk.Coin{}, nil, err
+	}
+	return refund, recipient, nil
 }
 
 type EventLeaseCreated struct {
@@ -98,6 +173,53 @@ func (e EventLeaseClosed) ToSDKEvent() sdk.Event {
 	)
 }
 
+// EventLeaseProviderClose is emitted when a provider schedules a lease to close.
+type EventLeaseProviderClose struct {
+	ID      LeaseID
+	CloseAt int64
+}
+
+func (e EventLeaseProviderClose) ToSDKEvent() sdk.Event {
+	return sdk.NewEvent(sdk.EventTypeMessage,
+		append([]sdk.Attribute{
+			sdk.NewAttribute(sdk.AttributeKeyModule, ModuleName),
+			sdk.NewAttribute(sdk.AttributeKeyAction, evActionLeaseProviderClose),
+			sdk.NewAttribute(evCloseAtKey, strconv.FormatInt(e.CloseAt, 10)),
+		}, LeaseIDEVAttributes(e.ID)...)...,
+	)
+}
+
+// EventLeaseProviderUnresponsive is emitted when a lease is closed after its
+// provider missed the heartbeat threshold.
+type EventLeaseProviderUnresponsive struct {
+	ID LeaseID
+}
+
+func (e EventLeaseProviderUnresponsive) ToSDKEvent() sdk.Event {
+	return sdk.NewEvent(sdk.EventTypeMessage,
+		append([]sdk.Attribute{
+			sdk.NewAttribute(sdk.AttributeKeyModule, ModuleName),
+			sdk.NewAttribute(sdk.AttributeKeyAction, evActionLeaseProviderUnresponsive),
+		}, LeaseIDEVAttributes(e.ID)...)...,
+	)
+}
+
+// EventLeasePriceUpdated is emitted when governance reprices a lease.
+type EventLeasePriceUpdated struct {
+	ID    LeaseID
+	Price sdk.Coin
+}
+
+func (e EventLeasePriceUpdated) ToSDKEvent() sdk.Event {
+	return sdk.NewEvent(sdk.EventTypeMessage,
+		append([]sdk.Attribute{
+			sdk.NewAttribute(sdk.AttributeKeyModule, ModuleName),
+			sdk.NewAttribute(sdk.AttributeKeyAction, evActionLeasePriceUpdated),
+			sdk.NewAttribute(evPriceKey, e.Price.String()),
+		}, LeaseIDEVAttributes(e.ID)...)...,
+	)
+}
+
 func OrderIDEVAttributes(id OrderID) []sdk.Attribute {
 	return append(dtypes.GroupIDEVAttributes(id.GroupID()),
 		sdk.NewAttribute(evOSeqKey, strconv.FormatUint(uint64(id.OSeq), 10)))
@@ -181,19 +303,47 @@ func ParseEvent(ev sdkutil.Event) (interface{}, error) {
 			return nil, err
 		}
 		return EventOrderClosed{ID: id}, nil
+	case evActionOrderCancel:
+		id, err := ParseEVOrderID(ev.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		return EventOrderCanceled{ID: id}, nil
 
 	case evActionBidCreated:
 		id, err := ParseEVBidID(ev.Attributes)
 		if err != nil {
 			return nil, err
 		}
-		return EventBidCreated{ID: id}, nil
+		value, err := sdkutil.GetString(ev.Attributes, evDepositKey)
+		if err != nil {
+			return nil, err
+		}
+		deposit, err := sdk.ParseCoin(value)
+		if err != nil {
+			return nil, err
+		}
+		return EventBidCreated{ID: id, Deposit: deposit}, nil
 	case evActionBidClosed:
 		id, err := ParseEVBidID(ev.Attributes)
 		if err != nil {
 			return nil, err
 		}
-		return EventBidClosed{ID: id}, nil
+		refund, recipient, err := parseRefundEVAttributes(ev.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		return EventBidClosed{ID: id, Refund: refund, Recipient: recipient}, nil
+	case evActionBidLost:
+		id, err := ParseEVBidID(ev.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		refund, recipient, err := parseRefundEVAttributes(ev.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		return EventBidLost{ID: id, Refund: refund, Recipient: recipient}, nil
 
 	case evActionLeaseCreated:
 		id, err := ParseEVLeaseID(ev.Attributes)
@@ -207,6 +357,36 @@ func ParseEvent(ev sdkutil.Event) (interface{}, error) {
 			return nil, err
 		}
 		return EventLeaseClosed{ID: id}, nil
+	case evActionLeaseProviderClose:
+		id, err := ParseEVLeaseID(ev.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		closeAt, err := sdkutil.GetUint64(ev.Attributes, evCloseAtKey)
+		if err != nil {
+			return nil, err
+		}
+		return EventLeaseProviderClose{ID: id, CloseAt: int64(closeAt)}, nil
+	case evActionLeaseProviderUnresponsive:
+		id, err := ParseEVLeaseID(ev.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		return EventLeaseProviderUnresponsive{ID: id}, nil
+	case evActionLeasePriceUpdated:
+		id, err := ParseEVLeaseID(ev.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		value, err := sdkutil.GetString(ev.Attributes, evPriceKey)
+		if err != nil {
+			return nil, err
+		}
+		price, err := sdk.ParseCoin(value)
+		if err != nil {
+			return nil, err
+		}
+		return EventLeasePriceUpdated{ID: id, Price: price}, nil
 
 	default:
 		return nil, sdkutil.ErrUnknownAction
@@ -1,7 +1,12 @@
 package types
 
+import "github.com/cosmos/cosmos-sdk/x/supply"
+
 const (
 	ModuleName = "market"
 	RouterKey  = ModuleName
 	StoreKey   = ModuleName
 )
+
+// EscrowAddress is the module account holding bid deposits.
+var EscrowAddress = supply.NewModuleAddress(ModuleName)
@@ -8,6 +8,12 @@ type MsgCreateBid struct {
 	Order    OrderID        `json:"order"`
 	Provider sdk.AccAddress `json:"owner"`
 	Price    sdk.Coin       `json:"price"`
+
+	// Longest lease, in blocks, the provider will hold.  Zero for no limit.
+	MaxDuration int64 `json:"max-duration,omitempty"`
+
+	// Escrowed from the provider until the bid is lost or closed.
+	Deposit sdk.Coin `json:"deposit"`
 }
 
 func (msg MsgCreateBid) Route() string { return RouterKey }
@@ -32,6 +38,14 @@ func (msg MsgCreateBid) ValidateBasic() error {
 		return ErrSameAccount
 	}
 
+	if msg.MaxDuration < 0 {
+		return ErrLeaseDurationExceeded
+	}
+
+	if !msg.Deposit.IsValid() {
+		return ErrInvalidDeposit
+	}
+
 	return nil
 }
 
@@ -51,6 +65,26 @@ func (msg MsgCloseBid) ValidateBasic() error {
 	return nil
 }
 
+// MsgWithdrawBid closes an open bid before it has been matched.
+type MsgWithdrawBid struct {
+	BidID `json:"id"`
+}
+
+func (msg MsgWithdrawBid) Route() string { return RouterKey }
+func (msg MsgWithdrawBid) Type() string  { return "withdraw-bid" }
+func (msg MsgWithdrawBid) GetSignBytes() []byte {
+	return sdk.MustSortJSON(cdc.MustMarshalJSON(msg))
+}
+func (msg MsgWithdrawBid) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Provider}
+}
+func (msg MsgWithdrawBid) ValidateBasic() error {
+	if msg.Provider.Empty() {
+		return ErrInvalidBidProvider
+	}
+	return nil
+}
+
 type MsgCloseOrder struct {
 	OrderID `json:"id"`
 }
@@ -66,3 +100,63 @@ func (msg MsgCloseOrder) GetSigners() []sdk.AccAddress {
 func (msg MsgCloseOrder) ValidateBasic() error {
 	return nil
 }
+
+// MsgCancelOrder closes an open order before it has been leased.
+type MsgCancelOrder struct {
+	OrderID `json:"id"`
+}
+
+func (msg MsgCancelOrder) Route() string { return RouterKey }
+func (msg MsgCancelOrder) Type() string  { return "cancel-order" }
+func (msg MsgCancelOrder) GetSignBytes() []byte {
+	return sdk.MustSortJSON(cdc.MustMarshalJSON(msg))
+}
+func (msg MsgCancelOrder) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Owner}
+}
+func (msg MsgCancelOrder) ValidateBasic() error {
+	if msg.Owner.Empty() {
+		return ErrInvalidOrder
+	}
+	return nil
+}
+
+// MsgProviderCloseLease requests a lease be closed by its provider after the notice window.
+type MsgProviderCloseLease struct {
+	LeaseID `json:"id"`
+}
+
+func (msg MsgProviderCloseLease) Route() string { return RouterKey }
+func (msg MsgProviderCloseLease) Type() string  { return "provider-close-lease" }
+func (msg MsgProviderCloseLease) GetSignBytes() []byte {
+	return sdk.MustSortJSON(cdc.MustMarshalJSON(msg))
+}
+func (msg MsgProviderCloseLease) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Provider}
+}
+func (msg MsgProviderCloseLease) ValidateBasic() error {
+	if msg.Provider.Empty() {
+		return ErrEmptyProvider
+	}
+	return nil
+}
+
+// MsgLeaseHeartbeat records that the provider is still serving a lease.
+type MsgLeaseHeartbeat struct {
+	LeaseID `json:"id"`
+}
+
+func (msg MsgLeaseHeartbeat) Route() string { return RouterKey }
+func (msg MsgLeaseHeartbeat) Type() string  { return "lease-heartbeat" }
+func (msg MsgLeaseHeartbeat) GetSignBytes() []byte {
+	return sdk.MustSortJSON(cdc.MustMarshalJSON(msg))
+}
+func (msg MsgLeaseHeartbeat) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Provider}
+}
+func (msg MsgLeaseHeartbeat) ValidateBasic() error {
+	if msg.Provider.Empty() {
+		return ErrEmptyProvider
+	}
+	return nil
+}
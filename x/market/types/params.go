@@ -0,0 +1,157 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/params"
+)
+
+const (
+	DefaultParamspace = ModuleName
+
+	DefaultOrderTTL int64 = 5 // blocks
+
+	DefaultLeaseCloseNotice        int64 = 100 // blocks
+	DefaultLeaseHeartbeatThreshold int64 = 600 // blocks
+
+	DefaultMaxOpenOrdersPerOwner uint32 = 0 // unlimited
+
+	DefaultDutchAuctionWindow int64  = 100 // blocks
+	DefaultDutchAuctionFloor  uint32 = 50  // percent
+)
+
+// DefaultMinBidDeposit requires no deposit.
+var DefaultMinBidDeposit = sdk.NewInt64Coin("akash", 0)
+
+var (
+	KeyOrderTTL                = []byte("OrderTTL")
+	KeyLeaseCloseNotice        = []byte("LeaseCloseNotice")
+	KeyLeaseHeartbeatThreshold = []byte("LeaseHeartbeatThreshold")
+	KeyMaxOpenOrdersPerOwner   = []byte("MaxOpenOrdersPerOwner")
+	KeyDutchAuctionWindow      = []byte("DutchAuctionWindow")
+	KeyDutchAuctionFloor       = []byte("DutchAuctionFloor")
+	KeyMinBidDeposit           = []byte("MinBidDeposit")
+)
+
+var _ params.ParamSet = (*Params)(nil)
+
+// Params are the governance-settable market parameters.
+type Params struct {
+	// blocks an order is open for bidding before it can be matched.
+	OrderTTL int64 `json:"order-ttl" yaml:"order_ttl"`
+
+	// blocks between a provider requesting a lease close and the lease closing.
+	LeaseCloseNotice int64 `json:"lease-close-notice" yaml:"lease_close_notice"`
+
+	// blocks without a provider heartbeat before an active lease is closed.
+	// zero disables the check.
+	LeaseHeartbeatThreshold int64 `json:"lease-heartbeat-threshold" yaml:"lease_heartbeat_threshold"`
+
+	// open orders a single owner may have at once.  zero disables the cap.
+	MaxOpenOrdersPerOwner uint32 `json:"max-open-orders-per-owner" yaml:"max_open_orders_per_owner"`
+
+	// blocks over which a Dutch auction order's ceiling price falls from the
+	// group's price limit to the floor.
+	DutchAuctionWindow int64 `json:"dutch-auction-window" yaml:"dutch_auction_window"`
+
+	// percent of the group's price limit a Dutch auction ceiling falls to.
+	DutchAuctionFloor uint32 `json:"dutch-auction-floor" yaml:"dutch_auction_floor"`
+
+	// deposit a provider must escrow with each bid.  a zero amount disables
+	// the minimum.
+	MinBidDeposit sdk.Coin `json:"min-bid-deposit" yaml:"min_bid_deposit"`
+}
+
+func ParamKeyTable() params.KeyTable {
+	return params.NewKeyTable().RegisterParamSet(&Params{})
+}
+
+func DefaultParams() Params {
+	return Params{
+		OrderTTL:                DefaultOrderTTL,
+		LeaseCloseNotice:        DefaultLeaseCloseNotice,
+		LeaseHeartbeatThreshold: DefaultLeaseHeartbeatThreshold,
+		MaxOpenOrdersPerOwner:   DefaultMaxOpenOrdersPerOwner,
+		DutchAuctionWindow:      DefaultDutchAuctionWindow,
+		DutchAuctionFloor:       DefaultDutchAuctionFloor,
+		MinBidDeposit:           DefaultMinBidDeposit,
+	}
+}
+
+func (p *Params) ParamSetPairs() params.ParamSetPairs {
+	return params.ParamSetPairs{
+		params.NewParamSetPair(KeyOrderTTL, &p.OrderTTL, validateBlockCount),
+		params.NewParamSetPair(KeyLeaseCloseNotice, &p.LeaseCloseNotice, validateBlockCount),
+		params.NewParamSetPair(KeyLeaseHeartbeatThreshold, &p.LeaseHeartbeatThreshold, validateBlockCount),
+		params.NewParamSetPair(KeyMaxOpenOrdersPerOwner, &p.MaxOpenOrdersPerOwner, validateOrderCount),
+		params.NewParamSetPair(KeyDutchAuctionWindow, &p.DutchAuctionWindow, validateBlockCount),
+		params.NewParamSetPair(KeyDutchAuctionFloor, &p.DutchAuctionFloor, validatePercent),
+		params.NewParamSetPair(KeyMinBidDeposit, &p.MinBidDeposit, validateDeposit),
+	}
+}
+
+func (p Params) Validate() error {
+	if err := validateBlockCount(p.OrderTTL); err != nil {
+		return err
+	}
+	if err := validateBlockCount(p.LeaseCloseNotice); err != nil {
+		return err
+	}
+	if err := validateBlockCount(p.LeaseHeartbeatThreshold); err != nil {
+		return err
+	}
+	if err := validateOrderCount(p.MaxOpenOrdersPerOwner); err != nil {
+		return err
+	}
+	if err := validateBlockCount(p.DutchAuctionWindow); err != nil {
+		return err
+	}
+	if err := validatePercent(p.DutchAuctionFloor); err != nil {
+		return err
+	}
+	if err := validateDeposit(p.MinBidDeposit); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateBlockCount(i interface{}) error {
+	v, ok := i.(int64)
+	if !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+	if v < 0 {
+		return fmt.Errorf("block count must not be negative: %v", v)
+	}
+	return nil
+}
+
+func validateOrderCount(i interface{}) error {
+	if _, ok := i.(uint32); !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+	return nil
+}
+
+func validatePercent(i interface{}) error {
+	v, ok := i.(uint32)
+	if !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+	if v > 100 {
+		return fmt.Errorf("percent must not exceed 100: %v", v)
+	}
+	return nil
+}
+
+func validateDeposit(i interface{}) error {
+	v, ok := i.(sdk.Coin)
+	if !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+	if !v.IsValid() {
+		return fmt.Errorf("invalid deposit: %v", v)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+)
+
+const (
+	ProposalTypeLeasePriceUpdate = "LeasePriceUpdate"
+)
+
+var _ govtypes.Content = LeasePriceUpdateProposal{}
+
+// LeasePriceUpdate sets a new price on an active lease.
+type LeasePriceUpdate struct {
+	LeaseID `json:"id"`
+	Price   sdk.Coin `json:"price"`
+}
+
+// LeasePriceUpdateProposal is a governance proposal to reprice active leases
+// in bulk, e.g. following a redenomination.
+type LeasePriceUpdateProposal struct {
+	Title       string             `json:"title" yaml:"title"`
+	Description string             `json:"description" yaml:"description"`
+	Updates     []LeasePriceUpdate `json:"updates" yaml:"updates"`
+}
+
+func (p LeasePriceUpdateProposal) GetTitle() string       { return p.Title }
+func (p LeasePriceUpdateProposal) GetDescription() string { return p.Description }
+func (p LeasePriceUpdateProposal) ProposalRoute() string  { return RouterKey }
+func (p LeasePriceUpdateProposal) ProposalType() string   { return ProposalTypeLeasePriceUpdate }
+
+func (p LeasePriceUpdateProposal) ValidateBasic() error {
+	if err := govtypes.ValidateAbstract(p); err != nil {
+		return err
+	}
+	if len(p.Updates) == 0 {
+		return ErrInvalidLeasePrice
+	}
+	for _, update := range p.Updates {
+		if !update.Price.IsValid() || update.Price.IsZero() {
+			return ErrInvalidLeasePrice
+		}
+	}
+	return nil
+}
+
+func (p LeasePriceUpdateProposal) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`Lease Price Update Proposal:
+  Title:       %s
+  Description: %s
+  Updates:
+`, p.Title, p.Description))
+	for _, update := range p.Updates {
+		b.WriteString(fmt.Sprintf("    %s/%v/%v/%v/%s: %s\n",
+			update.Owner, update.DSeq, update.GSeq, update.OSeq, update.Provider, update.Price))
+	}
+	return b.String()
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"bytes"
 	"fmt"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -23,6 +24,9 @@ type Order struct {
 	// block height to start matching
 	StartAt int64            `json:"start-at"`
 	Spec    dtypes.GroupSpec `json:"spec"`
+
+	// block height the order was created at
+	CreatedAt int64 `json:"created-at"`
 }
 
 func (obj Order) ID() OrderID {
@@ -44,6 +48,30 @@ func (o Order) Price() sdk.Coin {
 	return o.Spec.Price()
 }
 
+// DutchCeiling returns the highest bid price a Dutch auction order accepts
+// at height.  The ceiling is the group's price limit until StartAt, then
+// falls linearly over window blocks to floor percent of the limit, where it
+// stays.
+func (o Order) DutchCeiling(height, window int64, floor uint32) sdk.Coin {
+	limit := o.Spec.PriceLimit()
+	if limit.Denom == "" {
+		return limit
+	}
+
+	min := limit.Amount.MulRaw(int64(floor)).QuoRaw(100)
+
+	elapsed := height - o.StartAt
+	switch {
+	case elapsed <= 0:
+		return limit
+	case elapsed >= window:
+		return sdk.NewCoin(limit.Denom, min)
+	}
+
+	drop := limit.Amount.Sub(min).MulRaw(elapsed).QuoRaw(window)
+	return sdk.NewCoin(limit.Denom, limit.Amount.Sub(drop))
+}
+
 func (o Order) MatchAttributes(attrs []tmkv.Pair) bool {
 	return o.Spec.MatchAttributes(attrs)
 }
@@ -83,12 +111,37 @@ type Bid struct {
 	BidID `json:"id"`
 	State BidState `json:"state"`
 	Price sdk.Coin `json:"price"`
+
+	// block height at which the bid was placed.
+	CreatedAt int64 `json:"created-at"`
+
+	// longest lease, in blocks, the provider will hold.  Zero for no limit.
+	MaxDuration int64 `json:"max-duration,omitempty"`
+
+	// amount held in escrow while the bid is open or matched.
+	Deposit sdk.Coin `json:"deposit"`
 }
 
 func (obj Bid) ID() BidID {
 	return obj.BidID
 }
 
+// CheaperThan reports whether obj should be preferred over other.  Lower
+// prices win, then earlier bids.  Unlike sdk.Coin.IsLT it does not panic on
+// mismatched denominations; those are ordered by denomination instead.
+func (obj Bid) CheaperThan(other Bid) bool {
+	if obj.Price.Denom != other.Price.Denom {
+		return obj.Price.Denom < other.Price.Denom
+	}
+	if !obj.Price.Amount.Equal(other.Price.Amount) {
+		return obj.Price.Amount.LT(other.Price.Amount)
+	}
+	if obj.CreatedAt != other.CreatedAt {
+		return obj.CreatedAt < other.CreatedAt
+	}
+	return bytes.Compare(obj.Provider, other.Provider) < 0
+}
+
 type LeaseState uint8
 
 const (
@@ -97,12 +150,156 @@ const (
 	LeaseClosed            LeaseState = iota
 )
 
+type LeaseCloseReason string
+
+const (
+	LeaseCloseReasonProviderUnresponsive LeaseCloseReason = "provider-unresponsive"
+)
+
 type Lease struct {
 	LeaseID `json:"id"`
 	State   LeaseState `json:"state"`
 	Price   sdk.Coin   `json:"price"`
+
+	// block height at which a provider-initiated close takes effect.
+	CloseAt int64 `json:"close-at"`
+
+	// block height at which the provider's maximum lease duration is reached.
+	ExpiresAt int64 `json:"expires-at,omitempty"`
+
+	// block height of the last heartbeat recorded by the provider.
+	Heartbeat int64 `json:"heartbeat"`
+
+	CloseReason LeaseCloseReason `json:"close-reason,omitempty"`
+
+	// block heights at which the lease was created and closed.
+	CreatedAt int64 `json:"created-at"`
+	ClosedAt  int64 `json:"closed-at"`
+
+	// block height through which the provider has been paid.
+	SettledAt int64 `json:"settled-at,omitempty"`
+
+	// tags copied from the order's group spec.
+	Tags []tmkv.Pair `json:"tags,omitempty"`
 }
 
 func (obj Lease) ID() LeaseID {
 	return obj.LeaseID
 }
+
+// HasTag returns true if the lease is tagged with the given key and value.
+func (obj Lease) HasTag(key, value string) bool {
+	for _, tag := range obj.Tags {
+		if string(tag.Key) == key && string(tag.Value) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CloseDue returns true if a provider-initiated close is due, or the lease
+// has reached its maximum duration, at the given height.
+func (obj Lease) CloseDue(height int64) bool {
+	if obj.State != LeaseActive {
+		return false
+	}
+	return (obj.CloseAt != 0 && obj.CloseAt <= height) ||
+		(obj.ExpiresAt != 0 && obj.ExpiresAt <= height)
+}
+
+// HeartbeatMissed returns true if an active lease has gone at least threshold
+// blocks without a provider heartbeat.  A zero threshold disables the check.
+func (obj Lease) HeartbeatMissed(height, threshold int64) bool {
+	if obj.State != LeaseActive || threshold <= 0 {
+		return false
+	}
+
+	// leases created before heartbeats were recorded are counted from their
+	// creation, or from now if that wasn't recorded either.
+	last := obj.Heartbeat
+	if last == 0 {
+		last = obj.CreatedAt
+	}
+	if last == 0 {
+		last = height
+	}
+
+	return height-last >= threshold
+}
+
+// ProviderLeaseCount is the number of active leases held by a provider.
+type ProviderLeaseCount struct {
+	Provider sdk.AccAddress `json:"provider"`
+	Leases   uint32         `json:"leases"`
+}
+
+// ResourceTotals is the sum of resource units across a set of group specs.
+type ResourceTotals struct {
+	CPU     uint64 `json:"cpu"`
+	Memory  uint64 `json:"memory"`
+	Storage uint64 `json:"storage"`
+}
+
+// Add accumulates the resources requested by spec.
+func (t *ResourceTotals) Add(spec dtypes.GroupSpec) {
+	for _, res := range spec.Resources {
+		count := uint64(res.Count)
+		t.CPU += uint64(res.Unit.CPU) * count
+		t.Memory += res.Unit.Memory * count
+		t.Storage += res.Unit.Storage * count
+	}
+}
+
+// ResourceSupplyDemand compares resources requested by open orders with
+// resources committed to active leases.
+type ResourceSupplyDemand struct {
+	Demand    ResourceTotals `json:"demand"`
+	Committed ResourceTotals `json:"committed"`
+}
+
+// ProviderBidStats counts a provider's bids placed within a height range and
+// how many of them were won or lost.  WinRate is the fraction of decided (won
+// or lost) bids that were won.
+type ProviderBidStats struct {
+	Provider sdk.AccAddress `json:"provider"`
+	Bids     uint32         `json:"bids"`
+	Won      uint32         `json:"won"`
+	Lost     uint32         `json:"lost"`
+	WinRate  sdk.Dec        `json:"win-rate"`
+}
+
+// LeaseDurationStats summarizes the durations, in blocks, of closed leases.
+type LeaseDurationStats struct {
+	Count  uint32 `json:"count"`
+	Mean   int64  `json:"mean"`
+	Median int64  `json:"median"`
+}
+
+// PricePoint is the winning price of a lease created at Height.
+type PricePoint struct {
+	Height int64    `json:"height"`
+	Price  sdk.Coin `json:"price"`
+}
+
+// MarketEvent is an entry in the market event log.
+type MarketEvent struct {
+	Height int64           `json:"height"`
+	Event  sdk.StringEvent `json:"event"`
+}
+
+// PageRequest selects a page of a keyed iteration.  Key is the NextKey of the
+// previous page, empty for the first page.  Limit is capped by the keeper.
+// When CountTotal is set the response carries the number of items in the
+// whole collection.
+type PageRequest struct {
+	Key        []byte `json:"key,omitempty"`
+	Limit      uint64 `json:"limit,omitempty"`
+	CountTotal bool   `json:"count-total,omitempty"`
+}
+
+// PageResponse describes the page returned for a PageRequest.  NextKey is
+// empty after the last page.
+type PageResponse struct {
+	NextKey []byte `json:"next-key,omitempty"`
+	Total   uint64 `json:"total,omitempty"`
+}
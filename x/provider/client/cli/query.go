@@ -1,13 +1,25 @@
 package cli
 
 import (
+	gocontext "context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/context"
 	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/client/keys"
 	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/ovrclk/akash/provider"
+	mtypes "github.com/ovrclk/akash/x/market/types"
 	"github.com/ovrclk/akash/x/provider/query"
 	"github.com/ovrclk/akash/x/provider/types"
 	"github.com/spf13/cobra"
+	"github.com/tendermint/tendermint/crypto"
 )
 
 func GetQueryCmd(key string, cdc *codec.Codec) *cobra.Command {
@@ -22,6 +34,7 @@ func GetQueryCmd(key string, cdc *codec.Codec) *cobra.Command {
 
 	cmd.AddCommand(flags.GetCommands(
 		cmdGetProviders(key, cdc),
+		cmdGetLeaseStatus(key, cdc),
 	)...)
 
 	return cmd
@@ -40,3 +53,79 @@ func cmdGetProviders(key string, cdc *codec.Codec) *cobra.Command {
 		},
 	}
 }
+
+func cmdGetLeaseStatus(key string, cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lease-status <provider> <dseq> <gseq> <oseq>",
+		Short: "Query the cluster status of one of your leases from its provider",
+		Args:  cobra.ExactArgs(4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.NewCLIContext().WithCodec(cdc)
+
+			lid, err := parseLeaseID(ctx.GetFromAddress(), args)
+			if err != nil {
+				return err
+			}
+
+			gateway, err := providerHostURI(query.NewClient(ctx, key), lid.Provider)
+			if err != nil {
+				return err
+			}
+
+			kb := auth.NewTxBuilderFromCLI(os.Stdin).Keybase()
+			sign := func(msg []byte) ([]byte, crypto.PubKey, error) {
+				return kb.Sign(ctx.GetFromName(), keys.DefaultKeyPass, msg)
+			}
+
+			status, err := provider.FetchLeaseStatus(gocontext.Background(), gateway, lid, sign)
+			if err != nil {
+				return err
+			}
+			return ctx.PrintOutput(status)
+		},
+	}
+
+	cmd.Flags().String(flags.FlagFrom, "", "Name or address of the lease owner's key")
+
+	return cmd
+}
+
+func parseLeaseID(owner sdk.AccAddress, args []string) (mtypes.LeaseID, error) {
+	prov, err := sdk.AccAddressFromBech32(args[0])
+	if err != nil {
+		return mtypes.LeaseID{}, err
+	}
+	dseq, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return mtypes.LeaseID{}, err
+	}
+	gseq, err := strconv.ParseUint(args[2], 10, 32)
+	if err != nil {
+		return mtypes.LeaseID{}, err
+	}
+	oseq, err := strconv.ParseUint(args[3], 10, 32)
+	if err != nil {
+		return mtypes.LeaseID{}, err
+	}
+	return mtypes.LeaseID{
+		Owner:    owner,
+		DSeq:     dseq,
+		GSeq:     uint32(gseq),
+		OSeq:     uint32(oseq),
+		Provider: prov,
+	}, nil
+}
+
+// providerHostURI looks up the gateway address the provider registered.
+func providerHostURI(qclient query.Client, addr sdk.AccAddress) (*url.URL, error) {
+	providers, err := qclient.Providers()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range providers {
+		if p.Owner.Equals(addr) {
+			return url.Parse(p.HostURI)
+		}
+	}
+	return nil, fmt.Errorf("provider %v not found", addr)
+}